@@ -2,7 +2,9 @@ package blockchain
 
 import (
 	"bytes"
+	"context"
 	"encoding/gob"
+	"fmt"
 	"log"
 	"time"
 )
@@ -16,41 +18,60 @@ import (
  */
 
 type Block struct {
+	Version      int // Rules the block was mined under; 0 for blocks from before versioning (see header.go)
 	Timestamp    int64
 	Hash         []byte         // Hash representing this block
 	Transactions []*Transaction // The Data that this block stored. Transaction/Record/Document
 	PrevHash     []byte         // The Hash of the previous block in a BlockChain
 	Nonce        int            // The Nonce for validation of proof or work in a mining process
 	Height       int
+	PrunedRoot   []byte // Merkle root of the original transactions, set only once the block is pruned (see prune.go)
+}
+
+// IsPruned reports whether spent transactions have been removed from the block
+func (b *Block) IsPruned() bool {
+	return len(b.PrunedRoot) > 0
 }
 
 // HashTransactions Special function for hashing the transactions in a block for PoW validation
+// A block without transactions hashes to the empty Merkle root, SHA-256("")
+// A pruned block no longer holds every transaction, so its saved root is returned instead
 func (b *Block) HashTransactions() []byte {
+	if b.IsPruned() {
+		return b.PrunedRoot
+	}
+
 	var txHashes [][]byte
 
 	for _, tx := range b.Transactions {
-		txHashes = append(txHashes, tx.Serialize())
+		txHashes = append(txHashes, tx.merkleLeaf(b.Version))
 	}
 
 	tree := NewMerkleTree(txHashes)
 	return tree.RootNode.Data
 }
 
-// CreateBlock Special function for creating block
-func CreateBlock(txs []*Transaction, prevHash []byte, height int) *Block {
-	block := &Block{Timestamp: time.Now().Unix(), Hash: []byte{}, Transactions: txs, PrevHash: prevHash, Nonce: 0, Height: height} // Using block constructor
-	pow := NewProof(block)
-	nonce, hash := pow.Run()
-
-	block.Hash = hash[:]
-	block.Nonce = nonce
-
+// CreateBlock Special function for creating block, sealed by the given consensus engine
+func CreateBlock(engine Consensus, txs []*Transaction, prevHash []byte, height int) *Block {
+	block, err := CreateBlockWithContext(context.Background(), engine, txs, prevHash, height)
+	Handle(err)
 	return block
 }
 
+// CreateBlockWithContext creates a block like CreateBlock, but the sealing can be cancelled through ctx
+// Returns ErrMiningCancelled (and no block) if ctx is cancelled before the block is sealed
+func CreateBlockWithContext(ctx context.Context, engine Consensus, txs []*Transaction, prevHash []byte, height int) (*Block, error) {
+	block := &Block{Version: currentBlockVersion, Timestamp: time.Now().Unix(), Hash: []byte{}, Transactions: txs, PrevHash: prevHash, Nonce: 0, Height: height} // Using block constructor
+	engine.Prepare(block)
+	if err := sealBlock(ctx, engine, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
 // Genesis Special function for creating the genesis block
-func Genesis(coinbase *Transaction) *Block {
-	return CreateBlock([]*Transaction{coinbase}, []byte{}, 0)
+func Genesis(coinbase *Transaction, engine Consensus) *Block {
+	return CreateBlock(engine, []*Transaction{coinbase}, []byte{}, 0)
 }
 
 // Serialize Special function for serializing the data before storing to the key value database badgerDB
@@ -66,15 +87,21 @@ func (b *Block) Serialize() []byte {
 
 // Deserialize Special function for decoding the data retrieved from the key value database badgerDB
 func Deserialize(data []byte) *Block {
+	block, err := DeserializeE(data)
+	Handle(err)
+	return block
+}
+
+// DeserializeE decodes a block like Deserialize, but returns an error for corrupt data
+func DeserializeE(data []byte) (*Block, error) {
 	var block Block
 
 	decoder := gob.NewDecoder(bytes.NewReader(data))
 
-	err := decoder.Decode(&block)
-	if err != nil {
-		Handle(err)
+	if err := decoder.Decode(&block); err != nil {
+		return nil, fmt.Errorf("decode block: %w", err)
 	}
-	return &block
+	return &block, nil
 }
 
 func Handle(err error) {
@@ -0,0 +1,80 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/golang-blockchain/logger"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 11:30
+ */
+
+// BLOCK SIZE LIMIT
+// A block is stored and sent whole, so an unbounded one could outgrow what peers are
+// willing to read (MAX_MESSAGE_SIZE_MB) and stall propagation. No block may serialize
+// to more than MaxBlockSize bytes: miners stop adding transactions before the limit
+// (see FitsInBlock) and received blocks above it are rejected.
+// MaxBlockSize is read from the MAX_BLOCK_SIZE env. var., in bytes.
+const (
+	defaultMaxBlockSize = 1 << 20 // 1 MiB
+	minMaxBlockSize     = 4 << 10 // Room for the header and a handful of transactions
+
+	// blockOverhead bounds what a block serializes to beyond its transactions: the
+	// header fields and the gob type information of the block and transaction types
+	blockOverhead = 1024
+)
+
+// txTypeInfoSize is what Transaction.Serialize spends on gob type information, which
+// a block carries once rather than per transaction. A few bytes of message framing are
+// left in, so estimates stay on the large side
+var txTypeInfoSize = len(Transaction{}.Serialize()) - 8
+
+// ErrBlockTooLarge is returned for a block that serializes to more than MaxBlockSize bytes
+var ErrBlockTooLarge = errors.New("block too large")
+
+// MaxBlockSize is the largest serialized block, in bytes, that is mined or accepted
+var MaxBlockSize = loadMaxBlockSize()
+
+// loadMaxBlockSize reads the block size limit from the MAX_BLOCK_SIZE env. var.
+// Falls back to the default when the variable is unset, not a number or too small
+func loadMaxBlockSize() int {
+	value := os.Getenv("MAX_BLOCK_SIZE")
+	if value == "" {
+		return defaultMaxBlockSize
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size < minMaxBlockSize {
+		logger.Warn("Invalid MAX_BLOCK_SIZE, using the default", "value", value, "min", minMaxBlockSize, "default", defaultMaxBlockSize)
+		return defaultMaxBlockSize
+	}
+	return size
+}
+
+// FitsInBlock reports whether a block holding transactions stays within MaxBlockSize
+// The estimate errs on the large side, so a block it accepts is never rejected for size
+func FitsInBlock(transactions []*Transaction) bool {
+	size := blockOverhead
+	for _, tx := range transactions {
+		size += len(tx.Serialize()) - txTypeInfoSize
+		if size > MaxBlockSize {
+			return false
+		}
+	}
+	return true
+}
+
+// checkBlockSize rejects a block that serializes to more than MaxBlockSize bytes
+func checkBlockSize(block *Block) error {
+	if size := len(block.Serialize()); size > MaxBlockSize {
+		return fmt.Errorf("%w: block %x is %d bytes, limit %d", ErrBlockTooLarge, block.Hash, size, MaxBlockSize)
+	}
+	return nil
+}
@@ -10,27 +10,76 @@ package blockchain
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/dgraph-io/badger/v4"
+	"github.com/golang-blockchain/logger"
+	"github.com/golang-blockchain/metrics"
+	"github.com/golang-blockchain/wallet"
 )
 
 const (
-	dbPath      = "./tmp/blocks_%s"
+	dbPath      = "blocks_%s" // Relative to wallet.DataDir (DATA_DIR env. var.)
 	genesisData = "First Transaction from Genesis"
+
+	maxOpenAttempts  = 5                      // Tries at opening a locked database before giving up
+	openRetryBackoff = 100 * time.Millisecond // Wait after the first failed retry, doubled each time
 )
 
+// ErrDatabaseLocked means another process (or another chain in this one) has the database open
+var ErrDatabaseLocked = errors.New("database is in use by another process")
+
+// ErrNoBlockchain means the node has no blockchain database yet
+var ErrNoBlockchain = errors.New("no existing blockchain found")
+
+// BlockChain is shared by the network goroutines and the miner
+// Every change of tip (mining, adding, reorganizing, importing) holds tipMu from reading
+// the current tip to writing the new one, so two blocks can't both claim the same parent
+// as the tip. LastHash itself is guarded by mu; read it through tip()
 type BlockChain struct {
-	LastHash []byte     // The hash of the last block in the blockchain
-	Database *badger.DB // The database for storing the blockchain
+	LastHash   []byte     // The hash of the last block in the blockchain
+	Database   *badger.DB // The database for storing the blockchain
+	Difficulty int        // Proof-of-work difficulty, chosen when the chain was created
+	Consensus  Consensus  // Seals and verifies blocks; nil means proof of work at Difficulty
+
+	mu    sync.RWMutex // Guards LastHash
+	tipMu sync.Mutex   // Serializes the read-modify-write of the tip
+}
+
+// ErrStaleTip is returned when the tip moved while a block was being mined on it
+var ErrStaleTip = errors.New("chain tip moved while mining")
+
+// updateTip runs fn in the read-write database transaction that moves the tip
+// Badger may fail the commit (e.g. with ErrConflict) after fn returned; tests replace it to
+// make that happen on demand
+var updateTip = func(db *badger.DB, fn func(txn *badger.Txn) error) error {
+	return db.Update(fn)
+}
+
+// tip returns the hash of the last block in the active chain
+func (chain *BlockChain) tip() []byte {
+	chain.mu.RLock()
+	defer chain.mu.RUnlock()
+	return chain.LastHash
+}
+
+// setTip records hash as the last block in the active chain
+// The caller holds tipMu and has already moved the "lh" key
+func (chain *BlockChain) setTip(hash []byte) {
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	chain.LastHash = hash
 }
 
 // DBExists Special function for checking if the database file exists
@@ -43,45 +92,84 @@ func DBExists(path string) bool {
 
 // InitBlockChain Address passed below is the address of the miner which gets the reward for mining the first block
 func InitBlockChain(address, nodeID string) *BlockChain {
-	path := fmt.Sprintf(dbPath, nodeID)
-	if DBExists(path) {
+	chain, err := InitBlockChainWithGenesis(map[string]int{address: BlockReward(0)}, Difficulty, nodeID)
+	if errors.Is(err, ErrBlockchainExists) {
 		fmt.Println("BlockChain already exists!")
 		runtime.Goexit()
 	}
+	Handle(err)
+	return chain
+}
 
-	var lastHash []byte
+// InitBlockChainWithGenesis creates a blockchain whose genesis coinbase pays every
+// address in allocations its amount (a premine), see GenesisTx
+// Genesis outputs are coinbase outputs, so they mature like any block reward.
+// Every block of the chain is mined at difficulty, which is stored with it.
+// Returns ErrBlockchainExists if the node already has a blockchain
+func InitBlockChainWithGenesis(allocations map[string]int, difficulty int, nodeID string) (*BlockChain, error) {
+	path := wallet.DataPath(fmt.Sprintf(dbPath, nodeID))
+	if DBExists(path) {
+		return nil, fmt.Errorf("%w at %s", ErrBlockchainExists, path)
+	}
+	if err := checkDifficulty(difficulty); err != nil {
+		return nil, err
+	}
+
+	// Check the allocations before any file is created
+	cbTXN, err := GenesisTx(allocations)
+	if err != nil {
+		return nil, err
+	}
 
 	opts := badger.DefaultOptions(path).WithLogger(nil)
 	opts.Dir = path      // Key and metadata will be stored in this directory
 	opts.ValueDir = path // Value will be stored in this directory
 
 	db, err := openDB(path, opts)
-	Handle(err)
+	if err != nil {
+		return nil, err
+	}
 
+	genesis := Genesis(cbTXN, NewProofOfWork(difficulty))
 	err = db.Update(func(txn *badger.Txn) error {
-		cbTXN := CoinbaseTx(address, genesisData)
-		genesis := Genesis(cbTXN)
-		fmt.Println("Genesis block created")
-		err = txn.Set(genesis.Hash, genesis.Serialize())
-		Handle(err)
-		err = txn.Set([]byte("lh"), genesis.Hash)
-		lastHash = genesis.Hash
-		return err
+		return storeGenesis(txn, genesis, difficulty)
 	})
-	Handle(err)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	logger.Info("Genesis block created", "hash", hex.EncodeToString(genesis.Hash), "allocations", len(allocations), "difficulty", difficulty)
+
+	return &BlockChain{LastHash: genesis.Hash, Database: db, Difficulty: difficulty}, nil
+}
 
-	chain := BlockChain{lastHash, db}
-	return &chain
+// storeGenesis writes the genesis block to an empty database and makes it the tip
+// The chain's difficulty is recorded alongside it
+func storeGenesis(txn *badger.Txn, genesis *Block, difficulty int) error {
+	if err := storeDifficulty(txn, difficulty); err != nil {
+		return err
+	}
+	if err := txn.Set(genesis.Hash, genesis.Serialize()); err != nil {
+		return err
+	}
+	if err := txn.Set(heightKey(genesis.Height), genesis.Hash); err != nil {
+		return err
+	}
+	if err := indexTransactions(txn, genesis); err != nil {
+		return err
+	}
+	return txn.Set([]byte("lh"), genesis.Hash)
 }
 
 func ContinueBlockChain(nodeID string) *BlockChain {
-	path := fmt.Sprintf(dbPath, nodeID)
+	path := wallet.DataPath(fmt.Sprintf(dbPath, nodeID))
 	if DBExists(path) == false {
 		fmt.Println("No existing blockchain found, create a one!")
 		runtime.Goexit()
 	}
 
 	var lastHash []byte
+	var difficulty int
 	opts := badger.DefaultOptions(path)
 	opts.Dir = path      // Key and metadata will be stored in this directory
 	opts.ValueDir = path // Value will be stored in this directory
@@ -96,12 +184,54 @@ func ContinueBlockChain(nodeID string) *BlockChain {
 			lastHash = val
 			return nil
 		})
+		if err != nil {
+			return err
+		}
+		difficulty, err = loadDifficulty(txn)
 		return err
 	})
 	Handle(err)
 
-	chain := BlockChain{lastHash, db}
-	return &chain
+	chain := &BlockChain{LastHash: lastHash, Database: db, Difficulty: difficulty}
+
+	// Databases created before the height or transaction index existed need them built once
+	if _, err := chain.GetBlockHashByHeight(0); errors.Is(err, ErrHeightNotFound) {
+		chain.ReindexHeights()
+	}
+	if !chain.hasTxIndex() {
+		chain.ReindexTransactions()
+	}
+	return chain
+}
+
+// OpenBlockChainReadOnly opens nodeID's existing blockchain for inspection only
+// Unlike ContinueBlockChain it neither panics on a missing tip nor rebuilds missing
+// indexes, so a damaged database can be examined as it is; nothing is ever written
+// Returns ErrNoBlockchain when there is no database
+func OpenBlockChainReadOnly(nodeID string) (*BlockChain, error) {
+	path := wallet.DataPath(fmt.Sprintf(dbPath, nodeID))
+	if !DBExists(path) {
+		return nil, ErrNoBlockchain
+	}
+
+	opts := badger.DefaultOptions(path).WithReadOnly(true)
+	db, err := openDB(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var difficulty int
+	err = db.View(func(txn *badger.Txn) error {
+		difficulty, err = loadDifficulty(txn)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// LastHash stays empty: the tip pointer is what gets inspected, read it through tipHash
+	return &BlockChain{Database: db, Difficulty: difficulty}, nil
 }
 
 // GetBestHeight returns the height (block number) of the current blockchain tip
@@ -213,6 +343,44 @@ func (chain *BlockChain) GetBlockHashes() [][]byte {
 	return blocks
 }
 
+// FindCommonAncestor returns the hash of the most recent block shared by the branches
+// ending at hashA and hashB (the point where the two branches diverge)
+// If one block is an ancestor of the other, that block's hash is returned
+// Used for chain reorganizations and fork analysis
+func (chain *BlockChain) FindCommonAncestor(hashA, hashB []byte) ([]byte, error) {
+	blockA, err := chain.GetBlock(hashA)
+	if err != nil {
+		return nil, fmt.Errorf("block %x: %w", hashA, err)
+	}
+	blockB, err := chain.GetBlock(hashB)
+	if err != nil {
+		return nil, fmt.Errorf("block %x: %w", hashB, err)
+	}
+
+	// Walk both branches back via PrevHash until they meet
+	// The higher block always steps back first, so both sides reach equal heights
+	for !bytes.Equal(blockA.Hash, blockB.Hash) {
+		// Both sides already at genesis with different hashes: unrelated chains
+		if len(blockA.PrevHash) == 0 && len(blockB.PrevHash) == 0 {
+			return nil, errors.New("blocks do not share a common ancestor")
+		}
+
+		if blockA.Height >= blockB.Height && len(blockA.PrevHash) > 0 {
+			prevHash := blockA.PrevHash
+			if blockA, err = chain.GetBlock(prevHash); err != nil {
+				return nil, fmt.Errorf("block %x: %w", prevHash, err)
+			}
+		} else {
+			prevHash := blockB.PrevHash
+			if blockB, err = chain.GetBlock(prevHash); err != nil {
+				return nil, fmt.Errorf("block %x: %w", prevHash, err)
+			}
+		}
+	}
+
+	return blockA.Hash, nil
+}
+
 // MineBlock creates a new block containing validated transactions and adds it to the blockchain
 // This is the core mining function that:
 // 1. Validates all input transactions
@@ -220,18 +388,42 @@ func (chain *BlockChain) GetBlockHashes() [][]byte {
 // 3. Creates a new block with the transactions
 // 4. Updates the blockchain database with the new block
 func (chain *BlockChain) MineBlock(transactions []*Transaction) *Block {
+	newBlock, err := chain.MineBlockWithContext(context.Background(), transactions)
+	Handle(err)
+	return newBlock
+}
+
+// MineBlockWithContext mines a block like MineBlock, but stops when ctx is cancelled
+// A cancelled attempt returns ErrMiningCancelled and leaves the database untouched
+// A transaction that fails VerifyTransaction gives ErrInvalidBlockTransaction before any work
+func (chain *BlockChain) MineBlockWithContext(ctx context.Context, transactions []*Transaction) (*Block, error) {
 	var lastHash []byte // Hash of the most recent block in the chain
 	var lastHeight int  // Height/number of the most recent block
 
 	// Validate every transaction before including it in the block
 	// This prevents invalid transactions from being permanently recorded on the blockchain
 	for _, tx := range transactions {
+		// A transaction spending one output twice is refused outright, not just unverified
+		if err := tx.CheckInputs(); err != nil {
+			return nil, err
+		}
+
 		// Check if each transaction is cryptographically valid and follows blockchain rules
 		if chain.VerifyTransaction(tx) != true {
-			log.Panic("Invalid Transaction") // Stop everything if any transaction is invalid
+			return nil, fmt.Errorf("%w: transaction %x has unknown inputs or a bad signature", ErrInvalidBlockTransaction, tx.ID)
 		}
 	}
 
+	// Valid one by one isn't enough: two of them may spend the same output
+	if err := checkDoubleSpends(transactions); err != nil {
+		return nil, err
+	}
+
+	// Peers would reject the block, so don't waste the work
+	if !FitsInBlock(transactions) {
+		return nil, fmt.Errorf("%w: transactions exceed %d bytes", ErrBlockTooLarge, MaxBlockSize)
+	}
+
 	// Read the current blockchain state from the database
 	// Using a read-only transaction to safely retrieve the last block information
 	err := chain.Database.View(func(txn *badger.Txn) error {
@@ -261,43 +453,145 @@ func (chain *BlockChain) MineBlock(transactions []*Transaction) *Block {
 	})
 	Handle(err) // Exit if any database operation failed
 
+	// The miner must mint what the schedule allows for this height, plus the fees
+	if err := chain.VerifyCoinbase(transactions, lastHeight+1); err != nil {
+		return nil, err
+	}
+
 	// Create the new block with:
 	// - The validated transactions
 	// - Reference to the previous block's hash (lastHash)
 	// - Next sequential height (lastHeight + 1)
-	newBlock := CreateBlock(transactions, lastHash, lastHeight+1)
+	started := time.Now()
+	newBlock, err := CreateBlockWithContext(ctx, chain.ConsensusEngine(), transactions, lastHash, lastHeight+1)
+	if err != nil {
+		return nil, err // Mining was abandoned, nothing to store
+	}
+	metrics.ObserveMiningDuration(time.Since(started))
 
 	// Write the new block to the database
 	// Using a read-write transaction to update the blockchain state
-	err = chain.Database.Update(func(txn *badger.Txn) error {
+	chain.tipMu.Lock()
+	defer chain.tipMu.Unlock()
+	err = updateTip(chain.Database, func(txn *badger.Txn) error {
+		// A block that arrived while mining took the tip; this one no longer extends it
+		item, err := txn.Get([]byte("lh"))
+		if err != nil {
+			return err
+		}
+		currentHash, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(currentHash, lastHash) {
+			return fmt.Errorf("%w: mined on %x, tip is now %x", ErrStaleTip, lastHash, currentHash)
+		}
+
 		// Step 1: Store the new block using its hash as the key
 		// This allows a quick lookup of any block by its hash
-		err := txn.Set(newBlock.Hash, newBlock.Serialize())
+		err = txn.Set(newBlock.Hash, newBlock.Serialize())
 		Handle(err) // Exit if you can't store a block
 
+		// Index the block by height; it extends the tip, so no other height changes
+		err = txn.Set(heightKey(newBlock.Height), newBlock.Hash)
+		Handle(err)
+		err = indexTransactions(txn, newBlock)
+		Handle(err)
+
 		// Step 2: Update the "last hash" pointer to point to this new block
 		// This is how the chain maintains its current tip/head
 		err = txn.Set([]byte("lh"), newBlock.Hash)
 		Handle(err) // Exit if can't update pointer
 
-		// Step 3: Update in-memory reference for faster later access
-		// This avoids needing to read from a database for the next mining operation
-		chain.LastHash = newBlock.Hash
-
 		return err // Return any accumulated error
 	})
-	Handle(err) // Exit if any database update failed
+	if err != nil {
+		return nil, err // The tip moved, or the commit failed: nothing was stored
+	}
+
+	// Step 3: Update in-memory reference for faster later access, now that the write is committed
+	// Setting it inside the closure would move it even if the commit then failed
+	chain.setTip(newBlock.Hash)
+	metrics.IncBlocksMined()
 
 	// Return the newly created and stored block
-	return newBlock
+	return newBlock, nil
 }
 
 // AddBlock adds an existing block to the blockchain
 // This function is used when receiving blocks from other nodes in the network
 // Unlike MineBlock, it doesn't create a block, just validates and stores it
-func (chain *BlockChain) AddBlock(block *Block) {
+// Returns an error (and stores nothing) if the block fails ValidateBlock, or if it extends
+// the tip but spends an output the active chain already spent (ErrOutputSpent)
+// A block whose parent is unknown is queued as an orphan and ErrOrphanBlock is returned;
+// once the parent is added, every orphan that now connects is added too
+func (chain *BlockChain) AddBlock(block *Block) error {
+	// Refuse oversized blocks before doing any other work on them
+	if err := checkBlockSize(block); err != nil {
+		return err
+	}
+
+	// A block that isn't genesis must build on a block we already have
+	if len(block.PrevHash) > 0 && !chain.HasBlock(block.PrevHash) {
+		// Only queue blocks that carry real work, so the pool can't be filled for free
+		if err := chain.validateProofOfWork(block); err != nil {
+			return err
+		}
+		orphans.add(block)
+		return fmt.Errorf("%w: block %x waits for %x", ErrOrphanBlock, block.Hash, block.PrevHash)
+	}
+
+	if err := chain.storeBlock(block); err != nil {
+		return err
+	}
+
+	// Connect any orphans that were waiting for this block (and for them in turn)
+	parents := [][]byte{block.Hash}
+	for len(parents) > 0 {
+		parent := parents[0]
+		parents = parents[1:]
+
+		for _, child := range orphans.takeChildren(parent) {
+			if err := chain.storeBlock(child); err != nil {
+				logger.Warn("Dropped orphan block", "hash", hex.EncodeToString(child.Hash), "err", err)
+				continue
+			}
+			parents = append(parents, child.Hash)
+		}
+	}
+	return nil
+}
+
+// HasBlock reports whether a block with the given hash is stored in the database
+func (chain *BlockChain) HasBlock(blockHash []byte) bool {
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(blockHash)
+		return err
+	})
+	return err == nil
+}
+
+// storeBlock validates a block whose parent is known and writes it to the database
+// If the block becomes the new tip, the UTXO set is brought up to date in the same commit;
+// ErrOutputSpent is returned, and nothing stored, when the block spends an output the
+// active chain has already spent
+func (chain *BlockChain) storeBlock(block *Block) error {
+	// Never store a block we can't verify: a peer could send a forged one
+	if err := chain.ValidateBlock(block); err != nil {
+		return err
+	}
+
+	var extendsTip bool // The block builds directly on the previous tip and became the new one
+	var overtakes bool  // The block ends a side branch that now holds more work than the active chain
+
+	// Hold the tip from the work comparison until the UTXO set matches the new tip
+	chain.tipMu.Lock()
+	defer chain.tipMu.Unlock()
+
 	// Write transaction to potentially add the block
-	err := chain.Database.Update(func(txn *badger.Txn) error {
+	err := updateTip(chain.Database, func(txn *badger.Txn) error {
+		extendsTip, overtakes = false, false // Only what the committed run decided counts
+
 		// Step 1: Check if a block already exists in the database
 		// This prevents duplicate blocks and wasted storage
 		if _, err := txn.Get(block.Hash); err == nil {
@@ -312,7 +606,7 @@ func (chain *BlockChain) AddBlock(block *Block) {
 		Handle(err) // Exit if you can't store a block
 
 		// Step 3: Check if this block should become the new chain tip
-		// We only update the tip if this block builds on the current longest chain
+		// We only update the tip if this block's branch holds more work than the active chain
 		item, err := txn.Get([]byte("lh"))
 		Handle(err) // Exit if can't get current tip pointer
 
@@ -320,28 +614,97 @@ func (chain *BlockChain) AddBlock(block *Block) {
 		lastHash, err := item.ValueCopy(nil)
 		Handle(err) // Exit if you can't read the current tip hash
 
-		// Step 4: Get the current tip block to compare heights
-		item, err = txn.Get(lastHash)
-		Handle(err) // Exit if the current tip block doesn't exist
+		// Step 4: Compare the cumulative work of both branches (see work.go)
+		// The new block's total is recorded here so later blocks can build on it
+		blockWork, err := totalWork(txn, block.Hash, chain.Difficulty, true)
+		if err != nil {
+			return err
+		}
+		lastWork, err := totalWork(txn, lastHash, chain.Difficulty, true)
+		if err != nil {
+			return err
+		}
 
-		lastBlockData, _ := item.ValueCopy(nil) // Get current tip block data
-		lastBlock := Deserialize(lastBlockData) // Convert to Block struct
+		// Step 5: Update chain tip if the new block's branch has more total work
+		// This implements the "most work" rule of blockchain consensus
+		if blockWork.Cmp(lastWork) > 0 {
+			if !bytes.Equal(block.PrevHash, lastHash) {
+				// A competing branch overtook the active chain: ReorganizeChain switches to it below
+				overtakes = true
+				return nil
+			}
 
-		// Step 5: Update chain tip if the new block has greater height
-		// This implements the "longest chain" rule of blockchain consensus
-		if lastBlock.Height < block.Height {
-			// The new block is on a longer chain, update the tip
+			// The new block extends the active chain, update the tip
 			err = txn.Set([]byte("lh"), block.Hash)
 			Handle(err) // Exit if can't update tip pointer
 
-			// Update in-memory reference for consistency
-			chain.LastHash = block.Hash
+			// Index the block by height; it extends the tip, so no other height changes
+			err = indexActiveChain(txn, block)
+			Handle(err)
+
+			// Spend the block's inputs in the same commit as the tip: a block spending an
+			// output an earlier block already spent is refused, and nothing is stored
+			if err := applyBlock(txn, block); err != nil {
+				return fmt.Errorf("%w: block %x", err, block.Hash)
+			}
+
+			extendsTip = true
 		}
 
 		// Return success - block was either added or already existed
 		return nil
 	})
-	Handle(err) // Exit if any database operation failed
+	if err != nil {
+		return err
+	}
+
+	// Step 6: Keep the in-memory tip in step with the committed one (the UTXO set already is)
+	if extendsTip {
+		chain.setTip(block.Hash)
+	} else if overtakes {
+		return chain.reorganize(block)
+	}
+	return nil
+}
+
+// ValidateBlock checks a received block before it is stored
+// 1. The version must be known, and the proof of work must produce the block's own hash
+// 2. Every non-coinbase transaction must pass VerifyTransaction and spend no more than its inputs
+// 3. The timestamp must lie between the median time past and MaxFutureBlockTime from now
+// 4. The coinbase must mint the reward plus fees (see VerifyCoinbase)
+// 5. The block may not serialize to more than MaxBlockSize bytes
+// VerifyBlock runs the same rules (and more) one by one for diagnosis
+func (chain *BlockChain) ValidateBlock(block *Block) error {
+	// Step 1: Redo the single hash that proves the miner's work, under the block's rules
+	if err := chain.checkVersion(block); err != nil {
+		return err
+	}
+	if err := chain.validateProofOfWork(block); err != nil {
+		return err
+	}
+
+	// Step 2: Check the signatures, inputs and value balance of every transfer
+	for _, tx := range block.Transactions {
+		if err := chain.checkTransaction(tx, block.Version); err != nil {
+			return fmt.Errorf("%w: block %x", err, block.Hash)
+		}
+	}
+	if err := checkDoubleSpends(block.Transactions); err != nil {
+		return fmt.Errorf("%w: block %x", err, block.Hash)
+	}
+
+	// Step 3: Reject blocks dated before their ancestors or far in the future
+	if err := chain.checkTimestamp(block); err != nil {
+		return err
+	}
+
+	// Step 4: Check the miner's reward
+	if err := chain.verifyCoinbase(block.Transactions, block.Height, block.Version); err != nil {
+		return err
+	}
+
+	// Step 5: Keep blocks small enough to propagate
+	return checkBlockSize(block)
 }
 
 // FindUTXO scans the entire blockchain to build a complete map of all unspent transaction outputs
@@ -373,6 +736,11 @@ func (chain *BlockChain) FindUTXO() map[string]TxOutputs {
 			// Outputs create new UTXOs (unless they're spent later)
 		Outputs:
 			for outIdx, out := range tx.Outputs {
+				// Data outputs can never be spent, so they are never unspent outputs either
+				if out.IsData() {
+					continue
+				}
+
 				// Check if any outputs from this transaction have been marked as spent
 				if spentTXOs[txID] != nil {
 					// Verify THIS specific output index hasn't been spent
@@ -386,9 +754,10 @@ func (chain *BlockChain) FindUTXO() map[string]TxOutputs {
 
 				// If we reach here, this output is NOT spent (it's a UTXO!)
 				// Add it to our UTXO map for this transaction
-				outs := UTXO[txID]                       // Get existing outputs for this transaction
-				outs.Outputs = append(outs.Outputs, out) // Add this unspent output
-				UTXO[txID] = outs                        // Update map
+				outs := UTXO[txID]    // Get existing outputs for this transaction
+				outs.Add(outIdx, out) // Add this unspent output, remembering its index
+				outs.Height, outs.Coinbase = block.Height, tx.IsCoinbase()
+				UTXO[txID] = outs // Update map
 			}
 
 			// Check each INPUT in this transaction (if not coinbase)
@@ -416,9 +785,24 @@ func (chain *BlockChain) FindUTXO() map[string]TxOutputs {
 	return UTXO
 }
 
+// ErrTransactionNotFound is returned when no block on the chain contains a transaction
+var ErrTransactionNotFound = errors.New("transaction does not exist")
+
 // FindTransaction searches the entire blockchain for a specific transaction by ID
-// Returns the transaction if found, or an error if not found
+// Returns the transaction if found, or ErrTransactionNotFound if not found
 func (bc *BlockChain) FindTransaction(ID []byte) (Transaction, error) {
+	tx, _, err := bc.FindTransactionBlock(ID)
+	return tx, err
+}
+
+// FindTransactionBlock is FindTransaction that also returns the block containing the transaction
+// The transaction index answers the lookup; the chain is scanned only if the index is damaged
+func (bc *BlockChain) FindTransactionBlock(ID []byte) (Transaction, *Block, error) {
+	tx, block, err := bc.findIndexedTransaction(ID)
+	if err == nil || errors.Is(err, ErrTransactionNotFound) || errors.Is(err, ErrTransactionPruned) {
+		return tx, block, err
+	}
+
 	// Create an iterator to traverse blocks from newest to oldest
 	// This is more efficient than iterating from genesis when looking for recent transactions
 	iter := bc.Iterator()
@@ -434,7 +818,7 @@ func (bc *BlockChain) FindTransaction(ID []byte) (Transaction, error) {
 			// bytes.Compare returns 0 if the byte slices are equal
 			if bytes.Compare(tx.ID, ID) == 0 {
 				// Transaction found! Return a copy of it
-				return *tx, nil
+				return *tx, block, nil
 			}
 		}
 
@@ -446,12 +830,35 @@ func (bc *BlockChain) FindTransaction(ID []byte) (Transaction, error) {
 	}
 
 	// If we get here, the transaction was not found in any block
-	return Transaction{}, fmt.Errorf("transaction does not exist")
+	return Transaction{}, nil, ErrTransactionNotFound
+}
+
+// Confirmations returns how deeply a transaction is buried: 1 in the tip block, 2 one
+// block below it, and so on; 0 while it only waits in the (persistent) memory pool
+// Returns ErrTransactionNotFound when neither the chain nor the memory pool has it
+func (bc *BlockChain) Confirmations(txID []byte) (int, error) {
+	_, block, err := bc.FindTransactionBlock(txID)
+	if err == nil {
+		return bc.GetBestHeight() - block.Height + 1, nil
+	}
+	if !errors.Is(err, ErrTransactionNotFound) {
+		return 0, err
+	}
+
+	pooled, poolErr := bc.IsMempoolTx(txID)
+	if poolErr != nil {
+		return 0, poolErr
+	}
+	if pooled {
+		return 0, nil
+	}
+	return 0, err
 }
 
 // SignTransaction signs a transaction by finding all referenced previous transactions
 // and calling the transaction's Sign method with the private key
-func (bc *BlockChain) SignTransaction(tx *Transaction, privateKey ecdsa.PrivateKey) {
+// Returns an error instead of signing when an input spends an unknown output
+func (bc *BlockChain) SignTransaction(tx *Transaction, privateKey ecdsa.PrivateKey) error {
 	// Create a map to store previous transactions referenced by this transaction's inputs
 	// Key: Previous transaction ID (as hex string)
 	// Value: The actual Transaction object
@@ -461,7 +868,9 @@ func (bc *BlockChain) SignTransaction(tx *Transaction, privateKey ecdsa.PrivateK
 	for _, in := range tx.Inputs {
 		// Find the transaction that created the output this input is trying to spend
 		prevTX, err := bc.FindTransaction(in.ID)
-		Handle(err) // Panic if transaction not found (shouldn't happen in valid blockchain)
+		if err != nil {
+			return fmt.Errorf("input %x:%d: %w", in.ID, in.Out, err)
+		}
 
 		// Store it in the map using hex-encoded ID as a key
 		prevTXs[hex.EncodeToString(in.ID)] = prevTX
@@ -474,13 +883,36 @@ func (bc *BlockChain) SignTransaction(tx *Transaction, privateKey ecdsa.PrivateK
 	// 3. Hash the transaction
 	// 4. Create digital signatures
 	// 5. Store signatures back in the original transaction
-	tx.Sign(privateKey, prevTXs)
+	return tx.Sign(privateKey, prevTXs)
 }
 
 // VerifyTransaction checks if a transaction's signatures are valid
 // This is crucial for preventing unauthorized spending
+// The coinbase amount depends on the whole block and is checked by VerifyCoinbase
 func (bc *BlockChain) VerifyTransaction(tx *Transaction) bool {
-	// Coinbase transactions (mining rewards) don't need verification
+	return bc.verifyTransaction(tx, currentBlockVersion)
+}
+
+// verifyTransaction checks a transaction like VerifyTransaction, under the rules of a
+// block of the given version. Blocks from before versioning hold transactions whose IDs
+// were taken over gob bytes, before signing, so only later ones must have ID == Hash
+func (bc *BlockChain) verifyTransaction(tx *Transaction, version int) bool {
+	// The outputs will be stored under the ID, so it must be the transaction's own
+	if version != legacyBlockVersion && tx.CheckID() != nil {
+		return false
+	}
+
+	// No output may be negative (or zero), and their sum must not overflow
+	if err := tx.CheckOutputs(); err != nil {
+		return false
+	}
+
+	// Each input must spend a different output, or its value would be counted twice
+	if err := tx.CheckInputs(); err != nil {
+		return false
+	}
+
+	// Coinbase transactions (mining rewards) don't need signature verification
 	// They create new coins, not spend existing ones
 	if tx.IsCoinbase() {
 		return true
@@ -492,7 +924,15 @@ func (bc *BlockChain) VerifyTransaction(tx *Transaction) bool {
 	// Find all transactions that are being spent by this transaction's inputs
 	for _, in := range tx.Inputs {
 		prevTX, err := bc.FindTransaction(in.ID)
-		Handle(err) // If we can't find the transaction, it's invalid
+		if err != nil {
+			return false // Spends a transaction we don't know: invalid
+		}
+		if in.Out < 0 || in.Out >= len(prevTX.Outputs) {
+			return false // Spends an output that doesn't exist
+		}
+		if prevTX.Outputs[in.Out].IsData() {
+			return false // Data outputs can't be spent
+		}
 
 		// Store the previous transaction
 		prevTXs[hex.EncodeToString(in.ID)] = prevTX
@@ -504,30 +944,177 @@ func (bc *BlockChain) VerifyTransaction(tx *Transaction) bool {
 	// 2. For each input, reconstruct what was signed
 	// 3. Verify the digital signature using the public key
 	// 4. Return true only if ALL signatures are valid
-	return tx.Verify(prevTXs)
+	return tx.verify(prevTXs, version)
 }
 
-func retry(dir string, originalOpts badger.Options) (*badger.DB, error) {
-	lockPath := filepath.Join(dir, "LOCK")
-	if err := os.Remove(lockPath); err != nil {
-		return nil, fmt.Errorf("failed to remove lock file: %w", err)
+// Fee returns the amount a transaction leaves for the miner:
+// the value of the outputs it spends minus the value of the outputs it creates
+// Coinbase transactions spend nothing, so their fee is always zero
+func (bc *BlockChain) Fee(tx *Transaction) (int, error) {
+	if tx.IsCoinbase() {
+		return 0, nil
+	}
+
+	// Sum the value of every previous output this transaction spends
+	inputTotal := 0
+	for _, in := range tx.Inputs {
+		prevTX, err := bc.FindTransaction(in.ID)
+		if err != nil {
+			return 0, err
+		}
+		if in.Out < 0 || in.Out >= len(prevTX.Outputs) {
+			return 0, fmt.Errorf("transaction %x has no output %d", in.ID, in.Out)
+		}
+		inputTotal += prevTX.Outputs[in.Out].Value
 	}
-	retryOpts := originalOpts
-	db, err := badger.Open(retryOpts)
-	return db, err
+
+	// Sum the value of the new outputs
+	outputTotal := 0
+	for _, out := range tx.Outputs {
+		outputTotal += out.Value
+	}
+
+	return inputTotal - outputTotal, nil
 }
 
-func openDB(dir string, opts badger.Options) (*badger.DB, error) {
-	if db, err := badger.Open(opts); err != nil {
-		if strings.Contains(err.Error(), "LOCK") {
-			if db, err = retry(dir, opts); err == nil {
-				log.Println("database unlocked")
-				return db, nil
+// Fees returns the total fee the transactions leave for the miner, which their block's
+// coinbase claims on top of the reward (see CoinbaseTx)
+func (bc *BlockChain) Fees(transactions []*Transaction) (int, error) {
+	total := 0
+	for _, tx := range transactions {
+		fee, err := bc.Fee(tx)
+		if err != nil {
+			return 0, err
+		}
+		total += fee
+	}
+	return total, nil
+}
+
+// VerifyCoinbase checks that the coinbase in a block's transactions mints exactly the
+// scheduled reward for height plus the fees left by the other transactions
+// The block must hold exactly one coinbase, first, paying its reward to a single output,
+// as CoinbaseTx builds it
+// Returns ErrExcessiveCoinbase when the miner pays itself too much, ErrInvalidCoinbase
+// when it pays itself less or the coinbase is missing, repeated or out of place
+func (bc *BlockChain) VerifyCoinbase(transactions []*Transaction, height int) error {
+	return bc.verifyCoinbase(transactions, height, currentBlockVersion)
+}
+
+// verifyCoinbase checks the coinbase like VerifyCoinbase, under the rules of a block of
+// the given version. Coinbases of blocks from before versioning never claimed the fees,
+// so there the reward plus fees is only an upper bound, and they could put the coinbase
+// anywhere in the block
+func (bc *BlockChain) verifyCoinbase(transactions []*Transaction, height, version int) error {
+	allowed := BlockReward(height)
+	minted := 0
+
+	// Two identical coinbases would share one UTXO entry, and code taking everything after
+	// the first transaction as the block's transfers relies on the coinbase being first
+	if version != legacyBlockVersion {
+		if len(transactions) == 0 || !transactions[0].IsCoinbase() {
+			return fmt.Errorf("%w: the first transaction is not a coinbase", ErrInvalidCoinbase)
+		}
+		for i, tx := range transactions[1:] {
+			if tx.IsCoinbase() {
+				return fmt.Errorf("%w: transaction %d (%x) is a second coinbase", ErrInvalidCoinbase, i+1, tx.ID)
 			}
-			log.Println("could not unlock database: ", err)
 		}
-		return nil, err
-	} else {
+	}
+
+	for _, tx := range transactions {
+		if tx.IsCoinbase() {
+			if len(tx.Outputs) != 1 {
+				return fmt.Errorf("%w: coinbase %x has %d outputs, want 1", ErrInvalidCoinbase, tx.ID, len(tx.Outputs))
+			}
+			if size := len(tx.Inputs[0].PubKey); size > maxCoinbaseDataSize {
+				return fmt.Errorf("%w: coinbase %x carries %d bytes of data, limit %d", ErrInvalidCoinbase, tx.ID, size, maxCoinbaseDataSize)
+			}
+			for _, out := range tx.Outputs {
+				minted += out.Value
+			}
+			continue
+		}
+
+		// Every fee in the block may be claimed by the miner
+		fee, err := bc.Fee(tx)
+		if err != nil {
+			return err
+		}
+		allowed += fee
+	}
+
+	if minted > allowed {
+		return fmt.Errorf("%w: minted %d, allowed %d at height %d", ErrExcessiveCoinbase, minted, allowed, height)
+	}
+	if minted < allowed && version != legacyBlockVersion {
+		return fmt.Errorf("%w: minted %d, want the reward plus fees, %d, at height %d", ErrInvalidCoinbase, minted, allowed, height)
+	}
+	return nil
+}
+
+// isLockError reports whether badger refused to open because the directory lock is held
+func isLockError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "Cannot acquire directory lock")
+}
+
+// lockOwner returns the PID badger wrote to the database's LOCK file, or 0 if it can't be read
+func lockOwner(dir string) int {
+	data, err := os.ReadFile(filepath.Join(dir, "LOCK"))
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// retry reopens a locked database, for a node that is still shutting down
+// Each failed attempt waits twice as long as the previous one, up to maxOpenAttempts.
+// The LOCK file is never removed: badger's lock is released when its owner exits,
+// even after a crash, so a lock that is held means a live process has the database
+// open, and opening it a second time would corrupt it
+func retry(dir string, opts badger.Options) (*badger.DB, error) {
+	backoff := openRetryBackoff
+
+	var err error
+	for attempt := 1; attempt <= maxOpenAttempts; attempt++ {
+		logger.Warn("Database locked, retrying", "attempt", attempt, "maxAttempts", maxOpenAttempts, "backoff", backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+
+		var db *badger.DB
+		if db, err = badger.Open(opts); err == nil {
+			return db, nil
+		}
+		if !isLockError(err) {
+			return nil, err
+		}
+	}
+
+	if pid := lockOwner(dir); pid > 0 {
+		return nil, fmt.Errorf("%w: %s is locked by process %d; stop it (e.g. the running node) and try again", ErrDatabaseLocked, dir, pid)
+	}
+	return nil, fmt.Errorf("%w: %s is locked: %v", ErrDatabaseLocked, dir, err)
+}
+
+// openDB opens the badger database, waiting a little for a node that is shutting down
+// Returns ErrDatabaseLocked if it stays locked; any other open error is returned unchanged
+func openDB(dir string, opts badger.Options) (*badger.DB, error) {
+	db, err := badger.Open(opts)
+	if err == nil {
 		return db, nil
 	}
+	if !isLockError(err) {
+		return nil, err
+	}
+
+	if db, err = retry(dir, opts); err != nil {
+		logger.Error("Could not open the database", "err", err)
+		return nil, err
+	}
+	logger.Info("Database lock released, opened")
+	return db, nil
 }
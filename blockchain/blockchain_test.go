@@ -0,0 +1,431 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 10:10
+ */
+
+func testDBOptions(dir string) badger.Options {
+	return badger.DefaultOptions(dir).WithLogger(nil)
+}
+
+func TestOpenDBWithStaleLockFile(t *testing.T) {
+	dir := t.TempDir()
+
+	// A crashed node leaves its LOCK file behind, but the lock itself died with it
+	if err := os.WriteFile(filepath.Join(dir, "LOCK"), []byte("999999\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := openDB(dir, testDBOptions(dir))
+	if err != nil {
+		t.Fatalf("openDB with a stale LOCK file: %v", err)
+	}
+	db.Close()
+}
+
+func TestOpenDBNeverStealsALiveLock(t *testing.T) {
+	dir := t.TempDir()
+	owner, err := openDB(dir, testDBOptions(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer owner.Close()
+
+	_, err = openDB(dir, testDBOptions(dir))
+	if !errors.Is(err, ErrDatabaseLocked) {
+		t.Fatalf("second openDB = %v, want ErrDatabaseLocked", err)
+	}
+	if !strings.Contains(err.Error(), strconv.Itoa(os.Getpid())) {
+		t.Errorf("error %q doesn't name the owning process", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "LOCK")); err != nil {
+		t.Errorf("LOCK file of the live owner is gone: %v", err)
+	}
+}
+
+func TestOverValuedCoinbaseIsRejected(t *testing.T) {
+	sender, recipient, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	const fee = 5
+	tx := newTestTransfer(t, chain, sender, recipient, 30, fee)
+	height := chain.GetBestHeight() + 1
+
+	cases := map[string]struct {
+		fees int
+		want error
+	}{
+		"over the reward plus fees":  {fee + 1, ErrExcessiveCoinbase},
+		"millions":                   {1000000, ErrExcessiveCoinbase},
+		"under the reward plus fees": {0, ErrInvalidCoinbase},
+	}
+	for name, c := range cases {
+		coinbase := CoinbaseTx(string(miner.Address()), "", height, c.fees)
+		txs := []*Transaction{coinbase, tx}
+		if err := chain.VerifyCoinbase(txs, height); !errors.Is(err, c.want) {
+			t.Errorf("%s: VerifyCoinbase = %v, want %v", name, err, c.want)
+		}
+		if _, err := chain.MineBlockWithContext(t.Context(), txs); !errors.Is(err, c.want) {
+			t.Errorf("%s: MineBlockWithContext = %v, want %v", name, err, c.want)
+		}
+
+		block := CreateBlock(chain.ConsensusEngine(), txs, chain.tip(), height)
+		if err := chain.ValidateBlock(block); !errors.Is(err, c.want) {
+			t.Errorf("%s: ValidateBlock = %v, want %v", name, err, c.want)
+		}
+	}
+
+	// Blocks from before the fees were claimed may pay less, never more
+	exact := []*Transaction{CoinbaseTx(string(miner.Address()), "", height, fee), tx}
+	if err := chain.VerifyCoinbase(exact, height); err != nil {
+		t.Errorf("exact coinbase: %v", err)
+	}
+	legacy := []*Transaction{CoinbaseTx(string(miner.Address()), "", height, 0), tx}
+	if err := chain.verifyCoinbase(legacy, height, legacyBlockVersion); err != nil {
+		t.Errorf("legacy coinbase without the fees: %v", err)
+	}
+}
+
+func TestCoinbaseMustBeFirstAndAlone(t *testing.T) {
+	sender, recipient, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	const fee = 5
+	tx := newTestTransfer(t, chain, sender, recipient, 30, fee)
+	height := chain.GetBestHeight() + 1
+	coinbase := CoinbaseTx(string(miner.Address()), "", height, fee)
+
+	// Each mints no more than allowed in total
+	half := CoinbaseTx(string(miner.Address()), "", height, 0)
+	half.Outputs[0].Value = (BlockReward(height) + fee) / 2
+	half.SetID()
+	other := *half
+	other.Outputs = append([]TxOutput{}, half.Outputs...)
+	other.Outputs[0].Value = BlockReward(height) + fee - half.Outputs[0].Value
+	other.SetID()
+
+	cases := map[string][]*Transaction{
+		"no coinbase":         {tx},
+		"coinbase last":       {tx, coinbase},
+		"reward split in two": {half, tx, &other},
+		"identical coinbases": {half, half},
+	}
+	for name, txs := range cases {
+		if err := chain.VerifyCoinbase(txs, height); !errors.Is(err, ErrInvalidCoinbase) {
+			t.Errorf("%s: VerifyCoinbase = %v, want ErrInvalidCoinbase", name, err)
+		}
+		block := CreateBlock(chain.ConsensusEngine(), txs, chain.tip(), height)
+		if err := chain.AddBlock(block); !errors.Is(err, ErrInvalidCoinbase) {
+			t.Errorf("%s: AddBlock = %v, want ErrInvalidCoinbase", name, err)
+		}
+	}
+
+	// Blocks from before versioning put the coinbase last
+	if err := chain.verifyCoinbase([]*Transaction{tx, coinbase}, height, legacyBlockVersion); err != nil {
+		t.Errorf("legacy block with the coinbase last: %v", err)
+	}
+}
+
+// Run with -race: miners, a peer and readers all move or read the tip at once
+func TestConcurrentMinersAndPeersKeepOneTip(t *testing.T) {
+	miner := wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{miner: 100})
+
+	const miners, blocksEach = 3, 5
+	var wg sync.WaitGroup
+	for m := 0; m < miners; m++ {
+		wg.Add(1)
+		go func(m int) {
+			defer wg.Done()
+			for mined := 0; mined < blocksEach; {
+				height := chain.GetBestHeight() + 1
+				coinbase := CoinbaseTx(string(miner.Address()), fmt.Sprintf("miner %d block %d", m, mined), height, 0)
+				_, err := chain.MineBlockWithContext(t.Context(), []*Transaction{coinbase})
+				if errors.Is(err, ErrStaleTip) {
+					continue // Another miner got there first; try on the new tip
+				} else if err != nil {
+					t.Errorf("miner %d: %v", m, err)
+					return
+				}
+				mined++
+			}
+		}(m)
+	}
+
+	// A peer sending blocks built on whatever tip it last saw
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < blocksEach; i++ {
+			parent, err := chain.GetBlock(chain.tip())
+			if err != nil {
+				t.Errorf("peer block %d: %v", i, err)
+				return
+			}
+			coinbase := CoinbaseTx(string(miner.Address()), fmt.Sprintf("peer block %d", i), parent.Height+1, 0)
+			block := CreateBlock(chain.ConsensusEngine(), []*Transaction{coinbase}, parent.Hash, parent.Height+1)
+			if err := chain.AddBlock(block); err != nil && !errors.Is(err, ErrOrphanBlock) {
+				t.Errorf("peer block %d: %v", i, err)
+			}
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	for reading := true; reading; {
+		select {
+		case <-done:
+			reading = false
+		default:
+			chain.GetBestHeight()
+			chain.GetTotalWork()
+		}
+	}
+
+	if height := chain.GetBestHeight(); height < miners*blocksEach {
+		t.Errorf("height %d, want at least the %d mined blocks", height, miners*blocksEach)
+	}
+	stored, err := chain.tipHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored, chain.tip()) {
+		t.Errorf("in-memory tip %x, database tip %x", chain.tip(), stored)
+	}
+	if err := chain.VerifyIntegrity(); err != nil {
+		t.Errorf("VerifyIntegrity: %v", err)
+	}
+}
+
+// failCommits makes every tip update run to the end, then fail to commit with ErrConflict,
+// as badger does when another transaction wrote what this one read
+func failCommits(t *testing.T) {
+	update := updateTip
+	updateTip = func(db *badger.DB, fn func(txn *badger.Txn) error) error {
+		txn := db.NewTransaction(true)
+		defer txn.Discard()
+		if err := fn(txn); err != nil {
+			return err
+		}
+		return badger.ErrConflict
+	}
+	t.Cleanup(func() { updateTip = update })
+}
+
+func TestTipMovesOnlyOnCommit(t *testing.T) {
+	miner := wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{miner: 100})
+	tip, rewards := chain.tip(), balance(t, chain, miner)
+
+	// Built before commits start failing, so only the update of the tip is affected
+	block := CreateBlock(chain.ConsensusEngine(), []*Transaction{CoinbaseTx(string(miner.Address()), "peer", 1, 0)}, tip, 1)
+	failCommits(t)
+
+	coinbase := CoinbaseTx(string(miner.Address()), "", 1, 0)
+	if _, err := chain.MineBlockWithContext(t.Context(), []*Transaction{coinbase}); !errors.Is(err, badger.ErrConflict) {
+		t.Errorf("MineBlockWithContext = %v, want ErrConflict", err)
+	}
+	if err := chain.AddBlock(block); !errors.Is(err, badger.ErrConflict) {
+		t.Errorf("AddBlock = %v, want ErrConflict", err)
+	}
+
+	if !bytes.Equal(chain.tip(), tip) {
+		t.Errorf("tip moved to %x without a commit", chain.tip())
+	}
+	if stored, err := chain.tipHash(); err != nil || !bytes.Equal(stored, tip) {
+		t.Errorf("database tip = %x, %v; want %x", stored, err, tip)
+	}
+	if got := balance(t, chain, miner); got != rewards {
+		t.Errorf("UTXO set moved on: miner has %d, want %d", got, rewards)
+	}
+	if chain.HasBlock(block.Hash) {
+		t.Error("the block was stored without a commit")
+	}
+}
+
+func TestAddBlockRefusesTamperedBlocksAndCrossBlockDoubleSpends(t *testing.T) {
+	sender, recipient, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+	chain.Consensus = nil // Real proof of work, so a changed nonce shows
+
+	// Two payments spending the same genesis output
+	payment := newTestTransfer(t, chain, sender, recipient, 30, 1)
+	conflict := newTestTransfer(t, chain, sender, recipient, 20, 1)
+	block := func(height int, tx *Transaction) *Block {
+		coinbase := CoinbaseTx(string(miner.Address()), "", height, 1)
+		return CreateBlock(chain.ConsensusEngine(), []*Transaction{coinbase, tx}, chain.tip(), height)
+	}
+
+	badNonce := block(1, payment)
+	for NewProof(badNonce, chain.Difficulty).Validate() {
+		badNonce.Nonce++
+	}
+	if err := chain.AddBlock(badNonce); !errors.Is(err, ErrInvalidProofOfWork) {
+		t.Errorf("AddBlock with a bad nonce = %v, want ErrInvalidProofOfWork", err)
+	}
+
+	forged := *payment
+	forged.Inputs = append([]TxInput{}, payment.Inputs...)
+	forged.Inputs[0].Signature = append([]byte{}, payment.Inputs[0].Signature...)
+	forged.Inputs[0].Signature[0] ^= 0xff
+	forged.SetID()
+	if err := chain.AddBlock(block(1, &forged)); !errors.Is(err, ErrInvalidBlockTransaction) {
+		t.Errorf("AddBlock with a forged signature = %v, want ErrInvalidBlockTransaction", err)
+	}
+
+	valid := block(1, payment)
+	if err := chain.AddBlock(valid); err != nil {
+		t.Fatalf("AddBlock of a valid block: %v", err)
+	}
+
+	// Valid on its own, but its input was spent by the block before
+	doubleSpend := block(2, conflict)
+	if err := chain.AddBlock(doubleSpend); !errors.Is(err, ErrOutputSpent) {
+		t.Errorf("AddBlock of a cross-block double spend = %v, want ErrOutputSpent", err)
+	}
+	if chain.HasBlock(doubleSpend.Hash) || !bytes.Equal(chain.tip(), valid.Hash) {
+		t.Error("the double-spending block was stored or moved the tip")
+	}
+	if got := balance(t, chain, recipient); got != 30 {
+		t.Errorf("recipient has %d, want 30", got)
+	}
+	if err := chain.VerifyIntegrity(); err != nil {
+		t.Errorf("VerifyIntegrity: %v", err)
+	}
+}
+
+func TestMiningAnInvalidTransactionReturnsAnError(t *testing.T) {
+	sender, recipient, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	forged := newTestTransfer(t, chain, sender, recipient, 30, 0)
+	forged.Inputs[0].Signature[0] ^= 0xff
+	forged.SetID()
+
+	txs := []*Transaction{CoinbaseTx(string(miner.Address()), "", 1, 0), forged}
+	if _, err := chain.MineBlockWithContext(t.Context(), txs); !errors.Is(err, ErrInvalidBlockTransaction) {
+		t.Errorf("MineBlockWithContext = %v, want ErrInvalidBlockTransaction", err)
+	}
+	if height := chain.GetBestHeight(); height != 0 {
+		t.Errorf("height %d after a refused block, want 0", height)
+	}
+}
+
+func TestOrphansConnectOnceTheirParentArrives(t *testing.T) {
+	alice, bob, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{alice: 100})
+	pool := orphans
+	orphans = newOrphanPool()
+	t.Cleanup(func() { orphans = pool })
+	tip := chain.LastHash
+
+	parent := branchBlock(chain, miner, tip, 1, newTestTransfer(t, chain, alice, bob, 30, 1))
+	child := branchBlock(chain, miner, parent.Hash, 2)
+	grandchild := branchBlock(chain, miner, child.Hash, 3)
+
+	// The descendants arrive first, newest first: both wait, and the tip stays put
+	for _, block := range []*Block{grandchild, child} {
+		if err := chain.AddBlock(block); !errors.Is(err, ErrOrphanBlock) {
+			t.Fatalf("AddBlock(height %d) before its parent = %v, want ErrOrphanBlock", block.Height, err)
+		}
+		if chain.HasBlock(block.Hash) {
+			t.Errorf("orphan at height %d was stored", block.Height)
+		}
+	}
+	if OrphanCount() != 2 || !bytes.Equal(chain.LastHash, tip) {
+		t.Fatalf("%d orphans with tip %x, want 2 and the old tip", OrphanCount(), chain.LastHash)
+	}
+
+	// The parent connects both of them
+	if err := chain.AddBlock(parent); err != nil {
+		t.Fatalf("AddBlock(parent): %v", err)
+	}
+	if OrphanCount() != 0 {
+		t.Errorf("%d orphans still waiting", OrphanCount())
+	}
+	if !bytes.Equal(chain.LastHash, grandchild.Hash) {
+		t.Errorf("LastHash %x, want the last orphan's %x", chain.LastHash, grandchild.Hash)
+	}
+	genesis, err := chain.GetBlock(tip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkHeights(t, chain, []*Block{&genesis, parent, child, grandchild})
+	checkBalances(t, chain, map[*wallet.Wallet]int{alice: 69, bob: 30, miner: BlockReward(1) + BlockReward(2) + BlockReward(3) + 1})
+	checkUTXOFollowsChain(t, chain)
+}
+
+func TestChainLivesInDataDir(t *testing.T) {
+	miner := wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{miner: 100})
+	mineTestBlock(t, chain, miner)
+	tip := chain.LastHash
+	chain.Database.Close()
+
+	path := filepath.Join(wallet.DataDir, "blocks_test")
+	if !DBExists(path) {
+		t.Fatalf("no database at %s", path)
+	}
+
+	reopened := ContinueBlockChain("test")
+	defer reopened.Database.Close()
+	if !bytes.Equal(reopened.LastHash, tip) {
+		t.Errorf("reopened chain's tip %x, want %x", reopened.LastHash, tip)
+	}
+	if _, err := InitBlockChainWithGenesis(map[string]int{string(miner.Address()): 100}, MinDifficulty, "test"); !errors.Is(err, ErrBlockchainExists) {
+		t.Errorf("InitBlockChainWithGenesis over the existing chain = %v, want ErrBlockchainExists", err)
+	}
+}
+
+func TestGenesisPremineToThreeAddresses(t *testing.T) {
+	alice, bob, carol, dave := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	premine := map[*wallet.Wallet]int{alice: 100, bob: 250, carol: 1}
+	chain := newTestChain(t, premine)
+
+	genesis, err := chain.GetBlock(chain.LastHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(genesis.Transactions) != 1 || len(genesis.Transactions[0].Outputs) != len(premine) {
+		t.Fatalf("genesis holds %d transactions, want one coinbase with %d outputs", len(genesis.Transactions), len(premine))
+	}
+
+	// The reindex behind newTestChain found every allocation, and only those
+	checkBalances(t, chain, map[*wallet.Wallet]int{alice: 100, bob: 250, carol: 1, dave: 0})
+
+	// Reindexing again changes nothing, and premined coins spend like any others
+	if err := (UTXOSet{Blockchain: chain}).Reindex(); err != nil {
+		t.Fatal(err)
+	}
+	mineTestBlock(t, chain, dave, newTestTransfer(t, chain, bob, carol, 50, 5))
+	checkBalances(t, chain, map[*wallet.Wallet]int{alice: 100, bob: 195, carol: 51, dave: BlockReward(1) + 5})
+	checkUTXOFollowsChain(t, chain)
+
+	// The same allocations always make the same genesis transaction
+	addresses := map[string]int{string(alice.Address()): 100, string(bob.Address()): 250, string(carol.Address()): 1}
+	again, err := GenesisTx(addresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.ID, genesis.Transactions[0].ID) {
+		t.Errorf("GenesisTx of the same allocations = %x, want %x", again.ID, genesis.Transactions[0].ID)
+	}
+}
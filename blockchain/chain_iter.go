@@ -1,6 +1,11 @@
 package blockchain
 
-import "github.com/dgraph-io/badger/v4"
+import (
+	"fmt"
+	"io"
+
+	"github.com/dgraph-io/badger/v4"
+)
 
 /**
  * Created by GoLand.
@@ -18,22 +23,81 @@ type Iterator struct {
 
 // Iterator Special function for creating an iterator for iterating through the blockchain
 func (chain *BlockChain) Iterator() *Iterator {
-	iterator := &Iterator{chain.LastHash, chain.Database}
+	iterator := &Iterator{chain.tip(), chain.Database}
 	return iterator
 }
 
+// Next returns the current block and steps back to its parent
+// It panics on database errors; prefer NextBlock, which returns them
 func (iter *Iterator) Next() *Block {
+	block, err := iter.NextBlock()
+	Handle(err)
+	return block
+}
+
+// HasNext reports whether NextBlock has another block to return
+// It turns false once the genesis block has been returned
+func (iter *Iterator) HasNext() bool {
+	return len(iter.CurrentHash) > 0
+}
+
+// NextBlock returns the current block and steps back to its parent
+// Returns io.EOF once the genesis block has been passed, and the database or decoding
+// error (without moving) if the block can't be read
+func (iter *Iterator) NextBlock() (*Block, error) {
+	if !iter.HasNext() {
+		return nil, io.EOF
+	}
+
 	var block *Block
 	err := iter.Database.View(func(txn *badger.Txn) error {
 		item, err := txn.Get(iter.CurrentHash)
-		Handle(err)
+		if err != nil {
+			return err
+		}
 		return item.Value(func(val []byte) error {
-			block = Deserialize(val)
-			return nil
+			block, err = DeserializeE(val)
+			return err
 		})
 	})
-	Handle(err)
+	if err != nil {
+		return nil, fmt.Errorf("block %x: %w", iter.CurrentHash, err)
+	}
 
 	iter.CurrentHash = block.PrevHash // Since it is going backward until genesis block
-	return block
+	return block, nil
+}
+
+// ForwardIterator walks the active chain from genesis towards the tip using the height index
+// It stops at the tip height it saw when created, so blocks mined meanwhile aren't returned
+type ForwardIterator struct {
+	chain      *BlockChain
+	nextHeight int // Height of the block the next call returns
+	tipHeight  int // Height of the last block to return
+}
+
+// ForwardIterator creates an iterator yielding the active chain's blocks in increasing height order
+func (chain *BlockChain) ForwardIterator() *ForwardIterator {
+	return &ForwardIterator{chain: chain, tipHeight: chain.GetBestHeight()}
+}
+
+// HasNext reports whether Next has another block to return
+func (iter *ForwardIterator) HasNext() bool {
+	return iter.nextHeight <= iter.tipHeight
+}
+
+// Next returns the block at the next height and advances towards the tip
+// Returns io.EOF once the tip has been returned
+func (iter *ForwardIterator) Next() (*Block, error) {
+	if !iter.HasNext() {
+		return nil, io.EOF
+	}
+
+	block, err := iter.chain.blockAtHeight(iter.nextHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	iter.nextHeight++
+	return block, nil
 }
@@ -0,0 +1,54 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 13:00
+ */
+
+func TestForwardIteratorYieldsIncreasingHeights(t *testing.T) {
+	miner := wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{miner: 100})
+	for i := 0; i < 3; i++ {
+		mineTestBlock(t, chain, miner)
+	}
+
+	iter := chain.ForwardIterator()
+	mineTestBlock(t, chain, miner) // Mined after the iterator was created, so not returned
+
+	var prev *Block
+	for height := 0; height <= 3; height++ {
+		if !iter.HasNext() {
+			t.Fatalf("iterator ended before height %d", height)
+		}
+		block, err := iter.Next()
+		if err != nil {
+			t.Fatalf("Next at height %d: %v", height, err)
+		}
+		if block.Height != height {
+			t.Fatalf("block at step %d has height %d", height, block.Height)
+		}
+		if prev != nil && !bytes.Equal(block.PrevHash, prev.Hash) {
+			t.Fatalf("block %d does not build on block %d", height, height-1)
+		}
+		prev = block
+	}
+
+	if iter.HasNext() {
+		t.Error("iterator continues past the tip it started with")
+	}
+	if _, err := iter.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Next past the tip = %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,113 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"sort"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 22/12/2025
+ * Time: 10:20
+ */
+
+// COIN SELECTION
+// Which of the owner's outputs a payment spends decides how many inputs the
+// transaction carries (its size) and how much change comes back. FindSpendableOutputs
+// takes one of these strategies:
+//
+//	FirstFit:     UTXO key order until the amount is covered (the original behaviour)
+//	LargestFirst: biggest outputs first, fewest inputs, but often large change
+//	BestFit:      the smallest single output that covers the amount; otherwise
+//	              largest-first, then drops inputs that turn out to be unnecessary
+type CoinSelection int
+
+const (
+	FirstFit CoinSelection = iota
+	LargestFirst
+	BestFit
+)
+
+// String returns the strategy name, as used in log output
+func (s CoinSelection) String() string {
+	switch s {
+	case FirstFit:
+		return "first-fit"
+	case LargestFirst:
+		return "largest-first"
+	case BestFit:
+		return "best-fit"
+	}
+	return "unknown"
+}
+
+// selectCoins picks outputs from candidates (in UTXO key order) worth at least amount
+// Returns the total picked; when the candidates can't cover the amount, all of them are
+// picked so callers can report how much is available
+func selectCoins(candidates []UTXORef, amount int, strategy CoinSelection) (int, []UTXORef) {
+	switch strategy {
+	case LargestFirst:
+		return accumulate(largestFirst(candidates), amount)
+
+	case BestFit:
+		// One output covering the whole amount: take the one leaving the least change
+		best := -1
+		for i, ref := range candidates {
+			if ref.Value >= amount && (best < 0 || ref.Value < candidates[best].Value) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			return candidates[best].Value, []UTXORef{candidates[best]}
+		}
+
+		// Otherwise combine the largest outputs, then give back the smallest ones we
+		// didn't actually need (this is what keeps dust out of the change)
+		total, picked := accumulate(largestFirst(candidates), amount)
+		for i := len(picked) - 1; i >= 0 && total >= amount; i-- {
+			if total-picked[i].Value >= amount {
+				total -= picked[i].Value
+				picked = append(picked[:i], picked[i+1:]...)
+			}
+		}
+		return total, picked
+	}
+
+	return accumulate(candidates, amount)
+}
+
+// accumulate takes outputs in the given order until their total covers amount
+func accumulate(candidates []UTXORef, amount int) (int, []UTXORef) {
+	var picked []UTXORef
+	total := 0
+	for _, ref := range candidates {
+		if total >= amount {
+			break
+		}
+		total += ref.Value
+		picked = append(picked, ref)
+	}
+	return total, picked
+}
+
+// largestFirst returns a copy of refs ordered by value, biggest first (ties keep UTXO key order)
+func largestFirst(refs []UTXORef) []UTXORef {
+	sorted := append([]UTXORef{}, refs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Value > sorted[j].Value
+	})
+	return sorted
+}
+
+// outputsByTx groups picked outputs the way transaction builders expect them:
+// hex transaction ID -> output indices
+func outputsByTx(refs []UTXORef) map[string][]int {
+	grouped := make(map[string][]int)
+	for _, ref := range refs {
+		txID := hex.EncodeToString(ref.TxID)
+		grouped[txID] = append(grouped[txID], ref.OutIdx)
+	}
+	return grouped
+}
@@ -0,0 +1,50 @@
+package blockchain
+
+import (
+	"math/rand"
+	"testing"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 11:10
+ */
+
+// syntheticUTXO returns n outputs, mostly dust with a few large ones, like a wallet that
+// received many small payments
+func syntheticUTXO(n int) []UTXORef {
+	rng := rand.New(rand.NewSource(1))
+	refs := make([]UTXORef, n)
+	for i := range refs {
+		value := 1 + rng.Intn(10)
+		if i%20 == 0 {
+			value = 100 + rng.Intn(900)
+		}
+		refs[i] = UTXORef{TxID: []byte{byte(i >> 8), byte(i)}, OutIdx: i % 3, Value: value}
+	}
+	return refs
+}
+
+// BenchmarkCoinSelection compares the inputs and change each strategy ends up with
+// Run with -bench CoinSelection and compare the inputs/op and change/op columns
+func BenchmarkCoinSelection(b *testing.B) {
+	candidates := syntheticUTXO(1000)
+	amounts := []int{5, 50, 500, 2000}
+
+	for _, strategy := range []CoinSelection{FirstFit, LargestFirst, BestFit} {
+		b.Run(strategy.String(), func(b *testing.B) {
+			inputs, change := 0, 0
+			for i := 0; i < b.N; i++ {
+				amount := amounts[i%len(amounts)]
+				total, picked := selectCoins(candidates, amount, strategy)
+				inputs += len(picked)
+				change += total - amount
+			}
+			b.ReportMetric(float64(inputs)/float64(b.N), "inputs/op")
+			b.ReportMetric(float64(change)/float64(b.N), "change/op")
+		})
+	}
+}
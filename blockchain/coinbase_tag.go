@@ -0,0 +1,79 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 19:30
+ */
+
+// MINER TAGS
+// The coinbase input carries no signature, only free-form data (see CoinbaseTx). That
+// data is a random nonce, which keeps coinbase IDs unique when the same miner is paid the
+// same reward twice. A miner may put a tag in front of it to sign its blocks:
+//
+//	"MyPool/3f9c...e1"   tag "MyPool"
+//	"3f9c...e1"          no tag (and every block mined before tags existed)
+//
+// Tags are limited to MaxMinerTagSize bytes of UTF-8; blocks whose coinbase data is
+// longer than a tag and nonce together are rejected (see VerifyCoinbase).
+const (
+	MaxMinerTagSize = 40 // Longest miner tag, in bytes
+
+	coinbaseNonceSize = 24  // Random bytes in the coinbase data, hex encoded
+	minerTagSeparator = "/" // Ends the tag; the nonce never contains it
+
+	maxCoinbaseDataSize = MaxMinerTagSize + len(minerTagSeparator) + 2*coinbaseNonceSize
+)
+
+// ErrInvalidMinerTag is returned for a tag that is too long or isn't valid UTF-8
+var ErrInvalidMinerTag = errors.New("invalid miner tag")
+
+// ValidateMinerTag checks that tag may be put in a coinbase
+func ValidateMinerTag(tag string) error {
+	if len(tag) > MaxMinerTagSize {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrInvalidMinerTag, len(tag), MaxMinerTagSize)
+	}
+	if !utf8.ValidString(tag) {
+		return fmt.Errorf("%w: not valid UTF-8", ErrInvalidMinerTag)
+	}
+	return nil
+}
+
+// coinbaseData joins a miner tag and a hex nonce into the data of a coinbase input
+func coinbaseData(tag, nonce string) string {
+	if tag == "" {
+		return nonce
+	}
+	return tag + minerTagSeparator + nonce
+}
+
+// MinerTag returns the tag the miner put in this coinbase, or "" if there is none
+func (tx *Transaction) MinerTag() string {
+	if !tx.IsCoinbase() {
+		return ""
+	}
+	data := string(tx.Inputs[0].PubKey) // CoinbaseTx puts the data where a spender's key goes
+	if i := strings.LastIndex(data, minerTagSeparator); i >= 0 {
+		return data[:i]
+	}
+	return ""
+}
+
+// MinerTag returns the tag in the block's coinbase, or "" if there is none
+func (b *Block) MinerTag() string {
+	for _, tx := range b.Transactions {
+		if tx.IsCoinbase() {
+			return tx.MinerTag()
+		}
+	}
+	return ""
+}
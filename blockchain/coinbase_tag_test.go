@@ -0,0 +1,58 @@
+package blockchain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 14:50
+ */
+
+func TestMinerTagSurvivesMiningAndSerialization(t *testing.T) {
+	miner := wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{miner: 100})
+
+	// The separator may appear in a tag: the nonce after the last one never contains it
+	for _, tag := range []string{"MyPool", "pool/eu-1", "矿池", strings.Repeat("x", MaxMinerTagSize), ""} {
+		if err := ValidateMinerTag(tag); err != nil {
+			t.Fatalf("ValidateMinerTag(%q) = %v", tag, err)
+		}
+		coinbase := CoinbaseTx(string(miner.Address()), tag, chain.GetBestHeight()+1, 0)
+		mined, err := chain.MineBlockWithContext(t.Context(), []*Transaction{coinbase})
+		if err != nil {
+			t.Fatalf("mine block tagged %q: %v", tag, err)
+		}
+
+		stored, err := chain.GetBlock(mined.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, err := DeserializeE(stored.Serialize())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := block.MinerTag(); got != tag {
+			t.Errorf("block tagged %q reads back %q", tag, got)
+		}
+	}
+
+	for _, tag := range []string{strings.Repeat("x", MaxMinerTagSize+1), "\xff\xfe"} {
+		if err := ValidateMinerTag(tag); !errors.Is(err, ErrInvalidMinerTag) {
+			t.Errorf("ValidateMinerTag(%q) = %v, want ErrInvalidMinerTag", tag, err)
+		}
+	}
+
+	// A tag past the limit makes the coinbase too big to be accepted in a block
+	coinbase := CoinbaseTx(string(miner.Address()), strings.Repeat("x", MaxMinerTagSize+1), chain.GetBestHeight()+1, 0)
+	if err := chain.VerifyCoinbase([]*Transaction{coinbase}, chain.GetBestHeight()+1); !errors.Is(err, ErrInvalidCoinbase) {
+		t.Errorf("VerifyCoinbase = %v, want ErrInvalidCoinbase", err)
+	}
+}
@@ -0,0 +1,121 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/sha256"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 18:10
+ */
+
+// CONSENSUS ENGINES
+// Block creation and validation go through a Consensus engine instead of calling the
+// proof of work directly, so another scheme (e.g. proof of stake) can replace it without
+// touching block storage, the UTXO set or the network code:
+//
+//	CreateBlock ── Prepare ── Seal ──▶ block with Nonce and Hash set
+//	AddBlock ───── ValidateBlock ── Verify
+//
+// Whatever the engine, a block's Hash is computed from its version, previous hash, Merkle
+// root, nonce and the chain's difficulty (Block.headerBytes); checkMerkleRoot relies on it.
+// ProofOfWorkConsensus is the default; NoOpConsensus seals instantly, for tests.
+
+// Consensus seals new blocks and verifies received ones
+type Consensus interface {
+	Prepare(block *Block)     // Resets the fields the engine fills in, before sealing
+	Seal(block *Block) error  // Sets the block's Nonce and Hash so that Verify accepts it
+	Verify(block *Block) bool // Reports whether the block's seal is valid
+}
+
+// ContextSealer is implemented by engines whose Seal can take long and be abandoned
+// CreateBlockWithContext uses it when available, and plain Seal otherwise
+type ContextSealer interface {
+	SealWithContext(ctx context.Context, block *Block) error
+}
+
+// ProofOfWorkConsensus is the HashCash proof of work (see proof.go)
+type ProofOfWorkConsensus struct {
+	Difficulty int // Leading zero bits a block hash needs
+	Workers    int // Goroutines mining in parallel (see RunParallel)
+}
+
+// NewProofOfWork returns the proof of work at difficulty, mining with MiningWorkers goroutines
+func NewProofOfWork(difficulty int) *ProofOfWorkConsensus {
+	return &ProofOfWorkConsensus{Difficulty: difficulty, Workers: MiningWorkers}
+}
+
+// Prepare clears any earlier nonce and hash
+func (p *ProofOfWorkConsensus) Prepare(block *Block) {
+	block.Nonce = 0
+	block.Hash = []byte{}
+}
+
+// Seal mines the block
+func (p *ProofOfWorkConsensus) Seal(block *Block) error {
+	return p.SealWithContext(context.Background(), block)
+}
+
+// SealWithContext mines the block, returning ErrMiningCancelled if ctx is cancelled first
+func (p *ProofOfWorkConsensus) SealWithContext(ctx context.Context, block *Block) error {
+	nonce, hash, err := NewProof(block, p.Difficulty).RunParallel(ctx, p.Workers)
+	if err != nil {
+		return err
+	}
+	block.Nonce = nonce
+	block.Hash = hash
+	return nil
+}
+
+// Verify checks that the block's nonce yields a hash under the target
+func (p *ProofOfWorkConsensus) Verify(block *Block) bool {
+	return NewProof(block, p.Difficulty).Validate()
+}
+
+// NoOpConsensus seals blocks without any work and accepts every block
+// Only for tests and throwaway local chains: anyone can rewrite such a chain for free
+type NoOpConsensus struct {
+	Difficulty int // Difficulty recorded in block hashes, so they match the chain's layout
+}
+
+// Prepare clears any earlier nonce and hash
+func (n NoOpConsensus) Prepare(block *Block) {
+	block.Nonce = 0
+	block.Hash = []byte{}
+}
+
+// Seal hashes the block with nonce 0
+func (n NoOpConsensus) Seal(block *Block) error {
+	hash := sha256.Sum256(block.headerBytes(n.Difficulty))
+	block.Hash = hash[:]
+	return nil
+}
+
+// Verify accepts every block
+func (n NoOpConsensus) Verify(*Block) bool {
+	return true
+}
+
+// ConsensusEngine returns the engine the chain seals and verifies blocks with
+// A chain with no engine set uses proof of work at its difficulty
+func (chain *BlockChain) ConsensusEngine() Consensus {
+	if chain.Consensus != nil {
+		return chain.Consensus
+	}
+	return NewProofOfWork(chain.Difficulty)
+}
+
+// sealBlock seals the block with engine, abandoning it on ctx if the engine allows
+func sealBlock(ctx context.Context, engine Consensus, block *Block) error {
+	if sealer, ok := engine.(ContextSealer); ok {
+		return sealer.SealWithContext(ctx, block)
+	}
+	if err := ctx.Err(); err != nil {
+		return ErrMiningCancelled
+	}
+	return engine.Seal(block)
+}
@@ -0,0 +1,83 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 14:10
+ */
+
+// recordingEngine is a NoOpConsensus that counts its calls and may refuse every block
+type recordingEngine struct {
+	NoOpConsensus
+	sealed, verified int
+	refuse           bool
+}
+
+func (e *recordingEngine) Seal(block *Block) error {
+	e.sealed++
+	return e.NoOpConsensus.Seal(block)
+}
+
+func (e *recordingEngine) Verify(*Block) bool {
+	e.verified++
+	return !e.refuse
+}
+
+func TestNoOpConsensusSealsWithoutWork(t *testing.T) {
+	const difficulty = 40 // Days of hashing for a real proof of work
+	block := CreateBlock(NoOpConsensus{Difficulty: difficulty}, goldenBlock(currentBlockVersion).Transactions, bytes.Repeat([]byte{0x11}, 32), 1)
+
+	hash := sha256.Sum256(block.headerBytes(difficulty))
+	if block.Nonce != 0 || !bytes.Equal(block.Hash, hash[:]) {
+		t.Errorf("sealed with nonce %d and hash %x, want nonce 0 and %x", block.Nonce, block.Hash, hash)
+	}
+	if NewProofOfWork(difficulty).Verify(block) {
+		t.Error("proof of work accepted a block sealed without work")
+	}
+}
+
+func TestBlocksGoThroughTheConsensusEngine(t *testing.T) {
+	miner := wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{miner: 100})
+
+	engine := &recordingEngine{NoOpConsensus: NoOpConsensus{Difficulty: chain.Difficulty}}
+	chain.Consensus = engine
+	mineTestBlock(t, chain, miner)
+	if engine.sealed != 1 {
+		t.Errorf("mining sealed through the engine %d times, want 1", engine.sealed)
+	}
+
+	// A received block is accepted or refused by the engine alone
+	engine.refuse = true
+	block := CreateBlock(engine, []*Transaction{CoinbaseTx(string(miner.Address()), "", 2, 0)}, chain.tip(), 2)
+	if err := chain.AddBlock(block); !errors.Is(err, ErrInvalidProofOfWork) {
+		t.Errorf("AddBlock of a block the engine refuses = %v, want ErrInvalidProofOfWork", err)
+	}
+	if engine.verified == 0 {
+		t.Error("AddBlock never asked the engine")
+	}
+	engine.refuse = false
+	if err := chain.AddBlock(block); err != nil {
+		t.Errorf("AddBlock of a block the engine accepts: %v", err)
+	}
+	if height := chain.GetBestHeight(); height != 2 {
+		t.Errorf("height %d, want 2", height)
+	}
+
+	// Without an engine, the chain uses proof of work at its difficulty
+	chain.Consensus = nil
+	if pow, ok := chain.ConsensusEngine().(*ProofOfWorkConsensus); !ok || pow.Difficulty != chain.Difficulty {
+		t.Errorf("default engine = %#v, want proof of work at difficulty %d", chain.ConsensusEngine(), chain.Difficulty)
+	}
+}
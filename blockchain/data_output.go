@@ -0,0 +1,70 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 19:10
+ */
+
+// DATA OUTPUTS
+// Like Bitcoin's OP_RETURN, a transaction may carry a small payload (a note, a document
+// hash) in an output nobody can spend. The payload goes where the lock would, behind a
+// marker byte, so TxOutput (and the bytes every existing transaction serializes to)
+// stays as it is:
+//
+//	TxOutput{Value: 0, PubKeyHash: 0x6a + "hello"}
+//
+// Any other output carries a value (see CheckOutputs), so a worthless output starting
+// with the marker can only be a data output. No key hashes to such a lock, and
+// IsLockedWithKey refuses data outputs outright, so they never count towards a balance
+// or get selected as inputs. They are left out of the UTXO set altogether, and an input
+// referring to one fails verification.
+const MaxDataOutputSize = 80 // Largest payload of a data output, in bytes
+
+// dataOutputMarker starts the lock of a data output (Bitcoin's OP_RETURN opcode)
+const dataOutputMarker = byte(0x6a)
+
+// ErrInvalidDataOutput is returned for a data output breaking the rules above
+var ErrInvalidDataOutput = errors.New("invalid data output")
+
+// NewDataOutput creates an unspendable output carrying data
+// Returns ErrInvalidDataOutput when data is empty or longer than MaxDataOutputSize
+func NewDataOutput(data []byte) (*TxOutput, error) {
+	out := &TxOutput{Value: 0, PubKeyHash: append([]byte{dataOutputMarker}, data...)}
+	if err := out.checkData(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IsData reports whether the output is an unspendable data output
+func (out *TxOutput) IsData() bool {
+	return out.Value == 0 && len(out.PubKeyHash) > 0 && out.PubKeyHash[0] == dataOutputMarker
+}
+
+// Payload returns the data a data output carries, or nil for any other output
+func (out *TxOutput) Payload() []byte {
+	if !out.IsData() {
+		return nil
+	}
+	return bytes.Clone(out.PubKeyHash[1:])
+}
+
+// checkData enforces the rules of a data output: a payload that isn't empty nor too long
+func (out *TxOutput) checkData() error {
+	switch size := len(out.PubKeyHash) - 1; {
+	case size <= 0:
+		return fmt.Errorf("%w: no data", ErrInvalidDataOutput)
+	case size > MaxDataOutputSize:
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrInvalidDataOutput, size, MaxDataOutputSize)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 09:20
+ */
+
+func TestDataOutputsNeverCountTowardsBalances(t *testing.T) {
+	sender, recipient, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+	utxoSet := UTXOSet{Blockchain: chain}
+
+	payload := []byte("hello")
+	tx, err := NewTransactionWithData(sender, string(recipient.Address()), 30, 0, payload, &utxoSet)
+	if err != nil {
+		t.Fatalf("build transaction: %v", err)
+	}
+	mineTestBlock(t, chain, miner, tx)
+
+	var data *TxOutput
+	for i := range tx.Outputs {
+		if tx.Outputs[i].IsData() {
+			data = &tx.Outputs[i]
+		}
+	}
+	if data == nil || !bytes.Equal(data.Payload(), payload) {
+		t.Fatalf("transaction carries no data output with payload %q", payload)
+	}
+
+	if got := balance(t, chain, sender); got != 70 {
+		t.Errorf("sender balance = %d, want 70", got)
+	}
+	if got := balance(t, chain, recipient); got != 30 {
+		t.Errorf("recipient balance = %d, want 30", got)
+	}
+	for _, outs := range chain.FindUTXO() {
+		for _, out := range outs.Outputs {
+			if out.IsData() {
+				t.Fatalf("data output %q found in the UTXO set", out.Payload())
+			}
+		}
+	}
+	for _, out := range utxoSet.FindUnspentTransactions(data.PubKeyHash) {
+		t.Errorf("data output lock owns an output worth %d", out.Value)
+	}
+}
@@ -0,0 +1,71 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 17:00
+ */
+
+// CHAIN DIFFICULTY
+// The proof-of-work difficulty is chosen once, when a chain is created, and stored under
+// the "difficulty" key next to the tip pointer. Every node mining or validating blocks of
+// that chain uses it, so a private network or a test can pick a difficulty that mines in
+// milliseconds while the default (Difficulty) keeps public chains meaningful. Chains
+// created before the key existed were mined at the default and load it.
+const (
+	MinDifficulty = 1  // Fewest leading zero bits a chain may require
+	MaxDifficulty = 64 // Most leading zero bits; anything higher could never be mined
+)
+
+var difficultyKey = []byte("difficulty") // Database key holding the chain's difficulty
+
+// ErrInvalidDifficulty is returned for a difficulty outside MinDifficulty..MaxDifficulty
+var ErrInvalidDifficulty = errors.New("invalid difficulty")
+
+// Proof returns the proof of work of a block at this chain's difficulty
+func (chain *BlockChain) Proof(b *Block) *ProofOfWork {
+	return NewProof(b, chain.Difficulty)
+}
+
+// checkDifficulty rejects difficulties no chain may use
+func checkDifficulty(difficulty int) error {
+	if difficulty < MinDifficulty || difficulty > MaxDifficulty {
+		return fmt.Errorf("%w: %d, must be between %d and %d", ErrInvalidDifficulty, difficulty, MinDifficulty, MaxDifficulty)
+	}
+	return nil
+}
+
+// storeDifficulty records the chain's difficulty
+func storeDifficulty(txn *badger.Txn, difficulty int) error {
+	return txn.Set(difficultyKey, []byte(strconv.Itoa(difficulty)))
+}
+
+// loadDifficulty reads the chain's difficulty, or Difficulty for chains that never stored one
+func loadDifficulty(txn *badger.Txn) (int, error) {
+	item, err := txn.Get(difficultyKey)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return Difficulty, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	value, err := item.ValueCopy(nil)
+	if err != nil {
+		return 0, err
+	}
+	difficulty, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0, fmt.Errorf("%w: stored value %q", ErrInvalidDifficulty, value)
+	}
+	return difficulty, checkDifficulty(difficulty)
+}
@@ -0,0 +1,62 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 14:00
+ */
+
+func TestLowDifficultyChainMinesAtOnce(t *testing.T) {
+	dataDir := wallet.DataDir
+	wallet.DataDir = t.TempDir()
+	t.Cleanup(func() { wallet.DataDir = dataDir })
+
+	const difficulty = 4 // Sixteen hashes a block on average, against thousands at the default
+	miner := wallet.MakeWallet()
+	for _, bad := range []int{MinDifficulty - 1, MaxDifficulty + 1} {
+		if _, err := InitBlockChainWithGenesis(map[string]int{string(miner.Address()): 100}, bad, "low"); !errors.Is(err, ErrInvalidDifficulty) {
+			t.Errorf("difficulty %d: InitBlockChainWithGenesis = %v, want ErrInvalidDifficulty", bad, err)
+		}
+	}
+
+	chain, err := InitBlockChainWithGenesis(map[string]int{string(miner.Address()): 100}, difficulty, "low")
+	if err != nil {
+		t.Fatalf("InitBlockChainWithGenesis: %v", err)
+	}
+	started := time.Now()
+	for height := 1; height <= 20; height++ {
+		coinbase := CoinbaseTx(string(miner.Address()), "", height, 0)
+		if _, err := chain.MineBlockWithContext(t.Context(), []*Transaction{coinbase}); err != nil {
+			t.Fatalf("mine block %d: %v", height, err)
+		}
+	}
+	t.Logf("mined 20 blocks at difficulty %d in %s", difficulty, time.Since(started))
+	chain.Database.Close()
+
+	// The difficulty is the chain's own: reopening uses it to check every block
+	chain = ContinueBlockChain("low")
+	defer chain.Database.Close()
+	if chain.Difficulty != difficulty {
+		t.Fatalf("reopened chain has difficulty %d, want %d", chain.Difficulty, difficulty)
+	}
+	if err := chain.VerifyIntegrity(); err != nil {
+		t.Errorf("VerifyIntegrity: %v", err)
+	}
+	block, err := chain.GetBlock(chain.tip())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := block.Header(difficulty).Validate(); err != nil {
+		t.Errorf("the tip's header at difficulty %d: %v", difficulty, err)
+	}
+}
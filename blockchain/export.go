@@ -0,0 +1,228 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 24/12/2025
+ * Time: 14:20
+ */
+
+// CHAIN EXPORT / IMPORT
+// The raw badger directory isn't portable between badger versions, so a chain is moved
+// between machines as a flat stream instead:
+//
+//	"GBCHAIN" | version (1 byte) | difficulty (1 byte) | { length (4 bytes, big endian) | serialized block } ...
+//
+// Blocks are written genesis first. Importing replays them through the normal block
+// validation rules, so a tampered export is rejected rather than trusted. Version 1
+// streams have no difficulty byte; their chains were mined at the default Difficulty.
+var exportMagic = []byte("GBCHAIN") // Marks a chain export stream
+
+const (
+	exportVersion        = 2        // Format version byte following the magic
+	exportVersionNoDiff  = 1        // Older format without the difficulty byte, still importable
+	maxExportedBlockSize = 32 << 20 // Largest block length accepted when importing (32 MiB)
+)
+
+// Errors returned when importing a chain
+var (
+	ErrBlockchainExists = errors.New("blockchain already exists")
+	ErrInvalidExport    = errors.New("not a valid chain export")
+)
+
+// Export writes every block of the active chain to w, genesis first
+// A pruned chain can't be exported: its old blocks no longer pass validation
+func (chain *BlockChain) Export(w io.Writer) error {
+	if chain.IsPruned() {
+		return ErrChainPruned
+	}
+	if _, err := w.Write(append(append([]byte{}, exportMagic...), exportVersion, byte(chain.Difficulty))); err != nil {
+		return err
+	}
+
+	iter := chain.ForwardIterator()
+	for iter.HasNext() {
+		block, err := iter.Next()
+		if err != nil {
+			return err
+		}
+
+		data := block.Serialize()
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+		if _, err := w.Write(length[:]); err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportChain creates the node's blockchain from a stream written by Export
+// Every block must pass proof of work, linkage and transaction validation; the UTXO
+// set is rebuilt once all blocks are in. On failure the partial database is removed
+func ImportChain(nodeID string, r io.Reader) (err error) {
+	path := wallet.DataPath(fmt.Sprintf(dbPath, nodeID))
+	if DBExists(path) {
+		return fmt.Errorf("%w at %s", ErrBlockchainExists, path)
+	}
+	difficulty, err := readExportHeader(r)
+	if err != nil {
+		return err
+	}
+
+	opts := badger.DefaultOptions(path).WithLogger(nil)
+	opts.Dir = path      // Key and metadata will be stored in this directory
+	opts.ValueDir = path // Value will be stored in this directory
+
+	db, err := openDB(path, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := db.Close()
+		if err != nil {
+			_ = os.RemoveAll(path) // Don't leave a half-imported chain behind
+			return
+		}
+		err = closeErr
+	}()
+
+	chain := &BlockChain{Database: db, Difficulty: difficulty}
+	count := 0
+	for {
+		block, err := readExportedBlock(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("block %d: %w", count, err)
+		}
+		if err := chain.importBlock(block); err != nil {
+			return fmt.Errorf("block %d (%x): %w", count, block.Hash, err)
+		}
+		count++
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: no blocks", ErrInvalidExport)
+	}
+
+	return UTXOSet{Blockchain: chain}.Reindex()
+}
+
+// readExportHeader checks the magic and version at the start of an export stream
+// and returns the difficulty of the exported chain
+func readExportHeader(r io.Reader) (int, error) {
+	header := make([]byte, len(exportMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+	if !bytes.Equal(header[:len(exportMagic)], exportMagic) {
+		return 0, fmt.Errorf("%w: bad magic", ErrInvalidExport)
+	}
+
+	switch version := header[len(exportMagic)]; version {
+	case exportVersionNoDiff:
+		return Difficulty, nil
+	case exportVersion:
+		var difficulty [1]byte
+		if _, err := io.ReadFull(r, difficulty[:]); err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+		}
+		if err := checkDifficulty(int(difficulty[0])); err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+		}
+		return int(difficulty[0]), nil
+	default:
+		return 0, fmt.Errorf("%w: unsupported version %d", ErrInvalidExport, version)
+	}
+}
+
+// readExportedBlock reads the next length-prefixed block from an export stream
+// Returns io.EOF at a clean end of the stream
+func readExportedBlock(r io.Reader) (*Block, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+
+	size := binary.BigEndian.Uint32(length[:])
+	if size == 0 || size > maxExportedBlockSize {
+		return nil, fmt.Errorf("%w: block length %d", ErrInvalidExport, size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("%w: truncated block: %v", ErrInvalidExport, err)
+	}
+	return DeserializeE(data)
+}
+
+// importBlock validates a block read from an export and makes it the new tip
+// The first block must be a genesis block; every later one must build on the previous
+func (chain *BlockChain) importBlock(block *Block) error {
+	chain.tipMu.Lock()
+	defer chain.tipMu.Unlock()
+
+	lastHash := chain.tip()
+	if lastHash == nil {
+		if len(block.PrevHash) != 0 || block.Height != 0 {
+			return fmt.Errorf("%w: first block is not a genesis block", ErrInvalidPrevHash)
+		}
+		if err := chain.validateProofOfWork(block); err != nil {
+			return err
+		}
+		err := chain.Database.Update(func(txn *badger.Txn) error {
+			return storeGenesis(txn, block, chain.Difficulty)
+		})
+		if err != nil {
+			return err
+		}
+		chain.setTip(block.Hash)
+		return nil
+	}
+
+	if !bytes.Equal(block.PrevHash, lastHash) {
+		return fmt.Errorf("%w: block builds on %x, not on %x", ErrInvalidPrevHash, block.PrevHash, lastHash)
+	}
+	if err := chain.checkPrevHash(block); err != nil {
+		return err
+	}
+	if err := chain.ValidateBlock(block); err != nil {
+		return err
+	}
+
+	// The UTXO set is rebuilt once at the end, so only the block and its indexes are written
+	err := chain.Database.Update(func(txn *badger.Txn) error {
+		if err := txn.Set(block.Hash, block.Serialize()); err != nil {
+			return err
+		}
+		if err := txn.Set([]byte("lh"), block.Hash); err != nil {
+			return err
+		}
+		return indexActiveChain(txn, block)
+	})
+	if err != nil {
+		return err
+	}
+	chain.setTip(block.Hash)
+	return nil
+}
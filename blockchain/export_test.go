@@ -0,0 +1,54 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 13:10
+ */
+
+func TestExportImportRoundTrip(t *testing.T) {
+	sender, recipient, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+	chain.Consensus = nil // Importing checks real proof of work, cheap at MinDifficulty
+	mineTestBlock(t, chain, miner, newTestTransfer(t, chain, sender, recipient, 30, 1))
+	mineTestBlock(t, chain, miner)
+
+	var stream bytes.Buffer
+	if err := chain.Export(&stream); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	exported := stream.Bytes()
+
+	if err := ImportChain("imported", bytes.NewReader(exported)); err != nil {
+		t.Fatalf("ImportChain: %v", err)
+	}
+	imported := ContinueBlockChain("imported")
+	defer imported.Database.Close()
+
+	if !bytes.Equal(imported.LastHash, chain.LastHash) {
+		t.Errorf("imported tip %x, want %x", imported.LastHash, chain.LastHash)
+	}
+	if got, want := imported.GetBestHeight(), chain.GetBestHeight(); got != want {
+		t.Errorf("imported height %d, want %d", got, want)
+	}
+	for _, w := range []*wallet.Wallet{sender, recipient, miner} { // The UTXO set was rebuilt
+		if got, want := balance(t, imported, w), balance(t, chain, w); got != want {
+			t.Errorf("%s has %d after import, want %d", w.Address(), got, want)
+		}
+	}
+
+	// The node now has a chain, so a second import is refused
+	if err := ImportChain("imported", bytes.NewReader(exported)); !errors.Is(err, ErrBlockchainExists) {
+		t.Errorf("second ImportChain = %v, want ErrBlockchainExists", err)
+	}
+}
@@ -0,0 +1,174 @@
+package blockchain
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 16/12/2025
+ * Time: 10:05
+ */
+
+/*
+   HISTORICAL FEE ESTIMATION
+
+	Instead of guessing a static minimum fee, the node learns from what actually happened:
+	for every confirmed transaction it remembers the fee rate it paid and how many blocks
+	it waited in the memory pool before being mined.
+
+	To answer "what fee rate gets me confirmed within N blocks?" we look for the lowest
+	fee rate at which (almost) every transaction paying that much or more confirmed
+	within N blocks.
+
+	Example history:
+	  fee rate 5.0 → 1 block,  fee rate 4.0 → 1 block,
+	  fee rate 1.0 → 6 blocks, fee rate 0.5 → 9 blocks
+	  EstimateSmartFee(2) = 4.0 (everything paying >= 4.0 confirmed within 2 blocks)
+*/
+
+const (
+	FeeHistoryWindow  = 1000 // Number of recent confirmations remembered by the estimator
+	feeSuccessPercent = 85   // Share of transactions (in %) that must have confirmed within the target
+)
+
+// ErrNoFeeData is returned when no confirmation history exists for the requested target
+var ErrNoFeeData = errors.New("not enough fee history to estimate a fee")
+
+// feeSample records one confirmed transaction
+type feeSample struct {
+	FeeRate      float64 // Fee paid per serialized byte
+	BlocksWaited int     // Blocks between entering the memory pool and confirmation
+}
+
+// FeeEstimator keeps a rolling window of confirmed fee rates and their wait times
+// It is safe for concurrent use
+type FeeEstimator struct {
+	mu      sync.Mutex
+	window  int         // Maximum number of samples kept
+	samples []feeSample // Oldest first, trimmed to window
+}
+
+// NewFeeEstimator creates an estimator remembering the last window confirmations
+func NewFeeEstimator(window int) *FeeEstimator {
+	if window <= 0 {
+		window = FeeHistoryWindow
+	}
+	return &FeeEstimator{window: window}
+}
+
+// RecordConfirmation remembers that a transaction paying feeRate confirmed after blocksWaited blocks
+// The oldest sample is dropped once the window is full
+func (fe *FeeEstimator) RecordConfirmation(feeRate float64, blocksWaited int) {
+	if blocksWaited < 1 {
+		blocksWaited = 1 // Confirmed in the very next block
+	}
+
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+
+	fe.samples = append(fe.samples, feeSample{FeeRate: feeRate, BlocksWaited: blocksWaited})
+	if len(fe.samples) > fe.window {
+		fe.samples = fe.samples[len(fe.samples)-fe.window:]
+	}
+}
+
+// EstimateSmartFee returns the lowest fee rate that has historically been enough
+// to confirm within targetBlocks blocks
+// Returns ErrNoFeeData when the history can't answer for this target
+func (fe *FeeEstimator) EstimateSmartFee(targetBlocks int) (float64, error) {
+	if targetBlocks < 1 {
+		targetBlocks = 1
+	}
+
+	fe.mu.Lock()
+	samples := make([]feeSample, len(fe.samples))
+	copy(samples, fe.samples)
+	fe.mu.Unlock()
+
+	// Walk from the highest fee rate down, tracking how many of the transactions
+	// paying at least the current rate made it within the target
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].FeeRate > samples[j].FeeRate
+	})
+
+	estimate, found := 0.0, false
+	total, withinTarget := 0, 0
+	for _, sample := range samples {
+		total++
+		if sample.BlocksWaited <= targetBlocks {
+			withinTarget++
+		}
+
+		// Still reliable at this rate: remember it and try a cheaper one
+		if withinTarget*100 >= total*feeSuccessPercent {
+			estimate, found = sample.FeeRate, true
+		}
+	}
+
+	if !found {
+		return 0, ErrNoFeeData
+	}
+	return estimate, nil
+}
+
+/*
+   BACKLOG FEE ESTIMATION
+
+	The history above needs confirmations to learn from; a fresh node has none. The memory
+	pool itself gives a second answer: miners fill blocks best fee rate first, so a new
+	transaction is mined within N blocks if it outbids whatever would be left over after
+	N blocks' worth (MaxBlockSize each) of the current backlog.
+
+	Example with room for 2 transactions per block and a backlog paying 9, 7, 5, 3, 2:
+	  EstimateBacklogFee(1) = 5 + MinFeeRate (5 and below wait for a second block)
+	  EstimateBacklogFee(3) = MinFeeRate     (the whole backlog clears within 3 blocks)
+*/
+
+// MinFeeRate is the fee rate, per serialized byte, suggested when blocks have room to spare
+// It is also the step by which a suggestion outbids the backlog
+const MinFeeRate = 1.0
+
+// PendingFee is the fee and size of one transaction waiting in the memory pool
+type PendingFee struct {
+	Fee  int // Inputs minus outputs, as returned by BlockChain.Fee
+	Size int // Serialized size in bytes
+}
+
+// EstimateBacklogFee returns the fee rate a transaction should pay to be mined within
+// targetBlocks blocks, given the transactions already waiting in the memory pool
+// Returns MinFeeRate when the backlog clears within the target
+func EstimateBacklogFee(pending []PendingFee, targetBlocks int) float64 {
+	if targetBlocks < 1 {
+		targetBlocks = 1
+	}
+
+	// Mining order: best fee rate first
+	sorted := make([]PendingFee, 0, len(pending))
+	for _, p := range pending {
+		if p.Size > 0 {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Fee*sorted[j].Size > sorted[j].Fee*sorted[i].Size // Fee rate, without float division
+	})
+
+	// Fill targetBlocks blocks; the first transaction left over sets the price
+	room := targetBlocks * (MaxBlockSize - blockOverhead)
+	for _, p := range sorted {
+		room -= p.Size
+		if room < 0 {
+			rate := float64(p.Fee) / float64(p.Size)
+			if rate < MinFeeRate {
+				return MinFeeRate
+			}
+			return rate + MinFeeRate
+		}
+	}
+	return MinFeeRate
+}
@@ -0,0 +1,77 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 14:20
+ */
+
+// syntheticBacklog returns one 1000-byte pending transaction per fee rate
+func syntheticBacklog(rates ...float64) []PendingFee {
+	pending := make([]PendingFee, len(rates))
+	for i, rate := range rates {
+		pending[i] = PendingFee{Fee: int(rate * 1000), Size: 1000}
+	}
+	return pending
+}
+
+func TestEstimateBacklogFeeAtDifferentCongestion(t *testing.T) {
+	maxSize := MaxBlockSize
+	MaxBlockSize = blockOverhead + 3000 // Three synthetic transactions a block
+	t.Cleanup(func() { MaxBlockSize = maxSize })
+
+	congested := syntheticBacklog(2, 9, 4, 7, 3, 8, 6, 5) // Mined 9, 8, 7 | 6, 5, 4 | 3, 2
+	cases := []struct {
+		name    string
+		pending []PendingFee
+		target  int
+		want    float64
+	}{
+		{"empty pool", nil, 1, MinFeeRate},
+		{"room to spare", syntheticBacklog(9, 5), 1, MinFeeRate},
+		{"congested, next block", congested, 1, 6 + MinFeeRate},
+		{"congested, within 2 blocks", congested, 2, 3 + MinFeeRate},
+		{"congested, within 3 blocks", congested, 3, MinFeeRate},
+		{"backlog of dust", syntheticBacklog(0.5, 0.5, 0.5, 0.5), 1, MinFeeRate},
+		{"target below 1 means the next block", congested, 0, 6 + MinFeeRate},
+	}
+	for _, c := range cases {
+		if got := EstimateBacklogFee(c.pending, c.target); got != c.want {
+			t.Errorf("%s: EstimateBacklogFee = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestEstimateSmartFeeFromHistory(t *testing.T) {
+	fe := NewFeeEstimator(0)
+	if _, err := fe.EstimateSmartFee(1); !errors.Is(err, ErrNoFeeData) {
+		t.Errorf("EstimateSmartFee without history = %v, want ErrNoFeeData", err)
+	}
+
+	// The example from the doc comment
+	fe.RecordConfirmation(5.0, 1)
+	fe.RecordConfirmation(4.0, 1)
+	fe.RecordConfirmation(1.0, 6)
+	fe.RecordConfirmation(0.5, 9)
+	for target, want := range map[int]float64{1: 4.0, 2: 4.0, 6: 1.0, 9: 0.5} {
+		if got, err := fe.EstimateSmartFee(target); err != nil || got != want {
+			t.Errorf("EstimateSmartFee(%d) = %v, %v; want %v", target, got, err, want)
+		}
+	}
+
+	// Only the window's most recent confirmations count: the slow one falls out
+	small := NewFeeEstimator(2)
+	small.RecordConfirmation(9.0, 9)
+	small.RecordConfirmation(2.0, 1)
+	small.RecordConfirmation(1.0, 1)
+	if got, err := small.EstimateSmartFee(1); err != nil || got != 1.0 {
+		t.Errorf("EstimateSmartFee over a window of 2 = %v, %v; want 1", got, err)
+	}
+}
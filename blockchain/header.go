@@ -0,0 +1,152 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"math/big"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 15:05
+ */
+
+// BLOCK HEADERS
+// A block's proof of work covers its version, previous hash, the Merkle root of its
+// transactions, the nonce and the difficulty (see encodeHeader). That is everything a light
+// client needs to check the work and link blocks together, so a header carries just
+// those fields plus the identifying hash, height and timestamp. Whether a transaction
+// is in a block can then be checked against MerkleRoot without downloading the block.
+
+// BlockHeader is a block without its transactions
+type BlockHeader struct {
+	Version    int // Rules the block was mined under (see encodeHeader)
+	Timestamp  int64
+	Hash       []byte // Claimed hash of the block; Validate recomputes it
+	PrevHash   []byte
+	MerkleRoot []byte // Root of the block's transactions (HashTransactions)
+	Nonce      int
+	Height     int
+	Difficulty int // Leading zero bits the hash must have
+}
+
+// Header hashing layout
+// encodeHeader is the one place the hashed bytes of a header are laid out, by hand
+// rather than through gob, so adding a field to Block or BlockHeader can't change the
+// hash of existing blocks. Blocks from before versioning carry Version 0 and hash:
+//
+//	prevHash | merkleRoot | nonce (8 bytes) | difficulty (8 bytes)
+//
+// with both integers signed and big-endian (as IntToBytes writes them). Their Merkle
+// leaves are the transactions' gob bytes. From Version 1 on, the version comes first,
+// so it is committed to by the proof of work, and the leaves are the canonical
+// transaction encoding (see tx_encoding.go):
+//
+//	version (4 bytes) | prevHash | merkleRoot | nonce (8 bytes) | difficulty (8 bytes)
+//
+// A new layout needs a new version, and blocks mined under the old one must keep
+// hashing as before.
+const (
+	legacyBlockVersion  = 0 // Blocks mined before the version was part of the header
+	currentBlockVersion = 1 // Version of the blocks this node mines
+)
+
+// encodeHeader lays out the header fields the block hash covers, as version defines them
+// Used while mining, with a fresh nonce each time, and when checking blocks and headers
+func encodeHeader(version int, prevHash, merkleRoot []byte, nonce, difficulty int) []byte {
+	data := make([]byte, 0, 4+len(prevHash)+len(merkleRoot)+16)
+	if version != legacyBlockVersion {
+		data = binary.BigEndian.AppendUint32(data, uint32(version))
+	}
+	data = append(data, prevHash...)
+	data = append(data, merkleRoot...)
+	data = binary.BigEndian.AppendUint64(data, uint64(int64(nonce)))
+	data = binary.BigEndian.AppendUint64(data, uint64(int64(difficulty)))
+	return data
+}
+
+// headerBytes returns the bytes the block's hash covers, for a block mined at difficulty
+func (b *Block) headerBytes(difficulty int) []byte {
+	return encodeHeader(b.Version, b.PrevHash, b.HashTransactions(), b.Nonce, difficulty)
+}
+
+// Header returns the header of the block, which was mined at the given difficulty
+func (b *Block) Header(difficulty int) BlockHeader {
+	return BlockHeader{
+		Version:    b.Version,
+		Timestamp:  b.Timestamp,
+		Hash:       b.Hash,
+		PrevHash:   b.PrevHash,
+		MerkleRoot: b.HashTransactions(),
+		Nonce:      b.Nonce,
+		Height:     b.Height,
+		Difficulty: difficulty,
+	}
+}
+
+// Serialize encodes the header for sending to a light client
+func (h BlockHeader) Serialize() []byte {
+	var res bytes.Buffer
+	err := gob.NewEncoder(&res).Encode(h)
+	Handle(err)
+	return res.Bytes()
+}
+
+// DeserializeHeader decodes a header produced by BlockHeader.Serialize
+func DeserializeHeader(data []byte) (BlockHeader, error) {
+	var header BlockHeader
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&header); err != nil {
+		return BlockHeader{}, fmt.Errorf("decode block header: %w", err)
+	}
+	return header, nil
+}
+
+// Validate checks the proof of work of the header alone
+// The fields must hash to the claimed Hash, and that hash must meet the header's difficulty;
+// VerifyHeaders also checks that difficulty is the chain's
+func (h BlockHeader) Validate() error {
+	if err := checkDifficulty(h.Difficulty); err != nil {
+		return fmt.Errorf("%w: block %x: %v", ErrInvalidProofOfWork, h.Hash, err)
+	}
+
+	hash := sha256.Sum256(encodeHeader(h.Version, h.PrevHash, h.MerkleRoot, h.Nonce, h.Difficulty))
+	if !bytes.Equal(hash[:], h.Hash) {
+		return fmt.Errorf("%w: header of block %x hashes to %x", ErrInvalidMerkleRoot, h.Hash, hash)
+	}
+
+	// The same target as NewProof: 1 shifted left by 256-difficulty bits
+	target := new(big.Int).Lsh(big.NewInt(1), uint(256-h.Difficulty))
+	if new(big.Int).SetBytes(hash[:]).Cmp(target) != -1 {
+		return fmt.Errorf("%w: block %x", ErrInvalidProofOfWork, h.Hash)
+	}
+	return nil
+}
+
+// VerifyHeaders checks a run of headers, oldest first, as a light client receives them
+// Every header must be mined at the chain's difficulty, pass Validate and build on the
+// one before it, one height higher
+func VerifyHeaders(headers []BlockHeader, difficulty int) error {
+	for i, header := range headers {
+		if header.Difficulty != difficulty {
+			return fmt.Errorf("%w: block %x claims difficulty %d, want %d", ErrInvalidProofOfWork, header.Hash, header.Difficulty, difficulty)
+		}
+		if err := header.Validate(); err != nil {
+			return err
+		}
+		if i == 0 {
+			continue
+		}
+		prev := headers[i-1]
+		if !bytes.Equal(header.PrevHash, prev.Hash) || header.Height != prev.Height+1 {
+			return fmt.Errorf("%w: header %x at height %d does not follow %x at height %d",
+				ErrInvalidPrevHash, header.Hash, header.Height, prev.Hash, prev.Height)
+		}
+	}
+	return nil
+}
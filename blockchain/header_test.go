@@ -0,0 +1,144 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 09:30
+ */
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenBlock returns a block whose every field is fixed, mined under version
+func goldenBlock(version int) *Block {
+	coinbase := &Transaction{
+		Inputs:  []TxInput{{ID: []byte{}, Out: -1, Signature: []byte("golden")}},
+		Outputs: []TxOutput{{Value: 100, PubKeyHash: bytes.Repeat([]byte{0x22}, 20)}},
+	}
+	coinbase.SetID()
+	transfer := &Transaction{
+		Inputs: []TxInput{{
+			ID:        coinbase.ID,
+			Out:       0,
+			Signature: bytes.Repeat([]byte{0x33}, signatureLength),
+			PubKey:    bytes.Repeat([]byte{0x44}, 33),
+		}},
+		Outputs: []TxOutput{
+			{Value: 60, PubKeyHash: bytes.Repeat([]byte{0x55}, 20)},
+			{Value: 39, PubKeyHash: bytes.Repeat([]byte{0x66}, 20)},
+		},
+	}
+	transfer.SetID()
+
+	return &Block{
+		Version:      version,
+		Timestamp:    1766822400,
+		Transactions: []*Transaction{coinbase, transfer},
+		PrevHash:     bytes.Repeat([]byte{0x11}, 32),
+		Nonce:        4242,
+		Height:       7,
+	}
+}
+
+// checkGolden compares got with the hex in testdata/name, or rewrites it under -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(hex.EncodeToString(got)+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(got) != strings.TrimSpace(string(want)) {
+		t.Errorf("%s changed:\n got %x\nwant %s", name, got, want)
+	}
+}
+
+func TestHeaderBytesGolden(t *testing.T) {
+	checkGolden(t, "header_v1.golden", goldenBlock(currentBlockVersion).headerBytes(MinDifficulty))
+
+	// Legacy Merkle leaves are gob bytes, which depend on what the process encoded
+	// before; pin the legacy layout over a fixed root instead
+	root := bytes.Repeat([]byte{0x77}, 32)
+	checkGolden(t, "header_v0.golden", encodeHeader(legacyBlockVersion, bytes.Repeat([]byte{0x11}, 32), root, 4242, MinDifficulty))
+}
+
+func TestHeaderCommitsToVersion(t *testing.T) {
+	block := goldenBlock(currentBlockVersion)
+	header := block.Header(MinDifficulty)
+	if header.Version != currentBlockVersion {
+		t.Fatalf("header version = %d, want %d", header.Version, currentBlockVersion)
+	}
+
+	other := encodeHeader(currentBlockVersion+1, header.PrevHash, header.MerkleRoot, header.Nonce, header.Difficulty)
+	if bytes.Equal(block.headerBytes(MinDifficulty), other) {
+		t.Error("header bytes don't depend on the version")
+	}
+}
+
+func TestHeaderRoundTripAndProofOfWork(t *testing.T) {
+	const difficulty = 8 // A few hundred hashes: a real proof of work, mined at once
+	mined := goldenBlock(currentBlockVersion)
+	if err := NewProofOfWork(difficulty).Seal(mined); err != nil {
+		t.Fatal(err)
+	}
+	header := mined.Header(difficulty)
+
+	decoded, err := DeserializeHeader(header.Serialize())
+	if err != nil {
+		t.Fatalf("DeserializeHeader: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, header) {
+		t.Fatalf("round trip changed the header:\n got %+v\nwant %+v", decoded, header)
+	}
+	if err := decoded.Validate(); err != nil {
+		t.Errorf("Validate of a mined header: %v", err)
+	}
+
+	// Any change to a hashed field breaks the claimed hash
+	tampered := map[string]func(h *BlockHeader){
+		"nonce":       func(h *BlockHeader) { h.Nonce++ },
+		"merkle root": func(h *BlockHeader) { h.MerkleRoot = bytes.Repeat([]byte{0x77}, 32) },
+		"prev hash":   func(h *BlockHeader) { h.PrevHash = bytes.Repeat([]byte{0x12}, 32) },
+		"version":     func(h *BlockHeader) { h.Version++ },
+	}
+	for name, tamper := range tampered {
+		h := header
+		tamper(&h)
+		if err := h.Validate(); err == nil {
+			t.Errorf("%s changed: Validate accepted the header", name)
+		}
+	}
+
+	// A hash that matches its fields but misses the claimed difficulty is no proof of work
+	h := header
+	h.Difficulty = 40
+	hash := sha256.Sum256(encodeHeader(h.Version, h.PrevHash, h.MerkleRoot, h.Nonce, h.Difficulty))
+	h.Hash = hash[:]
+	if err := h.Validate(); !errors.Is(err, ErrInvalidProofOfWork) {
+		t.Errorf("Validate at a harder difficulty = %v, want ErrInvalidProofOfWork", err)
+	}
+
+	if _, err := DeserializeHeader([]byte("not a header")); err == nil {
+		t.Error("DeserializeHeader accepted garbage")
+	}
+}
@@ -0,0 +1,172 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 17/12/2025
+ * Time: 09:40
+ */
+
+// HEIGHT INDEX
+// The active chain has exactly one block per height, so we keep a simple mapping
+// "height-{N}" -> block hash for constant-time height lookups instead of walking
+// the chain back from the tip. Side-chain blocks are stored but never indexed.
+var heightPrefix = []byte("height-") // Database key prefix for height index entries
+
+// ErrHeightNotFound is returned when no block on the active chain has the requested height
+var ErrHeightNotFound = errors.New("no block at this height")
+
+// heightKey builds the database key for a height, e.g. "height-42"
+func heightKey(height int) []byte {
+	return append(append([]byte{}, heightPrefix...), strconv.Itoa(height)...)
+}
+
+// indexActiveChain points the height index at the branch ending in tip
+// It walks back from the tip rewriting entries until it reaches a height that already
+// points at this branch: extending the tip costs one write, while a reorg rewrites
+// exactly the heights that moved to the new branch
+func indexActiveChain(txn *badger.Txn, tip *Block) error {
+	block := tip
+	for {
+		key := heightKey(block.Height)
+
+		// Stop as soon as the index already agrees with this branch
+		item, err := txn.Get(key)
+		if err == nil {
+			indexed, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(indexed, block.Hash) {
+				break
+			}
+		} else if !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+
+		if err := txn.Set(key, block.Hash); err != nil {
+			return err
+		}
+		// A block joining the active chain also gets its transactions indexed
+		if err := indexTransactions(txn, block); err != nil {
+			return err
+		}
+
+		if len(block.PrevHash) == 0 {
+			break // Indexed all the way down to genesis
+		}
+
+		// Step back to the parent block
+		item, err = txn.Get(block.PrevHash)
+		if err != nil {
+			return fmt.Errorf("parent block %x: %w", block.PrevHash, err)
+		}
+		blockData, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		block = Deserialize(blockData)
+	}
+
+	// Heights above the tip belonged to an abandoned branch
+	for height := tip.Height + 1; ; height++ {
+		key := heightKey(height)
+		if _, err := txn.Get(key); errors.Is(err, badger.ErrKeyNotFound) {
+			break
+		}
+		if err := txn.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBlockHashByHeight returns the hash of the active-chain block at the given height
+func (chain *BlockChain) GetBlockHashByHeight(height int) ([]byte, error) {
+	var blockHash []byte
+
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(heightKey(height))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return ErrHeightNotFound
+		} else if err != nil {
+			return err
+		}
+
+		blockHash, err = item.ValueCopy(nil)
+		return err
+	})
+
+	return blockHash, err
+}
+
+// GetBlockByHeight returns the active-chain block at the given height
+// Returns ErrHeightNotFound for a height below zero or above the tip
+func (chain *BlockChain) GetBlockByHeight(height int) (Block, error) {
+	hash, err := chain.GetBlockHashByHeight(height)
+	if err != nil {
+		return Block{}, fmt.Errorf("height %d: %w", height, err)
+	}
+	block, err := chain.GetBlock(hash)
+	if err != nil {
+		return Block{}, fmt.Errorf("block %x at height %d: %w", hash, height, err)
+	}
+	return block, nil
+}
+
+// ReindexHeights rebuilds the height index from scratch by walking back from the tip
+// Used for databases created before the index existed, or after corruption
+func (chain *BlockChain) ReindexHeights() {
+	// Collect the existing entries first; deleting while iterating isn't allowed
+	var staleKeys [][]byte
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(heightPrefix); it.ValidForPrefix(heightPrefix); it.Next() {
+			staleKeys = append(staleKeys, it.Item().KeyCopy(nil))
+		}
+		return nil
+	})
+	Handle(err)
+
+	err = chain.Database.Update(func(txn *badger.Txn) error {
+		for _, key := range staleKeys {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+		}
+
+		// Re-index the active chain from the tip down to genesis
+		item, err := txn.Get([]byte("lh"))
+		if err != nil {
+			return err
+		}
+		lastHash, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		item, err = txn.Get(lastHash)
+		if err != nil {
+			return err
+		}
+		tipData, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		return indexActiveChain(txn, Deserialize(tipData))
+	})
+	Handle(err)
+}
@@ -0,0 +1,74 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 15:00
+ */
+
+// checkHeights fails unless GetBlockByHeight finds each of blocks at its height, and
+// nothing above the last one
+func checkHeights(t *testing.T, chain *BlockChain, blocks []*Block) {
+	t.Helper()
+
+	for height, want := range blocks {
+		block, err := chain.GetBlockByHeight(height)
+		if err != nil {
+			t.Fatalf("GetBlockByHeight(%d): %v", height, err)
+		}
+		if !bytes.Equal(block.Hash, want.Hash) || block.Height != height {
+			t.Errorf("GetBlockByHeight(%d) = %x at height %d, want %x", height, block.Hash, block.Height, want.Hash)
+		}
+	}
+	for _, height := range []int{-1, len(blocks), len(blocks) + 1} {
+		if _, err := chain.GetBlockByHeight(height); !errors.Is(err, ErrHeightNotFound) {
+			t.Errorf("GetBlockByHeight(%d) = %v, want ErrHeightNotFound", height, err)
+		}
+	}
+}
+
+func TestGetBlockByHeightFollowsTheActiveChain(t *testing.T) {
+	miner := wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{miner: 100})
+	genesis, err := chain.GetBlock(chain.LastHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	main := []*Block{&genesis}
+	for i := 0; i < 3; i++ {
+		main = append(main, mineTestBlock(t, chain, miner))
+	}
+	checkHeights(t, chain, main)
+
+	// A longer branch off block 1 takes over heights 2 to 4
+	side := main[:2:2]
+	for height := 2; height <= 4; height++ {
+		block := branchBlock(chain, miner, side[height-1].Hash, height)
+		if err := chain.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock(side %d): %v", height, err)
+		}
+		side = append(side, block)
+	}
+	checkHeights(t, chain, side)
+
+	// The first branch outgrows it again and takes heights 2 to 5 back
+	for height := 4; height <= 5; height++ {
+		block := branchBlock(chain, miner, main[height-1].Hash, height)
+		if err := chain.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock(main %d): %v", height, err)
+		}
+		main = append(main, block)
+	}
+	checkHeights(t, chain, main)
+}
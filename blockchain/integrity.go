@@ -0,0 +1,76 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 16:35
+ */
+
+// INTEGRITY CHECK
+// Most chain methods treat a missing or undecodable block as impossible and panic. After
+// disk trouble that gives an operator nothing to go on, so VerifyIntegrity walks the active
+// chain from the tip down to genesis and reports the first block that is wrong:
+//  1. The block stored under a hash must decode and carry that hash
+//  2. Its proof of work must hold (a pruned block is checked against its saved Merkle root)
+//  3. Its PrevHash must name a stored block exactly one height below, ending at genesis
+//  4. The height index must point at it
+//
+// A failure of 4 alone can be repaired with reindexutxo; the others need the block
+// restored, e.g. from an exportchain file or by syncing from a peer.
+
+// ErrCorruptChain is returned by VerifyIntegrity, wrapped with the broken block
+var ErrCorruptChain = errors.New("chain database is corrupt")
+
+// ErrCorruptHeightIndex is returned by VerifyIntegrity when only the height index is wrong
+var ErrCorruptHeightIndex = errors.New("height index is corrupt")
+
+// VerifyIntegrity checks every block of the active chain, tip first
+// Returns nil, or an error wrapping ErrCorruptChain or ErrCorruptHeightIndex that names the first broken block
+func (chain *BlockChain) VerifyIntegrity() error {
+	hash, err := chain.tipHash()
+	if err != nil {
+		return fmt.Errorf("%w: cannot read the tip pointer: %v", ErrCorruptChain, err)
+	}
+
+	expectedHeight := -1 // Height the next block must have; unknown until the tip is read
+	iter := &Iterator{CurrentHash: hash, Database: chain.Database}
+	for iter.HasNext() {
+		block, err := iter.NextBlock()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrCorruptChain, err)
+		}
+
+		if !bytes.Equal(block.Hash, hash) {
+			return fmt.Errorf("%w: block stored under %x claims hash %x", ErrCorruptChain, hash, block.Hash)
+		}
+		if expectedHeight >= 0 && block.Height != expectedHeight {
+			return fmt.Errorf("%w: block %x has height %d, want %d", ErrCorruptChain, block.Hash, block.Height, expectedHeight)
+		}
+		if err := chain.checkProofOfWork(block); err != nil {
+			return fmt.Errorf("%w: %v", ErrCorruptChain, err)
+		}
+		if err := chain.checkMerkleRoot(block); err != nil {
+			return fmt.Errorf("%w: %v", ErrCorruptChain, err)
+		}
+		if len(block.PrevHash) == 0 && block.Height != 0 {
+			return fmt.Errorf("%w: block %x at height %d has no parent", ErrCorruptChain, block.Hash, block.Height)
+		}
+
+		indexed, err := chain.GetBlockHashByHeight(block.Height)
+		if err != nil || !bytes.Equal(indexed, block.Hash) {
+			return fmt.Errorf("%w: height %d does not point at block %x", ErrCorruptHeightIndex, block.Height, block.Hash)
+		}
+
+		hash = block.PrevHash
+		expectedHeight = block.Height - 1
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 10:40
+ */
+
+// damage deletes keys from chain's database and closes it, as if the node had stopped
+func damage(t *testing.T, chain *BlockChain, keys ...[]byte) {
+	t.Helper()
+
+	err := chain.Database.Update(func(txn *badger.Txn) error {
+		for _, key := range keys {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.Database.Close()
+}
+
+func TestOpenBlockChainReadOnlyWithoutDatabase(t *testing.T) {
+	dataDir := wallet.DataDir
+	wallet.DataDir = t.TempDir()
+	t.Cleanup(func() { wallet.DataDir = dataDir })
+
+	if _, err := OpenBlockChainReadOnly("missing"); !errors.Is(err, ErrNoBlockchain) {
+		t.Errorf("OpenBlockChainReadOnly = %v, want ErrNoBlockchain", err)
+	}
+}
+
+func TestVerifyIntegrityOfADatabaseWithoutTip(t *testing.T) {
+	chain := newTestChain(t, map[*wallet.Wallet]int{wallet.MakeWallet(): 100})
+	damage(t, chain, []byte("lh"))
+
+	chain, err := OpenBlockChainReadOnly("test")
+	if err != nil {
+		t.Fatalf("OpenBlockChainReadOnly: %v", err)
+	}
+	defer chain.Database.Close()
+
+	if err := chain.VerifyIntegrity(); !errors.Is(err, ErrCorruptChain) {
+		t.Errorf("VerifyIntegrity = %v, want ErrCorruptChain", err)
+	}
+}
+
+func TestVerifyIntegrityLeavesADamagedIndexAsFound(t *testing.T) {
+	miner := wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{miner: 100})
+	mineTestBlock(t, chain, miner)
+	damage(t, chain, heightKey(0)) // ContinueBlockChain would rebuild the index over this
+
+	for i := 0; i < 2; i++ { // Opening must not rebuild the index it is asked to check
+		chain, err := OpenBlockChainReadOnly("test")
+		if err != nil {
+			t.Fatalf("OpenBlockChainReadOnly: %v", err)
+		}
+		chain.Consensus = NoOpConsensus{Difficulty: MinDifficulty} // Block 1 was sealed by it
+		err = chain.VerifyIntegrity()
+		chain.Database.Close()
+		if !errors.Is(err, ErrCorruptHeightIndex) {
+			t.Fatalf("VerifyIntegrity = %v, want ErrCorruptHeightIndex", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package blockchain
+
+import (
+	"bytes"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 21/12/2025
+ * Time: 16:05
+ */
+
+// BLOCK LOCATOR
+// A locator describes our active chain to a peer in a few dozen hashes: the last
+// locatorDenseHashes blocks one by one, then exponentially further apart, ending at
+// genesis. The peer finds the most recent hash it shares with us and only answers
+// with the blocks after it, instead of sending its whole chain.
+
+const locatorDenseHashes = 10 // Number of tip blocks listed before the step starts doubling
+
+// BlockLocator returns the locator for our active chain, tip first and genesis last
+func (chain *BlockChain) BlockLocator() [][]byte {
+	var locator [][]byte
+
+	step := 1
+	for height := chain.GetBestHeight(); ; height -= step {
+		if height < 0 {
+			height = 0 // Always finish with genesis, the one block every peer shares
+		}
+
+		hash, err := chain.GetBlockHashByHeight(height)
+		if err != nil {
+			break // Height index incomplete; the hashes collected so far still help
+		}
+		locator = append(locator, hash)
+
+		if height == 0 {
+			break
+		}
+		if len(locator) >= locatorDenseHashes {
+			step *= 2
+		}
+	}
+
+	return locator
+}
+
+// HashesAfterLocator returns up to limit active-chain hashes following the most recent
+// locator hash that is also on our active chain, oldest first
+// With no shared hash (or an empty locator) the hashes start at genesis
+func (chain *BlockChain) HashesAfterLocator(locator [][]byte, limit int) [][]byte {
+	start := 0
+	for _, hash := range locator {
+		block, err := chain.GetBlock(hash)
+		if err != nil {
+			continue // Unknown to us
+		}
+
+		// A side-chain block isn't a common point: the peer needs our branch from below it
+		indexed, err := chain.GetBlockHashByHeight(block.Height)
+		if err == nil && bytes.Equal(indexed, hash) {
+			start = block.Height + 1
+			break
+		}
+	}
+
+	var hashes [][]byte
+	bestHeight := chain.GetBestHeight()
+	for height := start; height <= bestHeight && len(hashes) < limit; height++ {
+		hash, err := chain.GetBlockHashByHeight(height)
+		if err != nil {
+			break
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes
+}
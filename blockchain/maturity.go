@@ -0,0 +1,52 @@
+package blockchain
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/golang-blockchain/logger"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 10:40
+ */
+
+// COINBASE MATURITY
+// A block reward may be undone by a reorg, and with it every transaction that spent
+// it. So, as in Bitcoin, the wallet doesn't spend a coinbase output until enough blocks
+// are built on top of it: the output of a coinbase at height h can go into the block
+// at height h+CoinbaseMaturity or later. Each UTXO entry records the height and kind
+// of its transaction for this check (see TxOutputs).
+//
+// CoinbaseMaturity is read from the COINBASE_MATURITY env. var.; 0 lets rewards be
+// spent straight away, which is handy on a fresh local chain. Only coin selection
+// applies the rule; blocks spending immature outputs are still accepted.
+const defaultCoinbaseMaturity = 100
+
+// CoinbaseMaturity is how many blocks a coinbase output waits before it can be spent
+var CoinbaseMaturity = loadCoinbaseMaturity()
+
+// loadCoinbaseMaturity reads the maturity window from the COINBASE_MATURITY env. var.
+// Falls back to the default when the variable is unset or not a non-negative number
+func loadCoinbaseMaturity() int {
+	value := os.Getenv("COINBASE_MATURITY")
+	if value == "" {
+		return defaultCoinbaseMaturity
+	}
+	blocks, err := strconv.Atoi(value)
+	if err != nil || blocks < 0 {
+		logger.Warn("Invalid COINBASE_MATURITY, using the default", "value", value, "default", defaultCoinbaseMaturity)
+		return defaultCoinbaseMaturity
+	}
+	return blocks
+}
+
+// IsMature reports whether the outputs in the entry may be spent by the block after tipHeight
+// Outputs of ordinary transactions are always mature
+func (outs TxOutputs) IsMature(tipHeight int) bool {
+	return !outs.Coinbase || tipHeight+1-outs.Height >= CoinbaseMaturity
+}
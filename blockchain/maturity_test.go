@@ -0,0 +1,44 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 13:40
+ */
+
+func TestImmatureCoinbaseIsNotSpendable(t *testing.T) {
+	miner, other, recipient := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{other: 100})
+	CoinbaseMaturity = 3 // newTestChain restores the old value
+
+	// The reward of block 1 may go into block 4 at the earliest
+	mineTestBlock(t, chain, miner)
+	utxoSet := UTXOSet{Blockchain: chain}
+	for chain.GetBestHeight() < 3 {
+		if got, _, err := utxoSet.FindSpendableOutputs(wallet.PublicKeyHash(miner.PublicKey), 1, FirstFit); err != nil || got != 0 {
+			t.Errorf("at height %d: FindSpendableOutputs = %d, %v; want 0", chain.GetBestHeight(), got, err)
+		}
+		if _, err := NewTransaction(miner, string(recipient.Address()), 1, 0, &utxoSet); !errors.Is(err, ErrInsufficientFunds) {
+			t.Errorf("at height %d: spending the immature reward = %v, want ErrInsufficientFunds", chain.GetBestHeight(), err)
+		}
+		mineTestBlock(t, chain, other)
+	}
+
+	// Now it has matured
+	if got, _, err := utxoSet.FindSpendableOutputs(wallet.PublicKeyHash(miner.PublicKey), 1, FirstFit); err != nil || got != BlockReward(1) {
+		t.Errorf("FindSpendableOutputs of the mature reward = %d, %v; want %d", got, err, BlockReward(1))
+	}
+	mineTestBlock(t, chain, other, newTestTransfer(t, chain, miner, recipient, 1, 0))
+	if got := balance(t, chain, recipient); got != 1 {
+		t.Errorf("recipient has %d, want 1", got)
+	}
+}
@@ -0,0 +1,75 @@
+package blockchain
+
+import (
+	"errors"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 19/12/2025
+ * Time: 15:05
+ */
+
+// PERSISTENT MEMORY POOL
+// The network layer keeps unconfirmed transactions in memory; a copy of each one is
+// stored under "mempool-{txID}" so they survive a node restart.
+var mempoolPrefix = []byte("mempool-") // Database key prefix for pooled transactions
+
+// mempoolKey builds the database key for a pooled transaction
+func mempoolKey(txID []byte) []byte {
+	return append(append([]byte{}, mempoolPrefix...), txID...)
+}
+
+// SaveMempoolTx persists an unconfirmed transaction
+func (chain *BlockChain) SaveMempoolTx(tx *Transaction) error {
+	return chain.Database.Update(func(txn *badger.Txn) error {
+		return txn.Set(mempoolKey(tx.ID), tx.Serialize())
+	})
+}
+
+// DeleteMempoolTx removes a transaction from the persistent memory pool
+// Deleting a transaction that isn't stored is not an error
+func (chain *BlockChain) DeleteMempoolTx(txID []byte) error {
+	return chain.Database.Update(func(txn *badger.Txn) error {
+		return txn.Delete(mempoolKey(txID))
+	})
+}
+
+// IsMempoolTx reports whether a transaction is stored in the persistent memory pool
+func (chain *BlockChain) IsMempoolTx(txID []byte) (bool, error) {
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(mempoolKey(txID))
+		return err
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// LoadMempool returns every transaction in the persistent memory pool
+func (chain *BlockChain) LoadMempool() ([]Transaction, error) {
+	var txs []Transaction
+
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(mempoolPrefix); it.ValidForPrefix(mempoolPrefix); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				txs = append(txs, DeserializeTransaction(val))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return txs, err
+}
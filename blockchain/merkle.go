@@ -1,6 +1,9 @@
 package blockchain
 
-import "crypto/sha256"
+import (
+	"crypto/sha256"
+	"fmt"
+)
 
 /**
  * Created by GoLand.
@@ -62,15 +65,14 @@ func NewMerkleNode(left, right *MerkleNode, data []byte) *MerkleNode {
 func NewMerkleTree(data [][]byte) *MerkleTree {
 	var nodes []MerkleNode // Temporary slice to hold nodes at the current tree level
 
-	// STEP 1: Ensure an even number of leaves for binary tree construction
-	// Merkle Trees require an even number of nodes at each level
-	// If odd, duplicate the last element (common convention in blockchains)
-	if len(data)%2 != 0 {
-		data = append(data, data[len(data)-1]) // Duplicate last element
-		// In Bitcoin: This is called "balanced Merkle tree" construction
+	// STEP 0: An empty data set has no leaves to pair up
+	// Its root is defined as the hash of empty input: SHA-256("")
+	if len(data) == 0 {
+		hash := sha256.Sum256(nil)
+		return &MerkleTree{&MerkleNode{Data: hash[:]}}
 	}
 
-	// STEP 2: Create leaf nodes (bottom level of the tree)
+	// STEP 1: Create leaf nodes (bottom level of the tree)
 	// Each data block becomes a leaf node by hashing it
 	for _, dat := range data {
 		// Create a leaf node with no children, just hashed data
@@ -81,11 +83,19 @@ func NewMerkleTree(data [][]byte) *MerkleTree {
 	// DEBUG: At this point, nodes contain all leaf nodes
 	// Example with 4 transactions: nodes = [hash(tx1), hash(tx2), hash(tx3), hash(tx4)]
 
-	// STEP 3: Build a tree from bottom up (leaf → root)
+	// STEP 2: Build a tree from bottom up (leaf → root)
 	// We keep building parent levels until we reach a single root node
-	// Each iteration reduces the number of nodes by half
+	// Each iteration halves the number of nodes (rounding up)
+	// At least one level is always built, so a single leaf is paired with itself
+	// (this keeps the roots of existing one-transaction blocks, like genesis, unchanged)
+	for {
+		// Merkle Trees pair up nodes, so every level needs an even number of them
+		// If odd, duplicate the last (already hashed) node - Bitcoin does this at every level,
+		// not just for the leaves, because levels above the leaves can be odd too
+		if len(nodes)%2 != 0 {
+			nodes = append(nodes, nodes[len(nodes)-1])
+		}
 
-	for i := 0; i < len(data)/2; i++ {
 		var level []MerkleNode // Nodes at the next higher level
 
 		// Pair up nodes at the current level to create their parent nodes
@@ -97,16 +107,81 @@ func NewMerkleTree(data [][]byte) *MerkleTree {
 
 		// Move up one level: current nodes become the new level we just created
 		nodes = level
+		if len(nodes) == 1 {
+			break
+		}
 
-		// DEBUG Example with 4 leaves:
-		// Iteration 1: leaves [L1, L2, L3, L4] → parents [P1(hash(L1+L2)), P2(hash(L3+L4))]
-		// Iteration 2: parents/Branch [P1, P2] → root [R(hash(P1+P2))]
+		// DEBUG Example with 5 leaves:
+		// Iteration 1: [L1, L2, L3, L4, L5, L5'] → [P1, P2, P3]
+		// Iteration 2: [P1, P2, P3, P3'] → [Q1, Q2]
+		// Iteration 3: [Q1, Q2] → root [R(hash(Q1+Q2))]
 		// Done (len(nodes) = 1)
 	}
 
-	// STEP 4: Create the MerkleTree struct with the root node
+	// STEP 3: Create the MerkleTree struct with the root node
 	// The root node's hash is the Merkle Root - cryptographic fingerprint of all data
 	tree := MerkleTree{&nodes[0]} // nodes[0] is the final root node
 
 	return &tree
 }
+
+// MerkleProofStep is one level of a Merkle proof: the sibling hash to combine with
+type MerkleProofStep struct {
+	Hash []byte // Hash of the sibling node
+	Left bool   // The sibling is the left child, so it goes first when hashing
+}
+
+// NewMerkleProof returns the path from leaf index to the root of the tree NewMerkleTree builds
+// from data, pairing and duplicating nodes exactly the same way
+func NewMerkleProof(data [][]byte, index int) ([]MerkleProofStep, error) {
+	if index < 0 || index >= len(data) {
+		return nil, fmt.Errorf("leaf %d out of range for %d leaves", index, len(data))
+	}
+
+	var level [][]byte
+	for _, dat := range data {
+		level = append(level, NewMerkleNode(nil, nil, dat).Data)
+	}
+
+	var proof []MerkleProofStep
+	for {
+		if len(level)%2 != 0 {
+			level = append(level, level[len(level)-1])
+		}
+
+		// The sibling of an even index is to its right, of an odd index to its left
+		if index%2 == 0 {
+			proof = append(proof, MerkleProofStep{Hash: level[index+1], Left: false})
+		} else {
+			proof = append(proof, MerkleProofStep{Hash: level[index-1], Left: true})
+		}
+
+		var next [][]byte
+		for j := 0; j < len(level); j += 2 {
+			next = append(next, hashPair(level[j], level[j+1]))
+		}
+		level, index = next, index/2
+		if len(level) == 1 {
+			return proof, nil
+		}
+	}
+}
+
+// MerkleProofRoot folds a proof starting from a leaf hash and returns the root it leads to
+func MerkleProofRoot(leafHash []byte, proof []MerkleProofStep) []byte {
+	hash := leafHash
+	for _, step := range proof {
+		if step.Left {
+			hash = hashPair(step.Hash, hash)
+		} else {
+			hash = hashPair(hash, step.Hash)
+		}
+	}
+	return hash
+}
+
+// hashPair hashes two child hashes into their parent, as NewMerkleNode does
+func hashPair(left, right []byte) []byte {
+	hash := sha256.Sum256(append(append([]byte{}, left...), right...))
+	return hash[:]
+}
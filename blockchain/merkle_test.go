@@ -0,0 +1,85 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 11:00
+ */
+
+// leaves returns n distinct pieces of leaf data and their hashes
+func leaves(n int) ([][]byte, [][]byte) {
+	var data, hashes [][]byte
+	for i := 1; i <= n; i++ {
+		dat := []byte(fmt.Sprintf("tx%d", i))
+		hash := sha256.Sum256(dat)
+		data, hashes = append(data, dat), append(hashes, hash[:])
+	}
+	return data, hashes
+}
+
+func TestMerkleTreeDuplicatesOddNodesAtEveryLevel(t *testing.T) {
+	h := hashPair
+
+	// Worked out by hand: an odd level pairs its last node with itself, leaves and above
+	roots := map[int]func(l [][]byte) []byte{
+		5: func(l [][]byte) []byte {
+			p1, p2, p3 := h(l[0], l[1]), h(l[2], l[3]), h(l[4], l[4])
+			return h(h(p1, p2), h(p3, p3))
+		},
+		6: func(l [][]byte) []byte {
+			p1, p2, p3 := h(l[0], l[1]), h(l[2], l[3]), h(l[4], l[5])
+			return h(h(p1, p2), h(p3, p3))
+		},
+		7: func(l [][]byte) []byte {
+			p1, p2, p3, p4 := h(l[0], l[1]), h(l[2], l[3]), h(l[4], l[5]), h(l[6], l[6])
+			return h(h(p1, p2), h(p3, p4))
+		},
+	}
+
+	for n, root := range roots {
+		data, hashes := leaves(n)
+		want := root(hashes)
+		if got := NewMerkleTree(data).RootNode.Data; !bytes.Equal(got, want) {
+			t.Errorf("%d leaves: root %x, want %x", n, got, want)
+		}
+
+		// Every proof leads to the same root
+		for i := range data {
+			proof, err := NewMerkleProof(data, i)
+			if err != nil {
+				t.Fatalf("%d leaves: proof of %d: %v", n, i, err)
+			}
+			if got := MerkleProofRoot(hashes[i], proof); !bytes.Equal(got, want) {
+				t.Errorf("%d leaves: proof of leaf %d leads to %x, want %x", n, i, got, want)
+			}
+		}
+	}
+}
+
+func TestBlockWithoutTransactionsHasTheEmptyRoot(t *testing.T) {
+	want := sha256.Sum256(nil)
+	if got := NewMerkleTree(nil).RootNode.Data; !bytes.Equal(got, want[:]) {
+		t.Errorf("empty tree root %x, want SHA-256(\"\") %x", got, want)
+	}
+
+	block := &Block{Timestamp: 1, PrevHash: []byte("parent"), Height: 1}
+	if got := block.HashTransactions(); !bytes.Equal(got, want[:]) {
+		t.Errorf("HashTransactions of an empty block = %x, want %x", got, want)
+	}
+
+	// Such a block can still be proven and checked
+	pow := NewProof(block, 8)
+	block.Nonce, block.Hash = pow.Run()
+	if !NewProof(block, 8).Validate() {
+		t.Error("proof of work of an empty block does not validate")
+	}
+}
@@ -0,0 +1,152 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 18:40
+ */
+
+// OFFLINE SIGNING
+// A private key never has to touch a networked machine:
+//
+//	online:  buildtx      selects the inputs and writes an UnsignedTx (no keys needed)
+//	offline: signtx       signs it with the wallet and writes the signed transaction
+//	online:  broadcasttx  hands the signed transaction to the network
+//
+// Signing needs the outputs being spent (their lock is part of what each input signs),
+// and the offline machine has no chain to look them up in, so the UnsignedTx carries
+// the previous transactions along. The signer checks each one hashes to the ID its
+// input spends, and computes the fee from their outputs instead of trusting Fee.
+
+// UnsignedTx is a transaction waiting to be signed away from the chain
+type UnsignedTx struct {
+	From    string                 // Address whose outputs are spent; its wallet signs
+	Fee     int                    // Inputs minus outputs, left for the miner
+	Tx      Transaction            // Inputs selected, signatures and public keys still nil
+	PrevTxs map[string]Transaction // Transactions whose outputs Tx spends, by hex ID
+}
+
+// NewUnsignedTransaction builds a payment of amount from one address to another, leaving
+// fee for the miner, like NewTransaction but without the sender's keys
+// Returns ErrInvalidAddress or ErrInsufficientFunds like NewTransaction
+func NewUnsignedTransaction(from, to string, amount, fee int, UTXO *UTXOSet) (*UnsignedTx, error) {
+	if !wallet.ValidateAddress(from) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, from)
+	}
+
+	// Remove version [1 first byte] and checksum [4 last bytes]
+	pubKeyHash := wallet.Base58Decode([]byte(from))
+	pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-4]
+
+	// The public key is filled in by the signer, who is the only one holding it
+	inputs, outputs, err := fundPayment(pubKeyHash, nil, from, to, amount, fee, nil, UTXO)
+	if err != nil {
+		return nil, err
+	}
+
+	prevTXs := make(map[string]Transaction)
+	for _, in := range inputs {
+		prevTX, err := UTXO.Blockchain.FindTransaction(in.ID)
+		if err != nil {
+			return nil, fmt.Errorf("input %x: %w", in.ID, err)
+		}
+		prevTXs[hex.EncodeToString(in.ID)] = prevTX
+	}
+
+	return &UnsignedTx{
+		From:    from,
+		Fee:     fee,
+		Tx:      Transaction{Inputs: inputs, Outputs: outputs},
+		PrevTxs: prevTXs,
+	}, nil
+}
+
+// PaidFee returns what the transaction leaves for the miner: the previous outputs it
+// spends minus its own outputs
+// Each previous transaction must hash to the ID its input refers to, so the values come
+// from the outputs actually spent rather than from whatever the online machine claims
+// Outputs of transactions from before the canonical encoding can't be checked that way
+// and are refused
+func (u *UnsignedTx) PaidFee() (int, error) {
+	inputTotal := 0
+	for _, in := range u.Tx.Inputs {
+		prevTX, ok := u.PrevTxs[hex.EncodeToString(in.ID)]
+		if !ok || in.Out < 0 || in.Out >= len(prevTX.Outputs) {
+			return 0, fmt.Errorf("input %x:%d: previous output missing", in.ID, in.Out)
+		}
+		if hash := prevTX.Hash(); !bytes.Equal(hash, in.ID) {
+			return 0, fmt.Errorf("input %x:%d: %w: previous transaction hashes to %x", in.ID, in.Out, ErrInvalidTransactionID, hash)
+		}
+		inputTotal += prevTX.Outputs[in.Out].Value
+	}
+
+	outputTotal := 0
+	for _, out := range u.Tx.Outputs {
+		outputTotal += out.Value
+	}
+	return inputTotal - outputTotal, nil
+}
+
+// Sign returns the transaction signed by w, which must own every output it spends
+// The fee is recomputed from the previous outputs (see PaidFee); Sign returns
+// ErrUnbalancedTransaction unless it is Fee, so a tampered file can't make the signer
+// give more to the miner than agreed
+// The unsigned transaction itself is left as it is
+func (u *UnsignedTx) Sign(w *wallet.Wallet) (*Transaction, error) {
+	if w == nil {
+		return nil, ErrWalletNotFound
+	}
+
+	fee, err := u.PaidFee()
+	if err != nil {
+		return nil, err
+	}
+	if fee != u.Fee {
+		return nil, fmt.Errorf("%w: the inputs leave a fee of %d, the transaction claims %d", ErrUnbalancedTransaction, fee, u.Fee)
+	}
+
+	pubKeyHash := wallet.PublicKeyHash(w.PublicKey)
+	tx := Transaction{Outputs: append([]TxOutput{}, u.Tx.Outputs...)}
+	for _, in := range u.Tx.Inputs {
+		prevTX := u.PrevTxs[hex.EncodeToString(in.ID)]
+		if !prevTX.Outputs[in.Out].IsLockedWithKey(pubKeyHash) {
+			return nil, fmt.Errorf("input %x:%d is not locked to %s", in.ID, in.Out, w.Address())
+		}
+		tx.Inputs = append(tx.Inputs, TxInput{ID: in.ID, Out: in.Out, PubKey: w.PublicKey})
+	}
+
+	// The same steps as NewTransaction: the signatures first, then the ID covering them
+	if err := tx.Sign(w.PrivateKey, u.PrevTxs); err != nil {
+		return nil, err
+	}
+	tx.SetID()
+	return &tx, nil
+}
+
+// Serialize encodes the unsigned transaction for the trip to the signing machine
+func (u UnsignedTx) Serialize() []byte {
+	var buffer bytes.Buffer
+	err := gob.NewEncoder(&buffer).Encode(u)
+	Handle(err)
+	return buffer.Bytes()
+}
+
+// DeserializeUnsignedTx decodes bytes produced by UnsignedTx.Serialize
+func DeserializeUnsignedTx(data []byte) (*UnsignedTx, error) {
+	var u UnsignedTx
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&u); err != nil {
+		return nil, fmt.Errorf("decode unsigned transaction: %w", err)
+	}
+	return &u, nil
+}
@@ -0,0 +1,102 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 10:30
+ */
+
+// newTestUnsignedTx builds an unsigned transfer on chain and carries it through the
+// bytes written for the signing machine
+func newTestUnsignedTx(t *testing.T, chain *BlockChain, sender, recipient *wallet.Wallet, amount, fee int) *UnsignedTx {
+	t.Helper()
+
+	built, err := NewUnsignedTransaction(string(sender.Address()), string(recipient.Address()), amount, fee, &UTXOSet{Blockchain: chain})
+	if err != nil {
+		t.Fatalf("buildtx: %v", err)
+	}
+	unsigned, err := DeserializeUnsignedTx(built.Serialize())
+	if err != nil {
+		t.Fatalf("read unsigned transaction: %v", err)
+	}
+	return unsigned
+}
+
+func TestBuildSignBroadcast(t *testing.T) {
+	sender, recipient, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	// buildtx, online and without keys
+	unsigned := newTestUnsignedTx(t, chain, sender, recipient, 30, 2)
+
+	// signtx, offline
+	signed, err := unsigned.Sign(sender)
+	if err != nil {
+		t.Fatalf("signtx: %v", err)
+	}
+	if fee, err := unsigned.PaidFee(); err != nil || fee != 2 {
+		t.Errorf("PaidFee = %d, %v, want 2", fee, err)
+	}
+
+	// broadcasttx: what a node receives is checked like any other transaction, then mined
+	tx, err := DeserializeTransactionE(signed.Serialize())
+	if err != nil {
+		t.Fatalf("read signed transaction: %v", err)
+	}
+	if err := tx.CheckID(); err != nil {
+		t.Fatalf("CheckID: %v", err)
+	}
+	if !chain.VerifyTransaction(&tx) {
+		t.Fatal("the signed transaction does not verify")
+	}
+	block := mineTestBlock(t, chain, miner, &tx)
+
+	if got := balance(t, chain, recipient); got != 30 {
+		t.Errorf("recipient has %d, want 30", got)
+	}
+	if got := balance(t, chain, sender); got != 68 {
+		t.Errorf("sender has %d, want 68", got)
+	}
+	if got, want := balance(t, chain, miner), BlockReward(block.Height)+2; got != want {
+		t.Errorf("miner has %d, want %d", got, want)
+	}
+}
+
+func TestSignRejectsTamperedUnsignedTx(t *testing.T) {
+	sender, recipient := wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	t.Run("change lowered", func(t *testing.T) {
+		unsigned := newTestUnsignedTx(t, chain, sender, recipient, 30, 2)
+		unsigned.Tx.Outputs[len(unsigned.Tx.Outputs)-1].Value -= 50 // Fee still claims 2
+
+		if _, err := unsigned.Sign(sender); !errors.Is(err, ErrUnbalancedTransaction) {
+			t.Errorf("Sign = %v, want ErrUnbalancedTransaction", err)
+		}
+	})
+
+	t.Run("previous output inflated", func(t *testing.T) {
+		unsigned := newTestUnsignedTx(t, chain, sender, recipient, 30, 2)
+		for id, prevTX := range unsigned.PrevTxs {
+			prevTX.Outputs = append([]TxOutput{}, prevTX.Outputs...)
+			for i := range prevTX.Outputs {
+				prevTX.Outputs[i].Value += 50
+			}
+			unsigned.PrevTxs[id] = prevTX
+		}
+		unsigned.Fee += 50 * len(unsigned.Tx.Inputs) // Agrees with the forged values
+
+		if _, err := unsigned.Sign(sender); !errors.Is(err, ErrInvalidTransactionID) {
+			t.Errorf("Sign = %v, want ErrInvalidTransactionID", err)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"errors"
+	"sync"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 18/12/2025
+ * Time: 10:15
+ */
+
+// ORPHAN BLOCKS
+// Blocks can arrive before their parent (e.g. during a sync, peers send the tip first).
+// Such an "orphan" can't be validated or linked yet, so it waits in memory until the
+// parent is stored, then AddBlock connects it (and anything waiting on it in turn).
+
+// maxOrphans caps the number of orphan blocks kept in memory
+const maxOrphans = 500
+
+// ErrOrphanBlock is returned by AddBlock when the block's parent is unknown
+// The block is kept in the orphan pool and added automatically once its parent arrives
+var ErrOrphanBlock = errors.New("orphan block: parent not found")
+
+// orphanPool holds orphan blocks keyed by the hash of the parent they are waiting for
+// It is safe for concurrent use
+type orphanPool struct {
+	mu       sync.Mutex
+	byParent map[string][]*Block // Parent hash (hex) -> blocks waiting for it
+	known    map[string]bool     // Hashes (hex) of every block in the pool
+}
+
+// orphans is the node's orphan pool, shared by every AddBlock call
+var orphans = newOrphanPool()
+
+func newOrphanPool() *orphanPool {
+	return &orphanPool{byParent: make(map[string][]*Block), known: make(map[string]bool)}
+}
+
+// add queues a block until its parent arrives
+// Returns false if the block was already queued or the pool is full
+func (p *orphanPool) add(block *Block) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	hash := hex.EncodeToString(block.Hash)
+	if p.known[hash] || len(p.known) >= maxOrphans {
+		return false
+	}
+
+	parent := hex.EncodeToString(block.PrevHash)
+	p.byParent[parent] = append(p.byParent[parent], block)
+	p.known[hash] = true
+	return true
+}
+
+// takeChildren removes and returns the orphans waiting for the given parent hash
+func (p *orphanPool) takeChildren(parentHash []byte) []*Block {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	parent := hex.EncodeToString(parentHash)
+	children := p.byParent[parent]
+	delete(p.byParent, parent)
+	for _, child := range children {
+		delete(p.known, hex.EncodeToString(child.Hash))
+	}
+	return children
+}
+
+// OrphanCount returns how many orphan blocks are waiting for their parent
+func OrphanCount() int {
+	orphans.mu.Lock()
+	defer orphans.mu.Unlock()
+	return len(orphans.known)
+}
@@ -0,0 +1,27 @@
+package blockchain
+
+import "fmt"
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 17/12/2025
+ * Time: 15:10
+ */
+
+// ChainParams groups the settings that distinguish one chain (or a fork of it) from another
+// A fork can brand its own currency by changing Params instead of editing the core
+type ChainParams struct {
+	Ticker string // Currency ticker shown next to every amount, e.g. "TZS"
+}
+
+// Params are the parameters of the chain this node runs
+var Params = ChainParams{
+	Ticker: "TZS",
+}
+
+// FormatAmount renders an amount with the chain's currency ticker, e.g. "TZS 100"
+func (p ChainParams) FormatAmount(amount int) string {
+	return fmt.Sprintf("%s %d", p.Ticker, amount)
+}
@@ -2,11 +2,15 @@ package blockchain
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
+
+	"github.com/golang-blockchain/logger"
 )
 
 /**
@@ -105,22 +109,34 @@ import (
  * In production, this would be replaced with a dynamic difficulty algorithm.
  */
 
+// Difficulty is the proof-of-work difficulty of chains created without choosing one
+// Each chain records its own when it is created (see difficulty.go)
 const Difficulty = 20
 
+// cancelCheckInterval is how many nonces RunWithContext tries between checks for cancellation
+// Checking the context on every hash would slow mining down noticeably
+const cancelCheckInterval = 4096
+
+// ErrMiningCancelled is returned when mining is abandoned before a valid nonce is found
+// e.g. because a competing block arrived from the network
+var ErrMiningCancelled = errors.New("mining cancelled")
+
 type ProofOfWork struct {
-	Block  *Block   // The block inside the blockchain
-	Target *big.Int // The number that represents the requirements we described that derived by the difficulty. [The number to be targeted as nonce]
+	Block      *Block   // The block inside the blockchain
+	Target     *big.Int // The number that represents the requirements we described that derived by the difficulty. [The number to be targeted as nonce]
+	Difficulty int      // Leading zero bits required by the block's chain
 }
 
 // NewProof Special function for taking the pointer from the block and produce the pointer to the proof of work
-func NewProof(b *Block) *ProofOfWork {
+// difficulty is that of the chain the block belongs to; BlockChain.Proof supplies it
+func NewProof(b *Block, difficulty int) *ProofOfWork {
 	/*
 			Decimal: 1
 			Binary: 0000000000000000000000000000000000000000000000000000000000000001 (256 bits total)
 		    Shift: ↑ 244 positions to the left
 	*/
 	target := big.NewInt(1)
-	target.Lsh(target, uint(256-Difficulty))
+	target.Lsh(target, uint(256-difficulty))
 	/*  In Binary:
 			After: 000000000000 1 000000000000000000000000000000000000000000000000000
 	               000000000000000000000000000000000000000000000000000000000000000000
@@ -134,34 +150,50 @@ func NewProof(b *Block) *ProofOfWork {
 	//fmt.Println(target)        // Decimal: 28195255290653389114320483313055315385331013294976499200896921600
 	//fmt.Printf("%b\n", target) // Binary: 1 followed by 244 zeros
 	//fmt.Printf("%x\n", target) // Hexadecimal: 1 followed by 61 zeros
-	pow := &ProofOfWork{b, target}
+	pow := &ProofOfWork{b, target, difficulty}
 	return pow
 }
 
 // InitData Special function for creating the data to be hashed, replaces DeriveHash() in block.go
 func (pow *ProofOfWork) InitData(nonce int) []byte {
-	data := bytes.Join(
-		[][]byte{
-			pow.Block.PrevHash,           // Previous block's hash
-			pow.Block.HashTransactions(), // Current block's transaction data - Since we are using Transactions, we are not sending the data directly
-			ToHex(int64(nonce)),          // The nonce we're testing
-			ToHex(int64(Difficulty)),     // Current network difficulty
-		},
-		[]byte{}, // Separator (empty = no separator)
-	)
-	return data
+	// Since we are using Transactions, we are not sending the data directly but their Merkle root
+	// The layout is fixed by encodeHeader (see header.go)
+	return encodeHeader(pow.Block.Version, pow.Block.PrevHash, pow.Block.HashTransactions(), nonce, pow.Difficulty)
 }
 
 // Run Special function for running our algorithm
 func (pow *ProofOfWork) Run() (int, []byte) {
+	// A background context is never cancelled, so the error is always nil here
+	nonce, hash, _ := pow.RunWithContext(context.Background())
+	return nonce, hash
+}
+
+// RunWithContext runs the mining loop like Run, but gives up once ctx is cancelled
+// The context is checked every cancelCheckInterval nonces; on cancellation it returns
+// ErrMiningCancelled and no nonce or hash, so nothing partial can be stored in the block
+func (pow *ProofOfWork) RunWithContext(ctx context.Context) (int, []byte, error) {
 	var intHash big.Int
 	var hash [32]byte
 
 	nonce := 0
+	showProgress := logger.DebugEnabled() // Printing every hash slows mining down a lot
+
 	// MINING LOOP: Iterate through possible nonce values
 	// The nonce is the "number used once" that we change each iteration
 	// to create different hash inputs until we find a valid proof
 	for nonce < math.MaxInt64 {
+		// 0. CANCELLATION CHECK: Stop if someone else already extended the chain
+		if nonce%cancelCheckInterval == 0 {
+			select {
+			case <-ctx.Done():
+				if showProgress {
+					fmt.Println()
+				}
+				return 0, nil, ErrMiningCancelled
+			default:
+			}
+		}
+
 		// 1. PREPARE DATA: Combine block data with current nonce
 		//    This creates unique input for each mining attempt
 		data := pow.InitData(nonce)
@@ -170,9 +202,11 @@ func (pow *ProofOfWork) Run() (int, []byte) {
 		//    This is the computationally expensive part of Proof of Work
 		hash = sha256.Sum256(data)
 
-		// 3. DEBUG OUTPUT: Display a hash attempt (can be removed in production)
+		// 3. DEBUG OUTPUT: Display a hash attempt (only with LOG_LEVEL=debug)
 		//    Shows the mining progress in hexadecimal format
-		fmt.Printf("\r%x", hash)
+		if showProgress {
+			fmt.Printf("\r%x", hash)
+		}
 
 		// 4. CONVERT TO BIG INT: Convert hash to big.Int for mathematical comparison
 		//    Allows us to compare the hash value against the target difficulty
@@ -191,12 +225,14 @@ func (pow *ProofOfWork) Run() (int, []byte) {
 			nonce++
 		}
 	}
-	fmt.Println()
+	if showProgress {
+		fmt.Println()
+	}
 
 	// RETURN: Valid nonce and corresponding hash
 	// - nonce: The proof that work was done (must be included in block)
 	// - hash: The valid hash that meets the difficulty requirement
-	return nonce, hash[:]
+	return nonce, hash[:], nil
 }
 
 /**
@@ -220,7 +256,7 @@ func (pow *ProofOfWork) Validate() bool {
 	// 1. RECONSTRUCT THE INPUT DATA
 	// Use the nonce that was already found and stored in the block during mining
 	// This recreates the exact same input that was used to create the valid proof
-	data := pow.InitData(pow.Block.Nonce)
+	data := pow.Block.headerBytes(pow.Difficulty)
 
 	// 2. COMPUTE THE HASH
 	// Calculate SHA-256 hash of the data (single computation - very fast)
@@ -240,8 +276,8 @@ func (pow *ProofOfWork) Validate() bool {
 /**
  * CONVERTS INT64 TO BIG-ENDIAN BYTE REPRESENTATION
  *
- * Despite the name "ToHex"; this function actually converts an int64 into its
- * 8-byte binary representation using big-endian byte order.
+ * IntToBytes converts an int64 into its 8-byte binary representation using
+ * big-endian byte order. (It used to be called "ToHex", which was misleading.)
  *
  * WHAT IT DOES:
  * - Takes an int64 integer as input
@@ -254,7 +290,7 @@ func (pow *ProofOfWork) Validate() bool {
  * - Alternative (little-endian) would be: []byte{0x2A,0x00,0x00,0x00,0x00,0x00,0x00,0x00}
  *
  * USAGE IN PROOF OF WORK:
- * This function is used in InitData() to convert:
+ * The block hash (see encodeHeader in header.go) uses this same 8-byte form for:
  * - Nonce values (int) → 8-byte binary for hashing
  * - Difficulty values (int) → 8-byte binary for hashing
  *
@@ -265,20 +301,25 @@ func (pow *ProofOfWork) Validate() bool {
  * - Standard format for network protocols and blockchain systems
  *
  * EXAMPLE OUTPUTS:
- * ToHex(1)     → [0x00 0x00 0x00 0x00 0x00 0x00 0x00 0x01]
- * ToHex(256)   → [0x00 0x00 0x00 0x00 0x00 0x00 0x01 0x00]
- * ToHex(65535) → [0x00 0x00 0x00 0x00 0x00 0x00 0xFF 0xFF]
+ * IntToBytes(1)     → [0x00 0x00 0x00 0x00 0x00 0x00 0x00 0x01]
+ * IntToBytes(256)   → [0x00 0x00 0x00 0x00 0x00 0x00 0x01 0x00]
+ * IntToBytes(65535) → [0x00 0x00 0x00 0x00 0x00 0x00 0xFF 0xFF]
+ * IntToBytes(-1)    → [0xFF 0xFF 0xFF 0xFF 0xFF 0xFF 0xFF 0xFF] (two's complement)
  *
- * NOTE: The function name "ToHex" is misleading - it returns binary bytes,
- * not a hexadecimal string. A more accurate name would be "IntToBytes"
- * or "Int64ToBigEndian".
+ * A failed write would leave the caller with garbage, so it panics via Handle
+ * instead of returning nil (writing an int64 to a bytes.Buffer can't fail in practice).
  */
 
-func ToHex(num int64) []byte {
+func IntToBytes(num int64) []byte {
 	buffer := new(bytes.Buffer)                        // Create a new bytes buffer
 	err := binary.Write(buffer, binary.BigEndian, num) // Write num as binary bytes
-	if err != nil {
-		return nil
-	}
+	Handle(err)
 	return buffer.Bytes() // Return the binary bytes
 }
+
+// ToHex is the old name of IntToBytes
+//
+// Deprecated: use IntToBytes; the result is binary, not hex.
+func ToHex(num int64) []byte {
+	return IntToBytes(num)
+}
@@ -0,0 +1,105 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/sha256"
+	"math"
+	"math/big"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang-blockchain/logger"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 17:40
+ */
+
+// PARALLEL MINING
+// RunWithContext tries one nonce after another on a single goroutine. RunParallel gives
+// each of N workers its own stride of the nonce space (worker i tries i, i+N, i+2N, ...),
+// so every core hashes. A worker that finds a valid hash lowers the shared best nonce;
+// workers stop once they pass it, and a worker that finds a smaller one lowers it again.
+// The result is therefore the smallest valid nonce, exactly what the serial loop returns,
+// only found sooner.
+//
+// MINING_WORKERS sets N (default: one per CPU); 1 keeps the serial loop.
+var MiningWorkers = loadMiningWorkers()
+
+// loadMiningWorkers reads the number of mining goroutines from the MINING_WORKERS env. var.
+// Falls back to runtime.NumCPU() when the variable is unset or not a positive number
+func loadMiningWorkers() int {
+	defaultWorkers := runtime.NumCPU()
+	value := os.Getenv("MINING_WORKERS")
+	if value == "" {
+		return defaultWorkers
+	}
+	workers, err := strconv.Atoi(value)
+	if err != nil || workers < 1 {
+		logger.Warn("Invalid MINING_WORKERS, using the default", "value", value, "default", defaultWorkers)
+		return defaultWorkers
+	}
+	return workers
+}
+
+// RunParallel mines like RunWithContext, spreading the nonces over workers goroutines
+// It returns the same nonce and hash as the serial loop, or ErrMiningCancelled once ctx is cancelled
+func (pow *ProofOfWork) RunParallel(ctx context.Context, workers int) (int, []byte, error) {
+	if workers <= 1 {
+		return pow.RunWithContext(ctx)
+	}
+
+	// The Merkle root doesn't depend on the nonce; hash the transactions once, not per attempt
+	version, prevHash, merkleRoot := pow.Block.Version, pow.Block.PrevHash, pow.Block.HashTransactions()
+
+	var best atomic.Int64 // Smallest valid nonce found so far
+	best.Store(math.MaxInt64)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(start int64) {
+			defer wg.Done()
+			var intHash big.Int
+
+			// Nonces beyond the best known valid one can't win; stop there
+			for nonce, tried := start, 0; nonce < best.Load(); nonce, tried = nonce+int64(workers), tried+1 {
+				if tried%cancelCheckInterval == 0 && ctx.Err() != nil {
+					return
+				}
+
+				hash := sha256.Sum256(encodeHeader(version, prevHash, merkleRoot, int(nonce), pow.Difficulty))
+				if intHash.SetBytes(hash[:]).Cmp(pow.Target) == -1 {
+					// Lower the shared best unless another worker already found a smaller nonce
+					for {
+						current := best.Load()
+						if nonce >= current || best.CompareAndSwap(current, nonce) {
+							return
+						}
+					}
+				}
+				if nonce > math.MaxInt64-int64(workers) {
+					return // The next stride would overflow
+				}
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return 0, nil, ErrMiningCancelled
+	}
+	nonce := best.Load()
+	if nonce == math.MaxInt64 {
+		return 0, nil, ErrMiningCancelled // Nonce space exhausted; can't happen at any usable difficulty
+	}
+	hash := sha256.Sum256(encodeHeader(version, prevHash, merkleRoot, int(nonce), pow.Difficulty))
+	return int(nonce), hash[:], nil
+}
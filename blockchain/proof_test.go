@@ -0,0 +1,93 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"testing"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 11:50
+ */
+
+func TestIntToBytesIsBigEndian(t *testing.T) {
+	for _, c := range []struct {
+		num  int64
+		want []byte
+	}{
+		{0, []byte{0, 0, 0, 0, 0, 0, 0, 0}},
+		{1, []byte{0, 0, 0, 0, 0, 0, 0, 1}},
+		{256, []byte{0, 0, 0, 0, 0, 0, 1, 0}},
+		{0x0102030405060708, []byte{1, 2, 3, 4, 5, 6, 7, 8}},
+		{-1, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{math.MaxInt64, []byte{0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{math.MinInt64, []byte{0x80, 0, 0, 0, 0, 0, 0, 0}},
+	} {
+		if got := IntToBytes(c.num); !bytes.Equal(got, c.want) {
+			t.Errorf("IntToBytes(%d) = %x, want %x", c.num, got, c.want)
+		}
+		if got := ToHex(c.num); !bytes.Equal(got, c.want) {
+			t.Errorf("ToHex(%d) = %x, want %x", c.num, got, c.want)
+		}
+	}
+}
+
+func TestRunParallelFindsTheSerialNonce(t *testing.T) {
+	const difficulty = 12
+	for i := 0; i < 4; i++ {
+		block := goldenBlock(currentBlockVersion)
+		block.PrevHash = bytes.Repeat([]byte{byte(i)}, 32)
+		pow := NewProof(block, difficulty)
+
+		want, wantHash := pow.Run()
+		for _, workers := range []int{2, 3, 8} {
+			nonce, hash, err := pow.RunParallel(context.Background(), workers)
+			if err != nil {
+				t.Fatalf("block %d, %d workers: %v", i, workers, err)
+			}
+			if nonce != want || !bytes.Equal(hash, wantHash) {
+				t.Errorf("block %d, %d workers: nonce %d, want the serial %d", i, workers, nonce, want)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := NewProof(goldenBlock(currentBlockVersion), MaxDifficulty).RunParallel(ctx, 4); !errors.Is(err, ErrMiningCancelled) {
+		t.Errorf("RunParallel with a cancelled context = %v, want ErrMiningCancelled", err)
+	}
+}
+
+// BenchmarkMining compares the serial loop with RunParallel; the speedup shows on
+// machines with more than one CPU
+func BenchmarkMining(b *testing.B) {
+	const difficulty = 14
+	mine := func(b *testing.B, run func(pow *ProofOfWork)) {
+		block := goldenBlock(currentBlockVersion)
+		for i := 0; i < b.N; i++ {
+			block.PrevHash = IntToBytes(int64(i)) // A fresh search every time
+			run(NewProof(block, difficulty))
+		}
+	}
+
+	b.Run("serial", func(b *testing.B) {
+		mine(b, func(pow *ProofOfWork) { pow.Run() })
+	})
+	for _, workers := range []int{2, 4, runtime.NumCPU()} {
+		b.Run(fmt.Sprintf("parallel-%d", workers), func(b *testing.B) {
+			mine(b, func(pow *ProofOfWork) {
+				if _, _, err := pow.RunParallel(context.Background(), workers); err != nil {
+					b.Fatal(err)
+				}
+			})
+		})
+	}
+}
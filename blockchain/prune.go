@@ -0,0 +1,134 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/golang-blockchain/logger"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 24/12/2025
+ * Time: 16:40
+ */
+
+// PRUNING
+// An archive node keeps every transaction forever. A pruned node drops the data of
+// transactions that can never be needed again, from blocks deep enough below the tip:
+//
+//	[ header | PrunedRoot | unspent txs ]  ...  [ full blocks within keepHeight of the tip ]
+//
+// A transaction is kept while any of its outputs is unspent (it will be looked up when
+// spent) or while a block within keepHeight spends it (a reorg may need to revert that
+// block). The header and the original Merkle root stay, so the chain of proof of work
+// can still be checked; lookups of removed transactions return ErrTransactionPruned.
+// The UTXO set can't be rebuilt from a pruned chain, and such blocks are never served
+// to or accepted from peers.
+const MinPruneKeep = 100 // Fewest recent blocks Prune must leave intact
+
+var prunedKey = []byte("pruned") // Highest height pruned so far; absent on archive nodes
+
+// Errors returned for pruned data
+var (
+	ErrTransactionPruned = errors.New("transaction data has been pruned")
+	ErrChainPruned       = errors.New("blockchain has been pruned")
+)
+
+// Prune removes fully spent transactions from active-chain blocks more than keepHeight
+// below the tip. keepHeight must be at least MinPruneKeep
+func (chain *BlockChain) Prune(keepHeight int) error {
+	if keepHeight < MinPruneKeep {
+		return fmt.Errorf("must keep at least %d blocks, got %d", MinPruneKeep, keepHeight)
+	}
+
+	tip := chain.GetBestHeight()
+	boundary := tip - keepHeight // Highest height that gets pruned
+	if boundary < 0 {
+		return nil // Chain is too short to prune anything
+	}
+
+	// Transactions spent by the blocks we keep must survive for Revert
+	needed := make(map[string]bool)
+	for height := boundary + 1; height <= tip; height++ {
+		block, err := chain.blockAtHeight(height)
+		if err != nil {
+			return err
+		}
+		for _, tx := range block.Transactions {
+			for _, in := range tx.Inputs {
+				needed[string(in.ID)] = true
+			}
+		}
+	}
+
+	removed := 0
+	for height := 0; height <= boundary; height++ {
+		block, err := chain.blockAtHeight(height)
+		if err != nil {
+			return err
+		}
+
+		err = chain.Database.Update(func(txn *badger.Txn) error {
+			var kept []*Transaction
+			for _, tx := range block.Transactions {
+				if needed[string(tx.ID)] {
+					kept = append(kept, tx)
+					continue
+				}
+				_, err := txn.Get(append(append([]byte{}, utxoPrefix...), tx.ID...))
+				if err == nil {
+					kept = append(kept, tx) // Still has unspent outputs
+				} else if !errors.Is(err, badger.ErrKeyNotFound) {
+					return err
+				}
+			}
+			if len(kept) == len(block.Transactions) {
+				return nil // Nothing to drop
+			}
+
+			// Save the root before the transactions go; it is part of the block hash
+			if !block.IsPruned() {
+				block.PrunedRoot = block.HashTransactions()
+			}
+			removed += len(block.Transactions) - len(kept)
+			block.Transactions = kept
+			return txn.Set(block.Hash, block.Serialize())
+		})
+		if err != nil {
+			return fmt.Errorf("pruning block at height %d: %w", height, err)
+		}
+	}
+
+	err := chain.Database.Update(func(txn *badger.Txn) error {
+		return txn.Set(prunedKey, []byte(strconv.Itoa(boundary)))
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Pruned the chain", "transactions", removed, "throughHeight", boundary)
+	return nil
+}
+
+// IsPruned reports whether Prune has ever removed data from this chain
+func (chain *BlockChain) IsPruned() bool {
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(prunedKey)
+		return err
+	})
+	return err == nil
+}
+
+// blockAtHeight returns the active-chain block at the given height
+func (chain *BlockChain) blockAtHeight(height int) (*Block, error) {
+	block, err := chain.GetBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
@@ -0,0 +1,94 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 13:20
+ */
+
+func TestPruneKeepsHeadersAndReportsPrunedLookups(t *testing.T) {
+	sender, recipient, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+	chain.Consensus = nil // Real proof of work, so the headers can be checked on their own
+
+	// Spending the whole genesis output leaves genesis with nothing worth keeping
+	genesisBlock, err := chain.blockAtHeight(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spent := genesisBlock.Transactions[0]
+	transfer := newTestTransfer(t, chain, sender, recipient, 100, 0)
+	mineTestBlock(t, chain, miner, transfer)
+	for chain.GetBestHeight() < MinPruneKeep+2 {
+		mineTestBlock(t, chain, miner)
+	}
+
+	if err := chain.Prune(MinPruneKeep); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if !chain.IsPruned() {
+		t.Fatal("IsPruned = false after pruning")
+	}
+
+	// The spending transactions are gone, so the UTXO set can't be rebuilt, and stays as it was
+	if err := (UTXOSet{Blockchain: chain}).Reindex(); !errors.Is(err, ErrChainPruned) {
+		t.Errorf("Reindex of a pruned chain = %v, want ErrChainPruned", err)
+	}
+	if got := balance(t, chain, recipient); got != 100 {
+		t.Errorf("recipient has %d after the refused reindex, want 100", got)
+	}
+
+	// The headers still chain together under their proof of work
+	var headers []BlockHeader
+	for height := 0; height <= chain.GetBestHeight(); height++ {
+		block, err := chain.blockAtHeight(height)
+		if err != nil {
+			t.Fatalf("block at height %d: %v", height, err)
+		}
+		headers = append(headers, block.Header(chain.Difficulty))
+	}
+	if err := VerifyHeaders(headers, chain.Difficulty); err != nil {
+		t.Errorf("VerifyHeaders after pruning: %v", err)
+	}
+	if !bytes.Equal(headers[0].MerkleRoot, genesisBlock.HashTransactions()) {
+		t.Error("the genesis Merkle root changed")
+	}
+
+	// Pruned data fails cleanly
+	if _, err := chain.FindTransaction(spent.ID); !errors.Is(err, ErrTransactionPruned) {
+		t.Errorf("FindTransaction of a pruned transaction = %v, want ErrTransactionPruned", err)
+	}
+	pruned, err := chain.blockAtHeight(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pruned.MerkleProof(spent.ID); !errors.Is(err, ErrPrunedBlock) {
+		t.Errorf("MerkleProof in a pruned block = %v, want ErrPrunedBlock", err)
+	}
+
+	// Retained data still works, SPV proofs included
+	if _, err := chain.FindTransaction(transfer.ID); err != nil {
+		t.Errorf("FindTransaction of an unspent transfer: %v", err)
+	}
+	kept, err := chain.blockAtHeight(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := kept.MerkleProof(transfer.ID)
+	if err != nil {
+		t.Fatalf("MerkleProof in a retained block: %v", err)
+	}
+	if !VerifySPV(headers[1], transfer.MerkleHash(kept.Version), proof) {
+		t.Error("the SPV proof of a retained transfer does not verify")
+	}
+}
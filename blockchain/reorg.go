@@ -0,0 +1,146 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/golang-blockchain/logger"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 19/12/2025
+ * Time: 09:30
+ */
+
+// CHAIN REORGANIZATION
+// When a side branch holds more total work than the active chain (see work.go),
+// the node switches to it:
+//
+//	        ┌─ A2 ─ A3            (active chain, abandoned)
+//	G ─ B1 ─┤
+//	        └─ C2 ─ C3 ─ C4       (side branch, new active chain)
+//
+// 1. Find the common ancestor (B1)
+// 2. Undo the UTXO effects of the abandoned blocks, tip first (A3, A2)
+// 3. Move the tip pointer and height index to the new branch
+// 4. Apply the new branch's blocks to the UTXO set, oldest first (C2, C3, C4)
+
+// ReorganizeChain makes newTip the tip of the active chain
+// newTip must already be stored and its branch must hold more work than the active chain
+// Returns ErrOutputSpent, keeping the current tip, when a block of the new branch spends
+// an output that branch doesn't have
+func (chain *BlockChain) ReorganizeChain(newTip *Block) error {
+	chain.tipMu.Lock()
+	defer chain.tipMu.Unlock()
+	return chain.reorganize(newTip)
+}
+
+// reorganize is ReorganizeChain for a caller that already holds tipMu
+func (chain *BlockChain) reorganize(newTip *Block) error {
+	oldTip, err := chain.GetBlock(chain.tip())
+	if err != nil {
+		return fmt.Errorf("current tip %x: %w", chain.tip(), err)
+	}
+
+	var oldWork, newWork *big.Int
+	err = chain.Database.Update(func(txn *badger.Txn) error {
+		if oldWork, err = totalWork(txn, oldTip.Hash, chain.Difficulty, true); err != nil {
+			return err
+		}
+		newWork, err = totalWork(txn, newTip.Hash, chain.Difficulty, true)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if newWork.Cmp(oldWork) <= 0 {
+		return fmt.Errorf("branch ending at %x (work %s) does not outweigh the active chain (work %s)",
+			newTip.Hash, newWork, oldWork)
+	}
+
+	// Step 1: Find where the two branches meet
+	ancestor, err := chain.FindCommonAncestor(oldTip.Hash, newTip.Hash)
+	if err != nil {
+		return err
+	}
+
+	// Collect both branches above the ancestor
+	abandoned, err := chain.branchSince(oldTip.Hash, ancestor) // Tip first
+	if err != nil {
+		return err
+	}
+	adopted, err := chain.branchSince(newTip.Hash, ancestor) // Tip first
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Look up what the abandoned blocks spent while they are still the active
+	// chain, so the transactions their inputs spent can still be found
+	utxoSet := UTXOSet{Blockchain: chain}
+	undos := make([]*blockUndo, 0, len(abandoned))
+	for _, block := range abandoned {
+		undo, err := utxoSet.planRevert(block)
+		if err != nil {
+			return err
+		}
+		undos = append(undos, undo)
+	}
+
+	// Steps 2 to 4 in one commit: the new branch's blocks are only checked against the
+	// UTXO set once the abandoned ones are undone, and a block spending an output that
+	// is gone by then (e.g. one only the abandoned branch created) discards it all,
+	// leaving the old tip and its UTXO set as they were
+	err = updateTip(chain.Database, func(txn *badger.Txn) error {
+		for _, undo := range undos {
+			if err := revertBlock(txn, undo); err != nil {
+				return err
+			}
+		}
+
+		// Step 3: Switch the tip and re-point every height that moved to the new branch
+		if err := txn.Set([]byte("lh"), newTip.Hash); err != nil {
+			return err
+		}
+		if err := indexActiveChain(txn, newTip); err != nil {
+			return err
+		}
+
+		// Step 4: Apply the new branch, oldest block first
+		for i := len(adopted) - 1; i >= 0; i-- {
+			if err := applyBlock(txn, adopted[i]); err != nil {
+				return fmt.Errorf("%w: block %x of the branch ending at %x", err, adopted[i].Hash, newTip.Hash)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	chain.setTip(newTip.Hash)
+
+	logger.Info("Reorganized chain", "dropped", len(abandoned), "adopted", len(adopted),
+		"newTip", hex.EncodeToString(newTip.Hash))
+	return nil
+}
+
+// branchSince returns the blocks from tipHash back to (but not including) ancestorHash, tip first
+func (chain *BlockChain) branchSince(tipHash, ancestorHash []byte) ([]*Block, error) {
+	var branch []*Block
+
+	hash := tipHash
+	for !bytes.Equal(hash, ancestorHash) {
+		block, err := chain.GetBlock(hash)
+		if err != nil {
+			return nil, fmt.Errorf("block %x: %w", hash, err)
+		}
+		branch = append(branch, &block)
+		hash = block.PrevHash
+	}
+	return branch, nil
+}
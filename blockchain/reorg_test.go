@@ -0,0 +1,165 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 15:20
+ */
+
+// checkUTXOFollowsChain fails unless the stored UTXO set is what a scan of the active chain finds
+func checkUTXOFollowsChain(t *testing.T, chain *BlockChain) {
+	t.Helper()
+
+	scanned, stored := chain.FindUTXO(), storedUTXO(t, chain)
+	if len(stored) != len(scanned) {
+		t.Errorf("UTXO set holds %d entries, the active chain %d", len(stored), len(scanned))
+	}
+	for txID, outs := range scanned {
+		if !bytes.Equal(stored[txID].Serialize(), outs.Serialize()) {
+			t.Errorf("UTXO entry %s differs from the active chain's", txID)
+		}
+	}
+}
+
+// checkBalances fails unless each wallet holds its amount
+func checkBalances(t *testing.T, chain *BlockChain, want map[*wallet.Wallet]int) {
+	t.Helper()
+
+	for w, amount := range want {
+		if got := balance(t, chain, w); got != amount {
+			t.Errorf("%s has %d, want %d", w.Address(), got, amount)
+		}
+	}
+}
+
+func TestReorganizationFollowsTheLongerBranch(t *testing.T) {
+	alice, bob, carol := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	mainMiner, sideMiner := wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{alice: 100})
+	genesis := chain.tip()
+	reward := BlockReward(1)
+
+	// Alice pays Bob on one branch and Carol, out of the same output, on the other
+	toCarol := newTestTransfer(t, chain, alice, carol, 40, 2)
+	main1 := mineTestBlock(t, chain, mainMiner, newTestTransfer(t, chain, alice, bob, 30, 1))
+
+	side1 := branchBlock(chain, sideMiner, genesis, 1, toCarol)
+	if err := chain.AddBlock(side1); err != nil {
+		t.Fatalf("AddBlock(side 1): %v", err)
+	}
+	side2 := branchBlock(chain, sideMiner, side1.Hash, 2)
+	if err := chain.AddBlock(side2); err != nil {
+		t.Fatalf("AddBlock(side 2): %v", err)
+	}
+	if !bytes.Equal(chain.LastHash, side2.Hash) {
+		t.Fatalf("LastHash %x, want the longer side branch's %x", chain.LastHash, side2.Hash)
+	}
+	checkBalances(t, chain, map[*wallet.Wallet]int{alice: 58, bob: 0, carol: 40, mainMiner: 0, sideMiner: 2*reward + 2})
+	checkUTXOFollowsChain(t, chain)
+
+	// The first branch grows longer again and takes the tip back
+	main2 := branchBlock(chain, mainMiner, main1.Hash, 2)
+	main3 := branchBlock(chain, mainMiner, main2.Hash, 3)
+	for _, block := range []*Block{main2, main3} {
+		if err := chain.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock(main %d): %v", block.Height, err)
+		}
+	}
+	if !bytes.Equal(chain.LastHash, main3.Hash) {
+		t.Fatalf("LastHash %x, want the main branch's %x", chain.LastHash, main3.Hash)
+	}
+	checkBalances(t, chain, map[*wallet.Wallet]int{alice: 69, bob: 30, carol: 0, mainMiner: 3*reward + 1, sideMiner: 0})
+	checkUTXOFollowsChain(t, chain)
+}
+
+func TestReorganizationToABranchSpendingMissingOutputsKeepsTheTip(t *testing.T) {
+	alice, bob, carol, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{alice: 100})
+	genesis := chain.tip()
+
+	mineTestBlock(t, chain, miner, newTestTransfer(t, chain, alice, bob, 30, 1))
+	tip := mineTestBlock(t, chain, miner)
+
+	// Bob's output only exists on the active chain; a branch from genesis spending it is invalid
+	bad := branchBlock(chain, miner, genesis, 1, newTestTransfer(t, chain, bob, carol, 10, 1))
+	for height := 1; height <= 3; height++ {
+		if height > 1 {
+			bad = branchBlock(chain, miner, bad.Hash, height)
+		}
+		err := chain.AddBlock(bad)
+		if height < 3 && err != nil {
+			t.Fatalf("AddBlock(branch %d): %v", height, err)
+		}
+		if height == 3 && !errors.Is(err, ErrOutputSpent) {
+			t.Fatalf("AddBlock overtaking with an invalid branch = %v, want ErrOutputSpent", err)
+		}
+	}
+
+	if !bytes.Equal(chain.LastHash, tip.Hash) {
+		t.Errorf("LastHash %x, want the old tip %x", chain.LastHash, tip.Hash)
+	}
+	if block, err := chain.GetBlockByHeight(2); err != nil || !bytes.Equal(block.Hash, tip.Hash) {
+		t.Errorf("height 2 indexes %x, %v; want the old tip", block.Hash, err)
+	}
+	checkBalances(t, chain, map[*wallet.Wallet]int{alice: 69, bob: 30, carol: 0})
+	checkUTXOFollowsChain(t, chain)
+}
+
+func TestFindCommonAncestor(t *testing.T) {
+	miner := wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{miner: 100})
+	genesis := chain.tip()
+
+	var main []*Block // Heights 1 to 3
+	for i := 0; i < 3; i++ {
+		main = append(main, mineTestBlock(t, chain, miner))
+	}
+
+	// grow stores a side branch of n blocks on top of from, the block at the given height;
+	// none outgrows the active chain, so the tip stays put
+	grow := func(from []byte, height, n int) *Block {
+		var block *Block
+		for i := 1; i <= n; i++ {
+			block = branchBlock(chain, miner, from, height+i)
+			if err := chain.AddBlock(block); err != nil {
+				t.Fatalf("AddBlock(side %d): %v", height+i, err)
+			}
+			from = block.Hash
+		}
+		return block
+	}
+	forkedAt1 := grow(main[0].Hash, 1, 2)
+	fromGenesis := grow(genesis, 0, 2)
+
+	cases := map[string]struct {
+		a, b, want []byte
+	}{
+		"forked at height 1":    {main[2].Hash, forkedAt1.Hash, main[0].Hash},
+		"ancestor of the other": {main[0].Hash, main[2].Hash, main[0].Hash},
+		"the same block":        {main[1].Hash, main[1].Hash, main[1].Hash},
+		"sharing only genesis":  {main[2].Hash, fromGenesis.Hash, genesis},
+		"both side branches":    {forkedAt1.Hash, fromGenesis.Hash, genesis},
+	}
+	for name, c := range cases {
+		for _, order := range [][2][]byte{{c.a, c.b}, {c.b, c.a}} {
+			got, err := chain.FindCommonAncestor(order[0], order[1])
+			if err != nil || !bytes.Equal(got, c.want) {
+				t.Errorf("%s: FindCommonAncestor(%x, %x) = %x, %v; want %x", name, order[0], order[1], got, err, c.want)
+			}
+		}
+	}
+
+	if _, err := chain.FindCommonAncestor(main[2].Hash, []byte("unknown")); err == nil {
+		t.Error("FindCommonAncestor with an unknown block succeeded")
+	}
+}
@@ -0,0 +1,78 @@
+package blockchain
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 20:10
+ */
+
+// SIGNATURE ENCODING
+// An input signature is the ECDSA pair (r, s) on P-256, each left-padded to 32 bytes:
+//
+//	[r, 32 bytes big-endian] + [s, 32 bytes big-endian]
+//
+// For every valid (r, s), (r, N-s) is valid too, so anyone relaying a transaction could
+// flip s and change its serialized bytes. Only the "low" form, s <= N/2, is accepted;
+// Sign produces it. Anything else, including signatures of another length, is rejected
+// before ecdsa.Verify is ever called: by the memory pool and in blocks from version 1 on.
+//
+// Blocks from before versioning (see header.go) were signed with r.Bytes() || s.Bytes(),
+// neither padded nor normalized; their signatures are still read the old way, by
+// decodeLegacySignature, so an existing chain keeps validating.
+const signatureLength = 64
+
+// ErrInvalidSignature is returned for signature bytes breaking the encoding above
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// curveHalfOrder is N/2, the largest s a signature may carry
+var curveHalfOrder = new(big.Int).Rsh(elliptic.P256().Params().N, 1)
+
+// encodeSignature serializes (r, s) in the fixed-length, low-s form
+func encodeSignature(r, s *big.Int) []byte {
+	if s.Cmp(curveHalfOrder) > 0 {
+		s = new(big.Int).Sub(elliptic.P256().Params().N, s)
+	}
+
+	signature := make([]byte, signatureLength)
+	r.FillBytes(signature[:signatureLength/2])
+	s.FillBytes(signature[signatureLength/2:])
+	return signature
+}
+
+// decodeSignature splits signature bytes into (r, s)
+// Returns ErrInvalidSignature unless they are exactly in the form encodeSignature produces
+func decodeSignature(signature []byte) (*big.Int, *big.Int, error) {
+	if len(signature) != signatureLength {
+		return nil, nil, fmt.Errorf("%w: %d bytes, want %d", ErrInvalidSignature, len(signature), signatureLength)
+	}
+
+	r := new(big.Int).SetBytes(signature[:signatureLength/2])
+	s := new(big.Int).SetBytes(signature[signatureLength/2:])
+	if r.Sign() == 0 || s.Sign() == 0 {
+		return nil, nil, fmt.Errorf("%w: zero component", ErrInvalidSignature)
+	}
+	if s.Cmp(curveHalfOrder) > 0 {
+		return nil, nil, fmt.Errorf("%w: s is not in the lower half of the curve order", ErrInvalidSignature)
+	}
+	return r, s, nil
+}
+
+// decodeLegacySignature splits signature bytes the way Verify did before the encoding was
+// fixed: r is the first half, s the rest, whatever their length or the half s lies in
+// Only for blocks from before versioning; ecdsa.Verify still rejects a zero component
+func decodeLegacySignature(signature []byte) (*big.Int, *big.Int, error) {
+	if len(signature) == 0 {
+		return nil, nil, fmt.Errorf("%w: empty", ErrInvalidSignature)
+	}
+	half := len(signature) / 2
+	return new(big.Int).SetBytes(signature[:half]), new(big.Int).SetBytes(signature[half:]), nil
+}
@@ -0,0 +1,143 @@
+package blockchain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 09:50
+ */
+
+// rawSignature lays out r and s like encodeSignature, without normalizing s
+func rawSignature(r, s *big.Int) []byte {
+	signature := make([]byte, signatureLength)
+	r.FillBytes(signature[:signatureLength/2])
+	s.FillBytes(signature[signatureLength/2:])
+	return signature
+}
+
+func TestDecodeSignatureRejectsMalformed(t *testing.T) {
+	r, s := big.NewInt(7), new(big.Int).Sub(curveHalfOrder, big.NewInt(1))
+	valid := encodeSignature(r, s)
+	highS := rawSignature(r, new(big.Int).Add(curveHalfOrder, big.NewInt(1)))
+
+	cases := map[string][]byte{
+		"empty":      nil,
+		"short":      valid[:signatureLength-1],
+		"long":       append(append([]byte{}, valid...), 0),
+		"odd length": valid[:signatureLength/2+1],
+		"zero r":     rawSignature(big.NewInt(0), s),
+		"zero s":     rawSignature(r, big.NewInt(0)),
+		"high s":     highS,
+	}
+	for name, signature := range cases {
+		if _, _, err := decodeSignature(signature); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: decodeSignature = %v, want ErrInvalidSignature", name, err)
+		}
+	}
+
+	gotR, gotS, err := decodeSignature(valid)
+	if err != nil || gotR.Cmp(r) != 0 || gotS.Cmp(s) != 0 {
+		t.Errorf("decodeSignature(valid) = %v, %v, %v", gotR, gotS, err)
+	}
+}
+
+func TestEncodeSignatureNormalizesS(t *testing.T) {
+	high := new(big.Int).Add(curveHalfOrder, big.NewInt(5))
+	signature := encodeSignature(big.NewInt(1), high)
+	if len(signature) != signatureLength {
+		t.Fatalf("length = %d, want %d", len(signature), signatureLength)
+	}
+	if _, s, err := decodeSignature(signature); err != nil || s.Cmp(curveHalfOrder) > 0 {
+		t.Errorf("s = %v, err %v: not normalized", s, err)
+	}
+}
+
+func TestVerifyMalformedSignatures(t *testing.T) {
+	sender, recipient := wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	tx := newTestTransfer(t, chain, sender, recipient, 40, 0)
+	prevTX, err := chain.FindTransaction(tx.Inputs[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevTXs := map[string]Transaction{hex.EncodeToString(prevTX.ID): prevTX}
+	if !tx.Verify(prevTXs) {
+		t.Fatal("the untouched transaction doesn't verify")
+	}
+	signature := tx.Inputs[0].Signature
+
+	// The same signature with s flipped to N-s still satisfies ecdsa.Verify
+	r, s, err := decodeSignature(signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	highS := rawSignature(r, new(big.Int).Sub(elliptic.P256().Params().N, s))
+
+	cases := map[string][]byte{
+		"empty":     nil,
+		"truncated": signature[:signatureLength-1],
+		"padded":    append(append([]byte{}, signature...), 0),
+		"high s":    highS,
+	}
+	for name, malformed := range cases {
+		tx.Inputs[0].Signature = malformed
+		if tx.Verify(prevTXs) {
+			t.Errorf("%s signature verified", name)
+		}
+	}
+
+	// A high s was valid before the encoding was fixed, and stays valid in legacy blocks
+	tx.Inputs[0].Signature = highS
+	if !tx.verify(prevTXs, legacyBlockVersion) {
+		t.Error("high s signature rejected in a legacy block")
+	}
+
+	// So does a short r, which Sign used to store unpadded
+	short := shortSignature(t, tx, prevTXs, sender)
+	tx.Inputs[0].Signature = short
+	if tx.Verify(prevTXs) {
+		t.Errorf("%d byte signature verified", len(short))
+	}
+	if !tx.verify(prevTXs, legacyBlockVersion) {
+		t.Errorf("%d byte signature rejected in a legacy block", len(short))
+	}
+}
+
+// shortSignature signs the first input of tx until r has a leading zero byte, and
+// returns r.Bytes() || s.Bytes() the way Sign did before the encoding was fixed
+// (a short s was split wrongly by the old Verify, so no block can hold one)
+func shortSignature(t *testing.T, tx *Transaction, prevTXs map[string]Transaction, w *wallet.Wallet) []byte {
+	t.Helper()
+
+	txCopy := tx.TrimmedCopy()
+	in := tx.Inputs[0]
+	txCopy.Inputs[0].PubKey = prevTXs[hex.EncodeToString(in.ID)].Outputs[in.Out].PubKeyHash
+	hash := txCopy.Hash()
+
+	shortBound := new(big.Int).Lsh(big.NewInt(1), 248)
+	for i := 0; i < 1<<16; i++ {
+		r, s, err := ecdsa.Sign(rand.Reader, &w.PrivateKey, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Cmp(shortBound) < 0 && s.Cmp(shortBound) >= 0 {
+			return append(r.Bytes(), s.Bytes()...)
+		}
+	}
+	t.Fatal("no signature with a short r")
+	return nil
+}
@@ -0,0 +1,57 @@
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 15:55
+ */
+
+// SIMPLIFIED PAYMENT VERIFICATION
+// A light client holding verified headers (see header.go) can check that a transaction
+// is in a block without the block's other transactions. A full node sends the Merkle
+// proof for it: the sibling hash at every level of the tree, from the transaction's leaf
+// up to the root. Folding the leaf with those siblings must give the header's MerkleRoot,
+// and the header's proof of work makes that root expensive to fake.
+
+// MerkleHash returns the leaf hash of the transaction in the Merkle tree of a block of the given version
+func (tx *Transaction) MerkleHash(version int) []byte {
+	return NewMerkleNode(nil, nil, tx.merkleLeaf(version)).Data
+}
+
+// MerkleProof returns the Merkle proof of the transaction with the given ID in the block
+// A pruned block no longer has every leaf, so no proof can be built from it
+func (b *Block) MerkleProof(txID []byte) ([]MerkleProofStep, error) {
+	if b.IsPruned() {
+		return nil, fmt.Errorf("%w: block %x", ErrPrunedBlock, b.Hash)
+	}
+
+	var leaves [][]byte
+	index := -1
+	for i, tx := range b.Transactions {
+		leaves = append(leaves, tx.merkleLeaf(b.Version))
+		if bytes.Equal(tx.ID, txID) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("%w: %x is not in block %x", ErrTransactionNotFound, txID, b.Hash)
+	}
+	return NewMerkleProof(leaves, index)
+}
+
+// VerifySPV reports whether proof places the transaction with leaf hash txHash
+// (Transaction.MerkleHash of header.Version) in the block described by header, and the header's proof of work holds
+// The header should be one the client accepted through VerifyHeaders, which checks its difficulty
+func VerifySPV(header BlockHeader, txHash []byte, proof []MerkleProofStep) bool {
+	if len(proof) == 0 || header.Validate() != nil {
+		return false
+	}
+	return bytes.Equal(MerkleProofRoot(txHash, proof), header.MerkleRoot)
+}
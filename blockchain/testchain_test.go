@@ -0,0 +1,78 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 09:00
+ */
+
+// TEST CHAINS
+// Helpers shared by the tests of this package. A test chain lives in its own temporary
+// data directory, is sealed by NoOpConsensus and lets coinbases mature at once, so a
+// test can mine and spend in a handful of blocks.
+
+// newTestChain creates a chain whose genesis pays each wallet its allocation
+// The UTXO set is indexed and the database closed when the test ends
+func newTestChain(t testing.TB, allocations map[*wallet.Wallet]int) *BlockChain {
+	t.Helper()
+
+	dataDir, maturity := wallet.DataDir, CoinbaseMaturity
+	wallet.DataDir, CoinbaseMaturity = t.TempDir(), 0
+	t.Cleanup(func() { wallet.DataDir, CoinbaseMaturity = dataDir, maturity })
+
+	addresses := make(map[string]int)
+	for w, amount := range allocations {
+		addresses[string(w.Address())] = amount
+	}
+	chain, err := InitBlockChainWithGenesis(addresses, MinDifficulty, "test")
+	if err != nil {
+		t.Fatalf("create chain: %v", err)
+	}
+	t.Cleanup(func() { chain.Database.Close() })
+
+	chain.Consensus = NoOpConsensus{Difficulty: MinDifficulty}
+	if err := (UTXOSet{Blockchain: chain}).Reindex(); err != nil {
+		t.Fatalf("index the UTXO set: %v", err)
+	}
+	return chain
+}
+
+// mineTestBlock mines txs into a block paying miner the reward and fees, and updates the UTXO set
+func mineTestBlock(t testing.TB, chain *BlockChain, miner *wallet.Wallet, txs ...*Transaction) *Block {
+	t.Helper()
+
+	fees, err := chain.Fees(txs)
+	if err != nil {
+		t.Fatalf("fees: %v", err)
+	}
+	height := chain.GetBestHeight() + 1
+	coinbase := CoinbaseTx(string(miner.Address()), "", height, fees)
+	block, err := chain.MineBlockWithContext(t.Context(), append([]*Transaction{coinbase}, txs...))
+	if err != nil {
+		t.Fatalf("mine block %d: %v", height, err)
+	}
+	utxoSet := UTXOSet{Blockchain: chain}
+	if err := utxoSet.Update(block); err != nil {
+		t.Fatalf("update the UTXO set with block %d: %v", height, err)
+	}
+	return block
+}
+
+// balance returns the confirmed balance of w
+func balance(t *testing.T, chain *BlockChain, w *wallet.Wallet) int {
+	t.Helper()
+
+	amount, err := UTXOSet{Blockchain: chain}.GetBalance(string(w.Address()))
+	if err != nil {
+		t.Fatalf("balance of %s: %v", w.Address(), err)
+	}
+	return amount
+}
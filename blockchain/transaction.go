@@ -8,9 +8,10 @@ import (
 	"crypto/sha256"
 	"encoding/gob"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
-	"math/big"
+	"math"
+	"sort"
 	"strings"
 
 	"github.com/golang-blockchain/wallet"
@@ -62,6 +63,39 @@ import (
         Validity is verified using the sender's public key on signed inputs.
 */
 
+// Errors returned when a transaction can't be built
+var (
+	ErrInsufficientFunds = errors.New("not enough funds")
+	ErrInvalidAddress    = errors.New("invalid address")
+	ErrWalletNotFound    = wallet.ErrWalletNotFound
+)
+
+// Coinbase reward schedule
+// The reward starts at InitialBlockReward and halves every HalvingInterval blocks,
+// so the total supply converges instead of growing forever
+const (
+	InitialBlockReward = 100    // Reward paid for mining a block before the first halving
+	HalvingInterval    = 210000 // Number of blocks between two halvings
+)
+
+// ErrExcessiveCoinbase means a block's coinbase pays more than the scheduled reward plus fees
+var ErrExcessiveCoinbase = errors.New("coinbase pays more than block reward plus fees")
+
+// ErrUnbalancedTransaction means a transaction's inputs don't cover exactly its outputs plus the fee
+var ErrUnbalancedTransaction = errors.New("transaction inputs do not match outputs plus fee")
+
+// ErrInvalidOutputValue is returned for an output that isn't positive, or outputs whose sum overflows
+var ErrInvalidOutputValue = errors.New("invalid output value")
+
+// ErrDuplicateInput is returned for a transaction listing the same output among its inputs twice
+var ErrDuplicateInput = errors.New("transaction spends the same output twice")
+
+// ErrUnknownOutput is returned for an input whose previous transaction is missing or has no such output
+var ErrUnknownOutput = errors.New("input spends an unknown output")
+
+// ErrInvalidTransactionID is returned for a transaction whose ID isn't its Hash
+var ErrInvalidTransactionID = errors.New("transaction ID does not match its hash")
+
 // Transaction represents a single transaction in the blockchain
 // It contains a unique ID and references to inputs and outputs
 type Transaction struct {
@@ -74,35 +108,51 @@ type Transaction struct {
 // This hash serves as the transaction's unique identifier (like a digital fingerprint)
 // CRITICAL: The hash must NOT include the existing ID field (circular dependency)
 // Used for:
-// - Transaction ID generation (tx.ID = tx.Hash())
+// - Transaction ID generation (SetID)
 // - Digital signatures (we sign the hash, not the entire transaction)
 // - Merkle tree construction in blocks
 // - Preventing transaction tampering
 func (tx *Transaction) Hash() []byte {
-	var hash [32]byte // SHA-256 produces 32-byte hashes
-
-	// Create a copy of the transaction to avoid modifying the original
-	// This is important because we need to clear the ID for hashing
-	txCopy := *tx // Shallow copy (but sufficient since we only modify ID)
-
-	// MUST clear the ID field before hashing!
+	// The ID is never part of the canonical encoding (see tx_encoding.go)
 	// Why? Because the ID IS the hash of the transaction
 	// Can't compute hash of something that contains the hash itself
-	txCopy.ID = []byte{} // Empty slice, not nil (nil might serialize differently)
-
-	// Step 1: Serialize the transaction copy (without ID)
-	serialized := txCopy.Serialize()
+	// So Hash gives the same result before and after SetID
 
-	// Step 2: Compute SHA-256 hash of the serialized data
 	// SHA-256 is a cryptographic hash function that produces a fixed 32-byte output
 	// Properties: deterministic, fast to compute, infeasible to reverse, small changes produce completely different hashes
-	hash = sha256.Sum256(serialized)
+	hash := sha256.Sum256(tx.canonicalBytes())
 
 	// Return as a slice (not fixed array) for flexibility
 	// This 32-byte hash becomes the transaction's unique ID
 	return hash[:]
 }
 
+// SetID sets the transaction's ID to its Hash
+// Always use this (not a separate hashing path) so CheckID accepts the transaction
+func (tx *Transaction) SetID() {
+	tx.ID = tx.Hash()
+}
+
+// CheckID makes sure the transaction's ID is its Hash
+// Outputs are stored and spent by ID, so a transaction reusing another's ID would
+// overwrite that transaction's unspent outputs and index entry
+func (tx *Transaction) CheckID() error {
+	if hash := tx.Hash(); !bytes.Equal(tx.ID, hash) {
+		return fmt.Errorf("%w: %x hashes to %x", ErrInvalidTransactionID, tx.ID, hash)
+	}
+	return nil
+}
+
+// legacyHash is how Hash worked before the canonical encoding: SHA-256 of the gob bytes
+// with the ID cleared. Gob bytes depend on what the process encoded before, so it is
+// only used to check signatures made back then, in blocks from before versioning
+func (tx *Transaction) legacyHash() []byte {
+	txCopy := *tx
+	txCopy.ID = []byte{}
+	hash := sha256.Sum256(txCopy.Serialize())
+	return hash[:]
+}
+
 // Serialize converts the entire transaction into a binary byte array
 // This is essential for:
 // - Storing transactions in blocks/persistence
@@ -127,46 +177,155 @@ func (tx Transaction) Serialize() []byte {
 	return encoded.Bytes()
 }
 
+// DeserializeTransaction decodes bytes produced by Transaction.Serialize back into a Transaction
+// It returns a value (not a pointer) so it can be stored directly in the network's memory pool
+// Used when transactions arrive over the network in "tx" messages
 func DeserializeTransaction(data []byte) Transaction {
+	transaction, err := DeserializeTransactionE(data)
+	Handle(err)
+	return transaction
+}
+
+// DeserializeTransactionE decodes a transaction like DeserializeTransaction, but returns
+// an error for malformed data instead of panicking
+func DeserializeTransactionE(data []byte) (Transaction, error) {
 	var transaction Transaction
 
 	decoder := gob.NewDecoder(bytes.NewReader(data))
-	err := decoder.Decode(&transaction)
-	Handle(err)
-	return transaction
+	if err := decoder.Decode(&transaction); err != nil {
+		return Transaction{}, fmt.Errorf("decode transaction: %w", err)
+	}
+	return transaction, nil
+}
+
+// BlockReward returns the coins minted by the coinbase of the block at the given height
+// Example: heights 0..209999 pay 100, 210000..419999 pay 50, and so on down to zero
+func BlockReward(height int) int {
+	if height < 0 {
+		height = 0
+	}
+
+	// Shifting by 64 or more yields zero, so the reward floors at zero on its own
+	halvings := uint(height / HalvingInterval)
+	return InitialBlockReward >> halvings
 }
 
 // CoinbaseTx creates the special "mining reward" transaction
 // This is the first transaction in each block, creating new coins from nothing
-func CoinbaseTx(to, data string) *Transaction {
-	// If no custom data provided, use a default mining message
-	if data == "" {
-		randData := make([]byte, 24)
-		_, err := rand.Read(randData)
-		Handle(err)
-		data = fmt.Sprintf("%x", randData)
-	}
+// height is the height of the block being mined and decides the reward (see BlockReward)
+// fees is the total fee of the block's other transactions (see BlockChain.Fees), claimed on top
+// tag is the miner's tag (see coinbase_tag.go), or "" for none; it must pass ValidateMinerTag
+func CoinbaseTx(to, tag string, height, fees int) *Transaction {
+	// A random nonce keeps the ID unique, even if the same miner earns the same reward again
+	randData := make([]byte, coinbaseNonceSize)
+	_, err := rand.Read(randData)
+	Handle(err)
+	data := coinbaseData(tag, fmt.Sprintf("%x", randData))
 
 	// Coinbase inputs are special - they reference "nothing" (no previous output)
 	// ID: empty (no previous transaction)
 	// Out: -1 (invalid index, indicating no specific output)
-	// Signature: contains arbitrary data (often mining pool name or miner's message)
+	// Signature: none
+	// PubKey: contains arbitrary data (often mining pool name or miner's message)
 	txIN := TxInput{[]byte{}, -1, nil, []byte(data)}
 
 	// Coinbase creates new coins as output
-	// Value: reward amount (100 tokens in this example)
+	// Value: reward scheduled for this height plus the fees (zero once the schedule has
+	// run out and nothing paid a fee, the only case where an output may be worth nothing,
+	// see CheckOutputs)
 	// PubKey: recipient's address who can spend these coins
-	txOUT := NewTXOutput(20, to)
+	txOUT := &TxOutput{Value: BlockReward(height) + fees}
+	txOUT.Lock([]byte(to))
 
 	// Create the transaction with no ID initially
 	tx := Transaction{nil, []TxInput{txIN}, []TxOutput{*txOUT}}
 
 	// Generate the transaction ID (hash of its contents)
-	tx.ID = tx.Hash()
+	tx.SetID()
 
 	return &tx
 }
 
+// GenesisTx creates the coinbase of a genesis block paying each address its allocation
+// Outputs are ordered by address, so the same allocations always give the same genesis.
+// Returns ErrInvalidAddress or ErrInvalidOutputValue for a bad allocation
+func GenesisTx(allocations map[string]int) (*Transaction, error) {
+	if len(allocations) == 0 {
+		return nil, errors.New("genesis needs at least one allocation")
+	}
+
+	var addresses []string
+	for address := range allocations {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses) // Map order is random; outputs shouldn't be
+
+	var outputs []TxOutput
+	for _, address := range addresses {
+		if !wallet.ValidateAddress(address) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+		}
+		out, err := NewTXOutputE(allocations[address], address)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, *out)
+	}
+
+	// Shaped like any coinbase: a single input referencing nothing, carrying genesisData
+	tx := Transaction{nil, []TxInput{{[]byte{}, -1, nil, []byte(genesisData)}}, outputs}
+	if err := tx.CheckOutputs(); err != nil {
+		return nil, err
+	}
+	tx.SetID()
+	return &tx, nil
+}
+
+// CheckOutputs makes sure every output carries a positive value and that their sum fits in an int
+// A coinbase output may also be zero (the reward schedule ends at zero), never negative
+func (tx *Transaction) CheckOutputs() error {
+	minValue := 1
+	if tx.IsCoinbase() {
+		minValue = 0
+	}
+
+	total, data := 0, 0
+	for i, out := range tx.Outputs {
+		// A data output carries no value, only its payload
+		if out.IsData() {
+			if err := out.checkData(); err != nil {
+				return fmt.Errorf("output %d: %w", i, err)
+			}
+			if data++; data > 1 {
+				return fmt.Errorf("%w: more than one per transaction", ErrInvalidDataOutput)
+			}
+			continue
+		}
+		if out.Value < minValue {
+			return fmt.Errorf("%w: output %d is %d", ErrInvalidOutputValue, i, out.Value)
+		}
+		if total > math.MaxInt-out.Value {
+			return fmt.Errorf("%w: outputs overflow", ErrInvalidOutputValue)
+		}
+		total += out.Value
+	}
+	return nil
+}
+
+// CheckInputs makes sure no output is listed twice among the inputs
+// Otherwise its value would count twice towards what the transaction may spend
+func (tx *Transaction) CheckInputs() error {
+	seen := make(map[string]bool, len(tx.Inputs))
+	for _, in := range tx.Inputs {
+		outpoint := in.Outpoint()
+		if seen[outpoint] {
+			return fmt.Errorf("%w: %s", ErrDuplicateInput, outpoint)
+		}
+		seen[outpoint] = true
+	}
+	return nil
+}
+
 // IsCoinbase checks if a transaction is a coinbase (mining reward) transaction
 // Coinbase transactions have special properties that distinguish them from regular transfers
 func (tx *Transaction) IsCoinbase() bool {
@@ -180,19 +339,19 @@ func (tx *Transaction) IsCoinbase() bool {
 // Sign signs all inputs of a transaction using the provided private key
 // This proves the signer owns the outputs being spent
 // Coinbase transactions (mining rewards) are not signed
-func (tx *Transaction) Sign(privateKey ecdsa.PrivateKey, prevTXs map[string]Transaction) {
+// Returns ErrUnknownOutput, signing nothing, when an input spends an output missing from prevTXs
+func (tx *Transaction) Sign(privateKey ecdsa.PrivateKey, prevTXs map[string]Transaction) error {
 	// Coinbase transactions create new coins, they don't spend existing outputs
 	// Therefore, they don't need signatures
 	if tx.IsCoinbase() {
-		return
+		return nil
 	}
 
-	// Validate that all previous transactions referenced by inputs exist
+	// Validate that every output referenced by the inputs exists
 	// This prevents signing transactions that reference non-existent outputs
 	for _, in := range tx.Inputs {
-		prevTxID := hex.EncodeToString(in.ID)
-		if prevTXs[prevTxID].ID == nil {
-			log.Panic("ERROR: Previous transaction not found!")
+		if _, err := in.prevOutput(prevTXs); err != nil {
+			return err
 		}
 	}
 
@@ -229,28 +388,48 @@ func (tx *Transaction) Sign(privateKey ecdsa.PrivateKey, prevTXs map[string]Tran
 		Handle(err)
 
 		// Combine r and s into a single signature (standard practice: r || s)
-		signature := append(r.Bytes(), s.Bytes()...)
-
+		// Both are padded to a fixed length and s is put in its low form (see signature.go)
 		// Store the signature in the ORIGINAL transaction (not the copy)
-		tx.Inputs[inID].Signature = signature
+		tx.Inputs[inID].Signature = encodeSignature(r, s)
 	}
+	return nil
+}
+
+// prevOutput returns the output the input spends, looked up in prevTXs
+// Returns ErrUnknownOutput when the previous transaction is missing or in.Out is out of range
+func (in *TxInput) prevOutput(prevTXs map[string]Transaction) (*TxOutput, error) {
+	prevTX, ok := prevTXs[hex.EncodeToString(in.ID)]
+	if !ok || prevTX.ID == nil {
+		return nil, fmt.Errorf("%w: transaction %x not found", ErrUnknownOutput, in.ID)
+	}
+	if in.Out < 0 || in.Out >= len(prevTX.Outputs) {
+		return nil, fmt.Errorf("%w: %x has no output %d", ErrUnknownOutput, in.ID, in.Out)
+	}
+	return &prevTX.Outputs[in.Out], nil
 }
 
 // Verify checks if all signatures in the transaction are valid
 // Returns true only if ALL inputs have valid signatures
 // Coinbase transactions are always valid (no signatures to verify)
 func (tx *Transaction) Verify(prevTXs map[string]Transaction) bool {
+	return tx.verify(prevTXs, currentBlockVersion)
+}
+
+// verify checks the signatures like Verify, under the rules of a block of the given version
+// In a block from before versioning a signature may also cover the legacy (gob) hash,
+// as every transaction signed before the canonical encoding does
+func (tx *Transaction) verify(prevTXs map[string]Transaction, version int) bool {
 	// Coinbase transactions (mining rewards) are always valid as they create new coins
 	// They don't spend existing outputs, so no signatures to verify
 	if tx.IsCoinbase() {
 		return true
 	}
 
-	// First, verify all referenced previous transactions exist
+	// First, verify all referenced previous outputs exist
 	// We need these to know what outputs are being spent and their locking conditions
 	for _, in := range tx.Inputs {
-		if prevTXs[hex.EncodeToString(in.ID)].ID == nil {
-			log.Panic("Previous transaction not correct")
+		if _, err := in.prevOutput(prevTXs); err != nil {
+			return false
 		}
 	}
 
@@ -278,42 +457,46 @@ func (tx *Transaction) Verify(prevTXs map[string]Transaction) bool {
 		// 3. Compute the hash of the modified transaction copy
 		// This should produce the EXACT same hash that was signed originally
 		txCopy.ID = txCopy.Hash()
+		var legacyID []byte
+		if version == legacyBlockVersion {
+			legacyID = txCopy.legacyHash()
+		}
 
 		// 4. Clear the public key field after hashing
 		// The signature is tied to the transaction hash, not the key itself
 		txCopy.Inputs[inId].PubKey = nil
 
 		// Extract the r and s components from the ECDSA signature
-		// ECDSA signatures consist of two 256-bit integers (r, s)
-		r := big.Int{}
-		s := big.Int{}
-
-		// Get the signature length and split it into r and s components
-		// Signature format: first half = r, second half = s
-		sigLen := len(in.Signature)
-		r.SetBytes(in.Signature[:(sigLen / 2)])
-		s.SetBytes(in.Signature[(sigLen / 2):])
-
-		// Extract the X and Y coordinates from the public key,
-		// Public key format for P-256: X (32 bytes) concatenated with Y (32 bytes)
-		x := big.Int{}
-		y := big.Int{}
-
-		// Get the public key length and split into X and Y coordinates
-		keyLen := len(in.PubKey)
-		x.SetBytes(in.PubKey[:(keyLen / 2)])
-		y.SetBytes(in.PubKey[(keyLen / 2):])
+		// ECDSA signatures consist of two 256-bit integers (r, s): first half = r, second half = s
+		// Any other length, or a high s, is rejected outright (see signature.go),
+		// except in blocks from before versioning, which hold the old unpadded form
+		decode := decodeSignature
+		if version == legacyBlockVersion {
+			decode = decodeLegacySignature
+		}
+		r, s, err := decode(in.Signature)
+		if err != nil {
+			return false
+		}
+
+		// Extract the X and Y coordinates from the public key
+		// Public key format for P-256: compressed (prefix + X) or X concatenated with Y
+		x, y, err := wallet.ParsePubKey(in.PubKey)
+		if err != nil {
+			return false // Not a point on the curve
+		}
 
 		// Reconstruct the ECDSA public key object from the extracted coordinates
 		rawPubKey := ecdsa.PublicKey{
 			Curve: curve, // P-256 elliptic curve
-			X:     &x,    // X coordinate on the curve
-			Y:     &y,    // Y coordinate on the curve
+			X:     x,     // X coordinate on the curve
+			Y:     y,     // Y coordinate on the curve
 		}
 
 		// Verify the digital signature using the public key
 		// This checks: "Was this transaction hash signed by the private key corresponding to this public key?"
-		if ecdsa.Verify(&rawPubKey, txCopy.ID, &r, &s) == false {
+		if ecdsa.Verify(&rawPubKey, txCopy.ID, r, s) == false &&
+			(legacyID == nil || ecdsa.Verify(&rawPubKey, legacyID, r, s) == false) {
 			return false // Signature verification failed for this input
 		}
 	}
@@ -325,70 +508,242 @@ func (tx *Transaction) Verify(prevTXs map[string]Transaction) bool {
 // NewTransaction creates a new transaction transferring tokens from one address to another
 // This is the main transaction constructor that builds valid, spendable transactions
 // by selecting inputs, creating outputs, and calculating change.
-func NewTransaction(w *wallet.Wallet, to string, amount int, UTXO *UTXOSet) *Transaction {
-	// Step 1: Initialize empty input and output collections
-	var inputs []TxInput   // Will reference outputs being spent
-	var outputs []TxOutput // Will define where funds go
+// fee is left unclaimed by the outputs, for the miner of the block to collect.
+// Returns ErrWalletNotFound, ErrInvalidAddress or ErrInsufficientFunds instead of panicking,
+// so callers like the CLI can report the problem and carry on
+func NewTransaction(w *wallet.Wallet, to string, amount, fee int, UTXO *UTXOSet) (*Transaction, error) {
+	return NewTransactionWithData(w, to, amount, fee, nil, UTXO)
+}
 
-	// Get the sender's public key hash (this identifies which outputs they own)
+// NewTransactionWithData creates a transaction like NewTransaction; a non-empty data is
+// embedded in an unspendable data output (see data_output.go) after the payment
+// Returns ErrInvalidDataOutput when data is longer than MaxDataOutputSize
+func NewTransactionWithData(w *wallet.Wallet, to string, amount, fee int, data []byte, UTXO *UTXOSet) (*Transaction, error) {
+	// Step 1: Make sure we have a sender to sign with and a valid recipient
+	if w == nil {
+		return nil, ErrWalletNotFound
+	}
+	if !wallet.ValidateAddress(to) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, to)
+	}
+
+	// Step 2: Select the sender's outputs and build the inputs spending them, the payment,
+	// the data output and the change (see fundPayment)
+	// The sender's public key hash identifies which outputs they own
 	pubKeyHash := wallet.PublicKeyHash(w.PublicKey)
+	inputs, outputs, err := fundPayment(pubKeyHash, w.PublicKey, string(w.Address()), to, amount, fee, data, UTXO)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 3: Construct the transaction
+	tx := Transaction{
+		ID:      nil,     // Will be set to hash
+		Inputs:  inputs,  // Inputs spending UTXOs
+		Outputs: outputs, // New outputs being created
+	}
+
+	// Step 4: Sign the transaction with the sender's private key
+	// This creates digital signatures proving ownership of inputs
+	// Each signature covers a trimmed copy of the transaction (see Sign), never the ID
+	if err := UTXO.Blockchain.SignTransaction(&tx, w.PrivateKey); err != nil {
+		return nil, err
+	}
+
+	// Step 5: Generate transaction ID (hash)
+	// Must be done AFTER signing because the hash covers the signatures (see CheckID)
+	tx.SetID()
+
+	// Step 6: Return the completed, signed transaction
+	return &tx, nil
+}
+
+// fundPayment selects outputs locked to pubKeyHash covering amount plus fee, and builds
+// the unsigned inputs spending them with pubKey (nil if the signer fills it in) and the
+// outputs: the payment to to, the data output if data isn't empty, and the change to from
+// Returns ErrInsufficientFunds when the outputs can't cover amount plus fee
+func fundPayment(pubKeyHash, pubKey []byte, from, to string, amount, fee int, data []byte, UTXO *UTXOSet) ([]TxInput, []TxOutput, error) {
+	if fee < 0 {
+		return nil, nil, fmt.Errorf("fee must not be negative, got %d", fee)
+	}
 
-	// Find enough unspent outputs owned by the sender to cover the requested amount
+	// Find enough unspent outputs owned by the sender to cover the amount and the fee
 	// Returns: total value found, and which specific outputs to spend
-	acc, validOutputs := UTXO.FindSpendableOutputs(pubKeyHash, amount)
+	// Best-fit keeps the input count and the leftover change small
+	acc, validOutputs, err := UTXO.FindSpendableOutputs(pubKeyHash, amount+fee, BestFit)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	// Step 3: Validate sufficient funds before proceeding
-	if acc < amount {
-		log.Panic("Error: Not enough funds!")
+	// Validate sufficient funds before proceeding
+	if acc < amount+fee {
+		return nil, nil, fmt.Errorf("%w: have %d, need %d (amount %d + fee %d)", ErrInsufficientFunds, acc, amount+fee, amount, fee)
 	}
 
-	// Step 4: Convert selected outputs into transaction inputs
+	// Convert selected outputs into transaction inputs
 	// Each UTXO being spent becomes an input in the new transaction
+	inputs, err := spendInputs(validOutputs, pubKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Create the payment output to the recipient
+	payment, err := NewTXOutputE(amount, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	outputs := []TxOutput{*payment}
+
+	// Attach the data output, if there is a payload
+	if len(data) > 0 {
+		dataOutput, err := NewDataOutput(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		outputs = append(outputs, *dataOutput)
+	}
+
+	// Create change output back to sender (if needed)
+	// Everything that isn't sent or left as the fee comes back as change
+	if acc > amount+fee {
+		changeOutput, err := NewTXOutputE(acc-amount-fee, from)
+		if err != nil {
+			return nil, nil, err
+		}
+		outputs = append(outputs, *changeOutput)
+	}
+
+	// Make sure the inputs pay for exactly the outputs plus the fee
+	// A coin-selection or change-calculation bug must never reach the signer
+	if err := checkBalance(UTXO, inputs, outputs, fee); err != nil {
+		return nil, nil, err
+	}
+	return inputs, outputs, nil
+}
+
+// NewMultiTransaction creates a transaction paying several recipients atomically
+// recipients maps each destination address to the amount it receives
+// Inputs are gathered to cover the total, one output is created per recipient
+// (sorted by address so the result is deterministic), plus a single change output
+// Returns ErrInvalidOutputValue for a non-positive amount or amounts whose total overflows
+func NewMultiTransaction(w *wallet.Wallet, recipients map[string]int, UTXO *UTXOSet) (*Transaction, error) {
+	if w == nil {
+		return nil, ErrWalletNotFound
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients given")
+	}
+
+	var addresses []string
+	for address := range recipients {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses) // Map order is random; outputs shouldn't be
+
+	// Step 1: One output per valid recipient; their total must fit in an int, like any outputs
+	var outputs []TxOutput
+	for _, address := range addresses {
+		if !wallet.ValidateAddress(address) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+		}
+		payment, err := NewTXOutputE(recipients[address], address)
+		if err != nil {
+			return nil, fmt.Errorf("amount for %s: %w", address, err)
+		}
+		outputs = append(outputs, *payment)
+	}
+	if err := (&Transaction{Outputs: outputs}).CheckOutputs(); err != nil {
+		return nil, err
+	}
+	total := 0
+	for _, out := range outputs {
+		total += out.Value
+	}
+
+	// Step 2: Select enough of the sender's outputs to cover the total
+	pubKeyHash := wallet.PublicKeyHash(w.PublicKey)
+	acc, validOutputs, err := UTXO.FindSpendableOutputs(pubKeyHash, total, BestFit)
+	if err != nil {
+		return nil, err
+	}
+	if acc < total {
+		return nil, fmt.Errorf("%w: have %d, need %d", ErrInsufficientFunds, acc, total)
+	}
+
+	inputs, err := spendInputs(validOutputs, w.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 3: The change goes back to the sender
+	if acc > total {
+		changeOutput, err := NewTXOutputE(acc-total, string(w.Address()))
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, *changeOutput)
+	}
+
+	// No fee: the inputs pay for exactly the outputs
+	if err := checkBalance(UTXO, inputs, outputs, 0); err != nil {
+		return nil, err
+	}
+
+	// Step 4: Sign and identify, exactly like NewTransaction
+	tx := Transaction{ID: nil, Inputs: inputs, Outputs: outputs}
+	if err := UTXO.Blockchain.SignTransaction(&tx, w.PrivateKey); err != nil {
+		return nil, err
+	}
+	tx.SetID()
+
+	return &tx, nil
+}
+
+// spendInputs turns selected outputs (TransactionID -> []OutputIndices) into unsigned inputs
+// spending them with the given public key
+func spendInputs(validOutputs map[string][]int, pubKey []byte) ([]TxInput, error) {
+	var inputs []TxInput
+
 	for id, outs := range validOutputs {
 		// Convert hex transaction ID string back to bytes
 		txID, err := hex.DecodeString(id)
-		Handle(err)
+		if err != nil {
+			return nil, err
+		}
 
 		// Create an input for each selected output
 		for _, out := range outs {
 			input := TxInput{
-				ID:        txID,        // Previous transaction ID
-				Out:       out,         // Which output in that transaction
-				Signature: nil,         // Will be set after signing
-				PubKey:    w.PublicKey, // Sender's public key (for verification)
+				ID:        txID,   // Previous transaction ID
+				Out:       out,    // Which output in that transaction
+				Signature: nil,    // Will be set after signing
+				PubKey:    pubKey, // Sender's public key (for verification)
 			}
 			inputs = append(inputs, input)
 		}
 	}
+	return inputs, nil
+}
 
-	from := fmt.Sprintf("%s", w.Address())
-
-	// Step 5: Create the payment output to the recipient
-	outputs = append(outputs, *NewTXOutput(amount, to))
-
-	// Step 6: Create change output back to sender (if needed)
-	if acc > amount {
-		change := acc - amount
-		outputs = append(outputs, *NewTXOutput(change, from))
+// checkBalance asserts that the outputs inputs spend, looked up in the UTXO set, cover
+// exactly outputs plus the fee
+// Anything else means value would be created or silently burned. The values are read
+// back rather than taken from coin selection, so a wrong total there is caught too
+func checkBalance(UTXO *UTXOSet, inputs []TxInput, outputs []TxOutput, fee int) error {
+	inputTotal, err := UTXO.InputTotal(inputs)
+	if err != nil {
+		return err
 	}
 
-	// Step 7: Construct the transaction
-	tx := Transaction{
-		ID:      nil,     // Will be set to hash
-		Inputs:  inputs,  // Inputs spending UTXOs
-		Outputs: outputs, // New outputs being created
+	outputTotal := 0
+	for _, out := range outputs {
+		outputTotal += out.Value
 	}
 
-	// Step 8: Generate transaction ID (hash)
-	// Must be done BEFORE signing because signatures sign the hash
-	tx.ID = tx.Hash()
-
-	// Step 9: Sign the transaction with the sender's private key
-	// This creates digital signatures proving ownership of inputs
-	UTXO.Blockchain.SignTransaction(&tx, w.PrivateKey)
-
-	// Step 10: Return the completed, signed transaction
-	return &tx
+	if inputTotal != outputTotal+fee {
+		return fmt.Errorf("%w: inputs %d, outputs %d, fee %d", ErrUnbalancedTransaction, inputTotal, outputTotal, fee)
+	}
+	return nil
 }
 
 // TrimmedCopy creates a modified copy of the transaction for signing/verification
@@ -447,8 +802,11 @@ func (tx Transaction) String() string {
 
 	for i, output := range tx.Outputs {
 		lines = append(lines, fmt.Sprintf("			Output %d:", i))
-		lines = append(lines, fmt.Sprintf("			Value: %d", output.Value))
+		lines = append(lines, fmt.Sprintf("			Value: %s", Params.FormatAmount(output.Value)))
 		lines = append(lines, fmt.Sprintf("			Script: %x", output.PubKeyHash)) // Script is used to derive the address
+		if output.IsData() {
+			lines = append(lines, fmt.Sprintf("			Data: %q", output.Payload()))
+		}
 	}
 
 	return strings.Join(lines, "\n")
@@ -0,0 +1,282 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 09:40
+ */
+
+// newTestTransfer builds a signed transfer of amount from sender to recipient on chain
+func newTestTransfer(t testing.TB, chain *BlockChain, sender, recipient *wallet.Wallet, amount, fee int) *Transaction {
+	t.Helper()
+
+	utxoSet := UTXOSet{Blockchain: chain}
+	tx, err := NewTransaction(sender, string(recipient.Address()), amount, fee, &utxoSet)
+	if err != nil {
+		t.Fatalf("build transaction: %v", err)
+	}
+	return tx
+}
+
+func TestSetIDMatchesHash(t *testing.T) {
+	sender, recipient := wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	coinbase := CoinbaseTx(string(recipient.Address()), "", 1, 0)
+	transfer := newTestTransfer(t, chain, sender, recipient, 40, 0)
+	for _, tx := range []*Transaction{coinbase, transfer} {
+		id := tx.ID
+		tx.SetID()
+		if !bytes.Equal(id, tx.ID) || !bytes.Equal(tx.ID, tx.Hash()) {
+			t.Errorf("ID %x, SetID %x, Hash %x differ", id, tx.ID, tx.Hash())
+		}
+		if err := tx.CheckID(); err != nil {
+			t.Errorf("CheckID: %v", err)
+		}
+	}
+}
+
+func TestTransactionWithForeignIDIsRejected(t *testing.T) {
+	sender, recipient := wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	genesis, err := chain.GetBlockByHeight(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := newTestTransfer(t, chain, sender, recipient, 40, 0)
+	tx.ID = genesis.Transactions[0].ID // Claims the genesis coinbase's ID
+
+	if err := tx.CheckID(); !errors.Is(err, ErrInvalidTransactionID) {
+		t.Errorf("CheckID = %v, want ErrInvalidTransactionID", err)
+	}
+	if chain.VerifyTransaction(tx) {
+		t.Error("VerifyTransaction accepted a transaction with another's ID")
+	}
+	if err := chain.checkTransaction(tx, currentBlockVersion); !errors.Is(err, ErrInvalidBlockTransaction) {
+		t.Errorf("checkTransaction = %v, want ErrInvalidBlockTransaction", err)
+	}
+}
+
+func TestLegacySignatureHashOnlyInLegacyBlocks(t *testing.T) {
+	sender, recipient := wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	tx := newTestTransfer(t, chain, sender, recipient, 40, 0)
+	prevTX, err := chain.FindTransaction(tx.Inputs[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevTXs := map[string]Transaction{hex.EncodeToString(prevTX.ID): prevTX}
+
+	// Re-sign every input the way Sign did before the canonical encoding
+	txCopy := tx.TrimmedCopy()
+	for i, in := range tx.Inputs {
+		txCopy.Inputs[i].PubKey = prevTX.Outputs[in.Out].PubKeyHash
+		r, s, err := ecdsa.Sign(rand.Reader, &sender.PrivateKey, txCopy.legacyHash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		txCopy.Inputs[i].PubKey = nil
+		tx.Inputs[i].Signature = encodeSignature(r, s)
+	}
+
+	if !tx.verify(prevTXs, legacyBlockVersion) {
+		t.Error("a legacy signature is rejected in a legacy block")
+	}
+	if tx.Verify(prevTXs) {
+		t.Error("a legacy signature is accepted under the current rules")
+	}
+}
+
+func TestInputsCoverAmountFeeAndChange(t *testing.T) {
+	sender, recipient, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	const amount, fee = 30, 5
+	tx := newTestTransfer(t, chain, sender, recipient, amount, fee)
+
+	inputs := 0
+	for _, in := range tx.Inputs {
+		prevTX, err := chain.FindTransaction(in.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs += prevTX.Outputs[in.Out].Value
+	}
+	paid, change := 0, 0
+	for _, out := range tx.Outputs {
+		if out.IsLockedWithKey(wallet.PublicKeyHash(sender.PublicKey)) {
+			change += out.Value
+		} else {
+			paid += out.Value
+		}
+	}
+	if paid != amount || inputs != amount+fee+change {
+		t.Errorf("inputs %d, paid %d, change %d: want inputs = %d + %d + change", inputs, paid, change, amount, fee)
+	}
+	if got, err := chain.Fee(tx); err != nil || got != fee {
+		t.Errorf("Fee = %d, %v; want %d", got, err, fee)
+	}
+
+	// The miner claims the fee on top of the reward
+	block := mineTestBlock(t, chain, miner, tx)
+	if got, want := balance(t, chain, miner), BlockReward(block.Height)+fee; got != want {
+		t.Errorf("miner balance = %d, want %d", got, want)
+	}
+	if got := balance(t, chain, sender); got != change {
+		t.Errorf("sender balance = %d, want the change %d", got, change)
+	}
+}
+
+func TestMiscomputedChangeIsCaughtBeforeSigning(t *testing.T) {
+	sender, recipient := wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+	utxoSet := UTXOSet{Blockchain: chain}
+
+	const amount, fee = 30, 5
+	acc, selected, err := utxoSet.FindSpendableOutputs(wallet.PublicKeyHash(sender.PublicKey), amount+fee, BestFit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs, err := spendInputs(selected, sender.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment, _ := NewTXOutputE(amount, string(recipient.Address()))
+
+	for _, total := range []int{acc + 1, acc - 1} { // As if coin selection had added up wrong
+		change, _ := NewTXOutputE(total-amount-fee, string(sender.Address()))
+		outputs := []TxOutput{*payment, *change}
+		if err := checkBalance(&utxoSet, inputs, outputs, fee); !errors.Is(err, ErrUnbalancedTransaction) {
+			t.Errorf("change from a total of %d (inputs hold %d): checkBalance = %v, want ErrUnbalancedTransaction", total, acc, err)
+		}
+	}
+
+	change, _ := NewTXOutputE(acc-amount-fee, string(sender.Address()))
+	if err := checkBalance(&utxoSet, inputs, []TxOutput{*payment, *change}, fee); err != nil {
+		t.Errorf("checkBalance of the right change = %v", err)
+	}
+}
+
+func TestTransactionSerializationRoundTrip(t *testing.T) {
+	sender, recipient := wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	utxoSet := UTXOSet{Blockchain: chain}
+	withData, err := NewTransactionWithData(sender, string(recipient.Address()), 10, 1, []byte("memo"), &utxoSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txs := []*Transaction{CoinbaseTx(string(recipient.Address()), "tag", 1, 0), withData}
+
+	for _, tx := range txs {
+		decoded := DeserializeTransaction(tx.Serialize())
+		if !bytes.Equal(decoded.Serialize(), tx.Serialize()) {
+			t.Errorf("transaction %x changed across a round trip:\n%s\n%s", tx.ID, tx, &decoded)
+		}
+		if err := decoded.CheckID(); err != nil {
+			t.Errorf("decoded transaction: %v", err)
+		}
+		if !decoded.IsCoinbase() && !chain.VerifyTransaction(&decoded) {
+			t.Errorf("transaction %x no longer verifies after a round trip", tx.ID)
+		}
+	}
+
+	if _, err := DeserializeTransactionE([]byte("not a transaction")); err == nil {
+		t.Error("DeserializeTransactionE accepted garbage")
+	}
+}
+
+func TestOutOfRangeOutputIndexIsRejected(t *testing.T) {
+	sender, recipient := wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+	valid := newTestTransfer(t, chain, sender, recipient, 30, 1)
+
+	prevTX, err := chain.FindTransaction(valid.Inputs[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevTXs := map[string]Transaction{hex.EncodeToString(prevTX.ID): prevTX}
+
+	for _, out := range []int{-1, len(prevTX.Outputs), len(prevTX.Outputs) + 100} {
+		tx := *valid
+		tx.Inputs = append([]TxInput{}, valid.Inputs...)
+		tx.Inputs[0].Out = out
+		tx.SetID()
+
+		if err := tx.Sign(sender.PrivateKey, prevTXs); !errors.Is(err, ErrUnknownOutput) {
+			t.Errorf("output %d: Sign = %v, want ErrUnknownOutput", out, err)
+		}
+		if err := chain.SignTransaction(&tx, sender.PrivateKey); !errors.Is(err, ErrUnknownOutput) {
+			t.Errorf("output %d: SignTransaction = %v, want ErrUnknownOutput", out, err)
+		}
+		if tx.Verify(prevTXs) {
+			t.Errorf("output %d: Verify accepted the input", out)
+		}
+		if chain.VerifyTransaction(&tx) {
+			t.Errorf("output %d: VerifyTransaction accepted the input", out)
+		}
+	}
+}
+
+func TestMultiTransactionPaysEveryRecipientAndTheChange(t *testing.T) {
+	sender, alice, bob, carol, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+	utxoSet := UTXOSet{Blockchain: chain}
+	address := func(w *wallet.Wallet) string { return string(w.Address()) }
+
+	tx, err := NewMultiTransaction(sender, map[string]int{address(alice): 10, address(bob): 20, address(carol): 30}, &utxoSet)
+	if err != nil {
+		t.Fatalf("NewMultiTransaction: %v", err)
+	}
+	if len(tx.Outputs) != 4 {
+		t.Errorf("%d outputs, want one per recipient and the change", len(tx.Outputs))
+	}
+	mineTestBlock(t, chain, miner, tx)
+	checkBalances(t, chain, map[*wallet.Wallet]int{sender: 40, alice: 10, bob: 20, carol: 30})
+
+	// Spending everything leaves no change output
+	tx, err = NewMultiTransaction(sender, map[string]int{address(alice): 15, address(bob): 25}, &utxoSet)
+	if err != nil {
+		t.Fatalf("NewMultiTransaction of the whole balance: %v", err)
+	}
+	if len(tx.Outputs) != 2 {
+		t.Errorf("%d outputs, want just the two payments", len(tx.Outputs))
+	}
+
+	cases := map[string]struct {
+		w          *wallet.Wallet
+		recipients map[string]int
+		want       error
+	}{
+		"insufficient funds": {sender, map[string]int{address(alice): 30, address(bob): 11}, ErrInsufficientFunds},
+		"invalid address":    {sender, map[string]int{address(alice): 1, "not an address": 1}, ErrInvalidAddress},
+		"zero amount":        {sender, map[string]int{address(alice): 0}, ErrInvalidOutputValue},
+		"negative amount":    {sender, map[string]int{address(alice): 5, address(bob): -5}, ErrInvalidOutputValue},
+		"overflowing total":  {sender, map[string]int{address(alice): math.MaxInt, address(bob): 1}, ErrInvalidOutputValue},
+		"no wallet":          {nil, map[string]int{address(alice): 1}, ErrWalletNotFound},
+	}
+	for name, c := range cases {
+		if _, err := NewMultiTransaction(c.w, c.recipients, &utxoSet); !errors.Is(err, c.want) {
+			t.Errorf("%s: NewMultiTransaction = %v, want %v", name, err, c.want)
+		}
+	}
+	if _, err := NewMultiTransaction(sender, nil, &utxoSet); err == nil {
+		t.Error("NewMultiTransaction without recipients succeeded")
+	}
+}
@@ -3,6 +3,8 @@ package blockchain
 import (
 	"bytes"
 	"encoding/gob"
+	"fmt"
+	"log"
 
 	"github.com/golang-blockchain/wallet"
 )
@@ -37,8 +39,53 @@ type TxInput struct {
 	PubKey    []byte // Full public key of the spender (not hashed, used for verification)
 }
 
+// TxOutputs is the UTXO set entry of one transaction: its outputs that are still unspent
+// Indices[i] is the position of Outputs[i] in the transaction, which inputs refer to;
+// entries written before Indices existed lack it (see Index)
+// Height and Coinbase describe the transaction, for coinbase maturity (see maturity.go);
+// entries written before they existed read as ordinary outputs until reindexutxo
 type TxOutputs struct {
-	Outputs []TxOutput
+	Outputs  []TxOutput
+	Indices  []int
+	Height   int  // Height of the block containing the transaction
+	Coinbase bool // Whether the transaction is a coinbase
+}
+
+// Index returns the transaction output index of Outputs[i]
+// Entries without Indices are treated as never partially spent (reindexutxo rewrites them)
+func (outs TxOutputs) Index(i int) int {
+	if len(outs.Indices) == len(outs.Outputs) {
+		return outs.Indices[i]
+	}
+	return i
+}
+
+// Find returns the unspent output at transaction output index outIdx, if it is still there
+func (outs TxOutputs) Find(outIdx int) (TxOutput, bool) {
+	for i, out := range outs.Outputs {
+		if outs.Index(i) == outIdx {
+			return out, true
+		}
+	}
+	return TxOutput{}, false
+}
+
+// Add records an unspent output, keeping the entry ordered by output index
+func (outs *TxOutputs) Add(outIdx int, out TxOutput) {
+	// Fill in the indices of a legacy entry before mixing in explicit ones
+	if len(outs.Indices) != len(outs.Outputs) {
+		outs.Indices = make([]int, len(outs.Outputs))
+		for i := range outs.Outputs {
+			outs.Indices[i] = i
+		}
+	}
+
+	pos := len(outs.Outputs)
+	for pos > 0 && outs.Indices[pos-1] > outIdx {
+		pos--
+	}
+	outs.Outputs = append(outs.Outputs[:pos], append([]TxOutput{out}, outs.Outputs[pos:]...)...)
+	outs.Indices = append(outs.Indices[:pos], append([]int{outIdx}, outs.Indices[pos:]...)...)
 }
 
 // NewTXOutput creates a new transaction output locked to an address
@@ -48,14 +95,35 @@ type TxOutputs struct {
 // - Change back to sender (remaining value after payment)
 // - Any output creation in a transaction
 func NewTXOutput(value int, address string) *TxOutput {
+	txo, err := NewTXOutputE(value, address)
+	if err != nil {
+		log.Panic(err)
+	}
+	return txo
+}
+
+// NewTXOutputE is NewTXOutput returning an error for an invalid address or a
+// non-positive value (ErrInvalidOutputValue) instead of panicking
+func NewTXOutputE(value int, address string) (*TxOutput, error) {
+	if value <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidOutputValue, value)
+	}
+
 	// Create output with nil PubKeyHash initially
-	txo := &TxOutput{value, nil}
+	txo := &TxOutput{Value: value}
 
 	// Lock it to the specified address
 	// The Address can be recipient's OR sender's (for change)
-	txo.Lock([]byte(address))
+	if err := txo.LockE([]byte(address)); err != nil {
+		return nil, err
+	}
 
-	return txo
+	return txo, nil
+}
+
+// Outpoint identifies the output this input spends as "txid:index" (txid in hex)
+func (in *TxInput) Outpoint() string {
+	return fmt.Sprintf("%x:%d", in.ID, in.Out)
 }
 
 // UsesKey checks if this input was created/signed with a specific public key hash
@@ -74,12 +142,32 @@ func (in *TxInput) UsesKey(pubKeyHash []byte) bool {
 
 // Lock "locks" an output to a specific Base58-encoded address
 // This means only the owner of that address can spend this output later
-// IMPORTANT: The address must decode to exactly 25 bytes (version + hash + checksum)
+// IMPORTANT: The address must decode to exactly 25 bytes (version + hash + checksum);
+// Lock panics otherwise, LockE returns the error
 func (out *TxOutput) Lock(address []byte) {
+	if err := out.LockE(address); err != nil {
+		log.Panic(err)
+	}
+}
+
+// LockE locks the output to address, or returns ErrInvalidAddress if the address is
+// malformed or belongs to another network
+func (out *TxOutput) LockE(address []byte) error {
 	// Decode the Base58 address to get the full 25-byte encoded data
 	// Structure: [1 byte version] + [20 byte pubKeyHash] + [4 byte checksum]
 	// Example: "1A1zP1e..." → 25 bytes of binary data
-	pubKeyHash := wallet.Base58Decode(address)
+	pubKeyHash, err := wallet.Base58DecodeSafe(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidAddress, address, err)
+	}
+	if len(pubKeyHash) != 25 {
+		return fmt.Errorf("%w: %s: wrong length", ErrInvalidAddress, address)
+	}
+
+	// Never lock coins to another network's address; they could only be spent over there
+	if pubKeyHash[0] != wallet.NetworkVersion {
+		return fmt.Errorf("%w: %s does not belong to this network", ErrInvalidAddress, address)
+	}
 
 	// Extract just the 20-byte public key hash (remove version and checksum)
 	// Version identifies network (e.g., 0x00 for Bitcoin mainnet)
@@ -90,6 +178,7 @@ func (out *TxOutput) Lock(address []byte) {
 	// Store the hash in the output - this output is now "locked" to that address
 	// Only someone with the matching private key can create a valid signature to spend this
 	out.PubKeyHash = pubKeyHash
+	return nil
 }
 
 // IsLockedWithKey checks if this output is locked to a specific public key hash
@@ -98,6 +187,11 @@ func (out *TxOutput) Lock(address []byte) {
 // - Verifying if a signature in an input matches this output's lock
 // - Finding which outputs belong to a wallet (by checking against wallet's pubKeyHash)
 func (out *TxOutput) IsLockedWithKey(pubKeyHash []byte) bool {
+	// Data outputs belong to nobody
+	if out.IsData() {
+		return false
+	}
+
 	// Direct comparison: does the provided hash match this output's lock?
 	// If true: The owner of pubKeyHash can spend this output
 	// This works for both payment outputs AND change outputs
@@ -114,9 +208,18 @@ func (outs TxOutputs) Serialize() []byte {
 }
 
 func DeserializeOutputs(data []byte) TxOutputs {
-	var outputs TxOutputs
-	decode := gob.NewDecoder(bytes.NewReader(data))
-	err := decode.Decode(&outputs)
+	outputs, err := DeserializeOutputsE(data)
 	Handle(err)
 	return outputs
 }
+
+// DeserializeOutputsE decodes outputs like DeserializeOutputs, but returns an error for
+// malformed data instead of panicking
+func DeserializeOutputsE(data []byte) (TxOutputs, error) {
+	var outputs TxOutputs
+	decode := gob.NewDecoder(bytes.NewReader(data))
+	if err := decode.Decode(&outputs); err != nil {
+		return TxOutputs{}, fmt.Errorf("decode outputs: %w", err)
+	}
+	return outputs, nil
+}
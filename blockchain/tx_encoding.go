@@ -0,0 +1,82 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 10:05
+ */
+
+// CANONICAL TRANSACTION ENCODING
+// Transaction IDs and signature hashes are taken over this fixed byte layout, not over
+// gob. Gob output depends on the encoder (type info, field numbering, omitted zero
+// values), so it isn't a stable basis for an ID across Go or code versions. Every
+// variable-length field is prefixed with its length, so no two transactions share an
+// encoding. All integers are big endian; the ID itself is never included:
+//
+//	uint32 input count
+//	  per input:  uint32 len | ID | int64 Out | uint32 len | Signature | uint32 len | PubKey
+//	uint32 output count
+//	  per output: int64 Value | uint32 len | PubKeyHash
+//
+// A data output keeps its payload in PubKeyHash (see data_output.go), so it needs no
+// section of its own.
+// Serialize (gob) is still used to store and send transactions; IDs, signature hashes and
+// the Merkle leaves of versioned blocks (see header.go) use this.
+
+// merkleLeaf returns the bytes the transaction contributes to the Merkle tree of a block
+// of the given version: gob for blocks from before versioning, the canonical encoding since
+func (tx *Transaction) merkleLeaf(version int) []byte {
+	if version == legacyBlockVersion {
+		return tx.Serialize()
+	}
+	return tx.canonicalBytes()
+}
+
+// canonicalBytes returns the canonical encoding of tx, which Hash digests
+func (tx *Transaction) canonicalBytes() []byte {
+	var buf bytes.Buffer
+
+	writeUint32(&buf, uint32(len(tx.Inputs)))
+	for _, in := range tx.Inputs {
+		writeBytes(&buf, in.ID)
+		writeInt64(&buf, int64(in.Out))
+		writeBytes(&buf, in.Signature)
+		writeBytes(&buf, in.PubKey)
+	}
+
+	writeUint32(&buf, uint32(len(tx.Outputs)))
+	for _, out := range tx.Outputs {
+		writeInt64(&buf, int64(out.Value))
+		writeBytes(&buf, out.PubKeyHash)
+	}
+
+	return buf.Bytes()
+}
+
+// writeBytes appends data preceded by its length
+// nil and empty slices encode the same way
+func writeBytes(buf *bytes.Buffer, data []byte) {
+	writeUint32(buf, uint32(len(data)))
+	buf.Write(data)
+}
+
+// writeUint32 appends n as 4 big-endian bytes
+func writeUint32(buf *bytes.Buffer, n uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], n)
+	buf.Write(b[:])
+}
+
+// writeInt64 appends n as 8 big-endian bytes
+func writeInt64(buf *bytes.Buffer, n int64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(n))
+	buf.Write(b[:])
+}
@@ -0,0 +1,145 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 24/12/2025
+ * Time: 09:30
+ */
+
+// TRANSACTION INDEX
+// "txindex-{txID}" -> hash of the block holding the transaction, so FindTransaction
+// (called once per input by SignTransaction and VerifyTransaction) doesn't rescan the
+// chain. Entries are written whenever a block joins the active chain. An entry left
+// behind by a reorg points at a block that is no longer on the active chain; lookups
+// check for that. Only a damaged index makes FindTransaction scan the chain instead.
+var txIndexPrefix = []byte("txindex-") // Database key prefix for transaction index entries
+
+// txIndexKey builds the database key for a transaction ID
+func txIndexKey(txID []byte) []byte {
+	return append(append([]byte{}, txIndexPrefix...), txID...)
+}
+
+// indexTransactions points the index entry of every transaction in block at the block
+func indexTransactions(txn *badger.Txn, block *Block) error {
+	for _, tx := range block.Transactions {
+		if err := txn.Set(txIndexKey(tx.ID), block.Hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findIndexedTransaction looks a transaction up through the index
+// Every transaction on the active chain is indexed, so a missing or stale entry means
+// ErrTransactionNotFound; any other error means the index can't be trusted for this lookup
+func (bc *BlockChain) findIndexedTransaction(ID []byte) (Transaction, *Block, error) {
+	var blockHash []byte
+	err := bc.Database.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(txIndexKey(ID))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return ErrTransactionNotFound
+		} else if err != nil {
+			return err
+		}
+		blockHash, err = item.ValueCopy(nil)
+		return err
+	})
+	if err != nil {
+		return Transaction{}, nil, err
+	}
+
+	block, err := bc.GetBlock(blockHash)
+	if err != nil {
+		return Transaction{}, nil, err
+	}
+
+	// Only blocks on the active chain count (see the note above)
+	indexed, err := bc.GetBlockHashByHeight(block.Height)
+	if err != nil {
+		return Transaction{}, nil, err
+	}
+	if !bytes.Equal(indexed, block.Hash) {
+		return Transaction{}, nil, ErrTransactionNotFound
+	}
+
+	for _, tx := range block.Transactions {
+		if bytes.Equal(tx.ID, ID) {
+			return *tx, &block, nil
+		}
+	}
+	if block.IsPruned() {
+		return Transaction{}, nil, fmt.Errorf("%w: transaction %x in block %x", ErrTransactionPruned, ID, block.Hash)
+	}
+	return Transaction{}, nil, fmt.Errorf("index points transaction %x at block %x, which doesn't hold it", ID, block.Hash)
+}
+
+// hasTxIndex reports whether the database holds any transaction index entries
+func (bc *BlockChain) hasTxIndex() bool {
+	found := false
+	err := bc.Database.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		it.Seek(txIndexPrefix)
+		found = it.ValidForPrefix(txIndexPrefix)
+		return nil
+	})
+	Handle(err)
+	return found
+}
+
+// ReindexTransactions rebuilds the transaction index from the active chain
+// Used for databases created before the index existed, or after corruption
+func (bc *BlockChain) ReindexTransactions() {
+	// Collect the existing entries first; deleting while iterating isn't allowed
+	var staleKeys [][]byte
+	err := bc.Database.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(txIndexPrefix); it.ValidForPrefix(txIndexPrefix); it.Next() {
+			staleKeys = append(staleKeys, it.Item().KeyCopy(nil))
+		}
+		return nil
+	})
+	Handle(err)
+
+	err = bc.Database.Update(func(txn *badger.Txn) error {
+		for _, key := range staleKeys {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	Handle(err)
+
+	// Index the active chain one block per write transaction, tip first
+	iter := bc.Iterator()
+	for {
+		block := iter.Next()
+
+		err := bc.Database.Update(func(txn *badger.Txn) error {
+			return indexTransactions(txn, block)
+		})
+		Handle(err)
+
+		if len(block.PrevHash) == 0 {
+			break // Indexed all the way down to genesis
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 11:20
+ */
+
+// BenchmarkVerifyTransaction verifies a transaction spending the genesis output from
+// under 200 blocks, once looking its input up through the transaction index and once
+// scanning the chain as FindTransaction did before the index
+func BenchmarkVerifyTransaction(b *testing.B) {
+	sender, recipient, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(b, map[*wallet.Wallet]int{sender: 100})
+	for i := 0; i < 200; i++ {
+		mineTestBlock(b, chain, miner)
+	}
+	tx := newTestTransfer(b, chain, sender, recipient, 40, 0)
+
+	b.Run("index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if !chain.VerifyTransaction(tx) {
+				b.Fatal("transaction does not verify")
+			}
+		}
+	})
+
+	// An entry pointing at a block that isn't stored makes every lookup scan the chain
+	err := chain.Database.Update(func(txn *badger.Txn) error {
+		for _, in := range tx.Inputs {
+			if err := txn.Set(txIndexKey(in.ID), []byte("no such block")); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if !chain.VerifyTransaction(tx) {
+				b.Fatal("transaction does not verify")
+			}
+		}
+	})
+}
+
+func TestConfirmationsAtDepth(t *testing.T) {
+	sender, recipient, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+	tx := newTestTransfer(t, chain, sender, recipient, 40, 0)
+
+	if _, err := chain.Confirmations(tx.ID); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("Confirmations of an unknown transaction = %v, want ErrTransactionNotFound", err)
+	}
+
+	// Waiting in the memory pool
+	if err := chain.SaveMempoolTx(tx); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := chain.Confirmations(tx.ID); err != nil || n != 0 {
+		t.Errorf("pooled: Confirmations = %d, %v; want 0", n, err)
+	}
+
+	// In the tip block, then buried under more
+	mineTestBlock(t, chain, miner, tx)
+	for depth := 1; depth <= 5; depth++ {
+		if n, err := chain.Confirmations(tx.ID); err != nil || n != depth {
+			t.Errorf("Confirmations = %d, %v; want %d", n, err, depth)
+		}
+		mineTestBlock(t, chain, miner)
+	}
+}
@@ -0,0 +1,77 @@
+package blockchain
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 12:10
+ */
+
+func TestInvalidRecipientIsAnError(t *testing.T) {
+	sender := wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+	utxoSet := UTXOSet{Blockchain: chain}
+
+	for _, to := range []string{"0OIl", "not an address", ""} {
+		if _, err := NewTXOutputE(10, to); !errors.Is(err, ErrInvalidAddress) {
+			t.Errorf("NewTXOutputE(%q) = %v, want ErrInvalidAddress", to, err)
+		}
+		if _, err := NewTransaction(sender, to, 10, 0, &utxoSet); !errors.Is(err, ErrInvalidAddress) {
+			t.Errorf("NewTransaction to %q = %v, want ErrInvalidAddress", to, err)
+		}
+	}
+}
+
+func TestOutputValuesMustBePositiveAndNotOverflow(t *testing.T) {
+	sender, recipient := wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	for _, value := range []int{0, -1, math.MinInt} {
+		if _, err := NewTXOutputE(value, string(recipient.Address())); !errors.Is(err, ErrInvalidOutputValue) {
+			t.Errorf("NewTXOutputE(%d) = %v, want ErrInvalidOutputValue", value, err)
+		}
+	}
+
+	for name, values := range map[string][]int{
+		"zero":     {0, 60},
+		"negative": {-40, 140},
+		"overflow": {math.MaxInt, 1},
+	} {
+		// Properly signed, so only the values are wrong
+		tx := newTestTransfer(t, chain, sender, recipient, 40, 0)
+		for i, value := range values {
+			tx.Outputs[i].Value = value
+		}
+		if err := chain.SignTransaction(tx, sender.PrivateKey); err != nil {
+			t.Fatal(err)
+		}
+		tx.SetID()
+
+		if err := tx.CheckOutputs(); !errors.Is(err, ErrInvalidOutputValue) {
+			t.Errorf("%s: CheckOutputs = %v, want ErrInvalidOutputValue", name, err)
+		}
+		if chain.VerifyTransaction(tx) {
+			t.Errorf("%s: VerifyTransaction accepted outputs %v", name, values)
+		}
+	}
+
+	// A coinbase may pay zero once the reward schedule ends, but never less
+	coinbase := CoinbaseTx(string(recipient.Address()), "", 1, 0)
+	coinbase.Outputs[0].Value = 0
+	if err := coinbase.CheckOutputs(); err != nil {
+		t.Errorf("zero coinbase: CheckOutputs = %v", err)
+	}
+	coinbase.Outputs[0].Value = -1
+	if err := coinbase.CheckOutputs(); !errors.Is(err, ErrInvalidOutputValue) {
+		t.Errorf("negative coinbase: CheckOutputs = %v, want ErrInvalidOutputValue", err)
+	}
+}
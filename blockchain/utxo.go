@@ -3,9 +3,14 @@ package blockchain
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"log"
+	"math"
 
 	"github.com/dgraph-io/badger/v4"
+	"github.com/golang-blockchain/logger"
+	"github.com/golang-blockchain/wallet"
 )
 
 /**
@@ -16,28 +21,52 @@ import (
  * Time: 11:19
  */
 
-// UTXO (Unspent Transaction Output) Set is an optimized data structure
-// that tracks all spendable outputs without scanning the entire blockchain
-// This dramatically improves performance for wallet operations
+// ErrOutputSpent is returned for a transaction spending an output that is not in the UTXO set
+var ErrOutputSpent = errors.New("output already spent or unknown")
+
+// ErrCorruptUTXO is returned when unreadable UTXO entries may have left a payment short
+var ErrCorruptUTXO = errors.New("UTXO set has unreadable entries")
+
 var (
 	utxoPrefix   = []byte("utxo-") // Database key prefix for UTXO entries
 	prefixLength = len(utxoPrefix) // Length of prefix for key manipulation
 )
 
+// UTXO (Unspent Transaction Output) Set is an optimized data structure
+// that tracks all spendable outputs without scanning the entire blockchain
+// This dramatically improves performance for wallet operations
 // UTXOSet represents the collection of all unspent transaction outputs
 // It's maintained as a separate index for fast lookups
 type UTXOSet struct {
 	Blockchain *BlockChain // Reference to the blockchain for full data access
+
+	// Spent lists outpoints (see TxInput.Outpoint) FindSpendableOutputs must pass over,
+	// e.g. those spent by earlier, not yet confirmed transactions of a batch; may be nil
+	Spent map[string]bool
+}
+
+// MarkSpent records the outputs tx spends in u.Spent, so later coin selection skips them
+func (u *UTXOSet) MarkSpent(tx *Transaction) {
+	if u.Spent == nil {
+		u.Spent = make(map[string]bool)
+	}
+	for _, in := range tx.Inputs {
+		u.Spent[in.Outpoint()] = true
+	}
 }
 
 // FindSpendableOutputs finds enough UTXOs to cover a payment amount
-// This is the core "coin selection" algorithm for creating transactions
-func (u UTXOSet) FindSpendableOutputs(pubkeyHash []byte, amount int) (int, map[string][]int) {
-	// Map to store selected outputs: TransactionID -> []OutputIndices
-	unspentOuts := make(map[string][]int)
-	accumulated := 0 // Total value collected so far
+// This is the core "coin selection" algorithm for creating transactions;
+// strategy decides which outputs are spent (see coin_selection.go)
+// Entries that can't be decoded are logged and passed over, so one corrupt entry doesn't
+// stop the wallet; if the selection then falls short, the partial result comes back with
+// ErrCorruptUTXO, since the missing funds may be in the skipped entries
+func (u UTXOSet) FindSpendableOutputs(pubkeyHash []byte, amount int, strategy CoinSelection) (int, map[string][]int, error) {
+	var candidates []UTXORef // Every output we could spend, in UTXO key order
+	skipped := 0             // Entries that couldn't be decoded
 
 	db := u.Blockchain.Database
+	tipHeight := u.Blockchain.GetBestHeight()
 
 	// Read-only transaction for safe concurrent access
 	err := db.View(func(txn *badger.Txn) error {
@@ -50,37 +79,51 @@ func (u UTXOSet) FindSpendableOutputs(pubkeyHash []byte, amount int) (int, map[s
 		// Iterate through all UTXO entries (keys starting with "utxo-")
 		for it.Seek(utxoPrefix); it.ValidForPrefix(utxoPrefix); it.Next() {
 			item := it.Item()
-			k := item.Key() // Key format: "utxo-" + transactionID
+
+			// Key format: "utxo-" + transactionID; keep the raw transaction ID
+			txID := bytes.TrimPrefix(item.KeyCopy(nil), utxoPrefix)
 
 			// Deserialize the outputs stored for this transaction
-			outs := TxOutputs{}
+			var outs TxOutputs
 			err := item.Value(func(val []byte) error {
-				outs = DeserializeOutputs(val)
-				return nil
+				var err error
+				outs, err = DeserializeOutputsE(val)
+				return err
 			})
+			if err != nil {
+				logger.Error("Skipped unreadable UTXO entry", "txid", hex.EncodeToString(txID), "err", err)
+				skipped++
+				continue
+			}
 
-			// Remove the "utxo-" prefix to get the raw transaction ID
-			k = bytes.TrimPrefix(k, utxoPrefix)
-			txID := hex.EncodeToString(k) // Convert to string for a map key
-			Handle(err)
+			// Block rewards can't be spent until they mature
+			if !outs.IsMature(tipHeight) {
+				continue
+			}
 
-			// Check each output in this transaction
-			for outIdx, out := range outs.Outputs {
-				// Two conditions must be met:
-				// 1. Output belongs to our address (matching pubkeyHash)
-				// 2. We haven't collected enough value yet
-				if out.IsLockedWithKey(pubkeyHash) && accumulated < amount {
-					// Add this output to our selection
-					accumulated += out.Value
-					unspentOuts[txID] = append(unspentOuts[txID], outIdx)
+			// Every output belonging to our address is a candidate, unless already spent off-chain
+			for i, out := range outs.Outputs {
+				if !out.IsLockedWithKey(pubkeyHash) {
+					continue
+				}
+				outpoint := TxInput{ID: txID, Out: outs.Index(i)}
+				if u.Spent[outpoint.Outpoint()] {
+					continue
 				}
+				candidates = append(candidates, UTXORef{TxID: txID, OutIdx: outs.Index(i), Value: out.Value})
 			}
 		}
 		return nil
 	})
+	if err != nil {
+		return 0, nil, err
+	}
 
-	Handle(err)
-	return accumulated, unspentOuts
+	accumulated, picked := selectCoins(candidates, amount, strategy)
+	if accumulated < amount && skipped > 0 {
+		return accumulated, outputsByTx(picked), fmt.Errorf("%w: %d skipped, run reindexutxo", ErrCorruptUTXO, skipped)
+	}
+	return accumulated, outputsByTx(picked), nil
 }
 
 // FindUnspentTransactions returns all UTXOs owned by a specific address
@@ -123,6 +166,197 @@ func (u UTXOSet) FindUnspentTransactions(pubkeyHash []byte) []TxOutput {
 	return UTXOs
 }
 
+// UTXORef identifies one unspent output together with its value
+type UTXORef struct {
+	TxID   []byte // Transaction that created the output
+	OutIdx int    // Index of the output within its transaction
+	Value  int    // Amount locked in the output
+}
+
+// ListUnspent returns one page of the UTXOs owned by pubKeyHash
+// Entries are ordered by transaction ID, then output index (the UTXO key order),
+// so consecutive pages never overlap or skip; an offset past the end gives an empty page
+func (u UTXOSet) ListUnspent(pubKeyHash []byte, offset, limit int) ([]UTXORef, error) {
+	if offset < 0 || limit <= 0 {
+		return nil, fmt.Errorf("invalid page: offset %d, limit %d", offset, limit)
+	}
+
+	var page []UTXORef
+	skipped := 0 // Matching outputs passed over to reach the offset
+
+	err := u.Blockchain.Database.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(utxoPrefix); it.ValidForPrefix(utxoPrefix); it.Next() {
+			item := it.Item()
+			txID := bytes.TrimPrefix(item.KeyCopy(nil), utxoPrefix)
+
+			outs := TxOutputs{}
+			err := item.Value(func(val []byte) error {
+				outs = DeserializeOutputs(val)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for i, out := range outs.Outputs {
+				if !out.IsLockedWithKey(pubKeyHash) {
+					continue
+				}
+				if skipped < offset {
+					skipped++
+					continue
+				}
+				page = append(page, UTXORef{TxID: txID, OutIdx: outs.Index(i), Value: out.Value})
+				if len(page) == limit {
+					return nil // Page full
+				}
+			}
+		}
+		return nil
+	})
+
+	return page, err
+}
+
+// IsUnspent reports whether output outIdx of transaction txID is in the UTXO set,
+// i.e. created on the active chain and not spent there yet
+func (u UTXOSet) IsUnspent(txID []byte, outIdx int) (bool, error) {
+	unspent := false
+	err := u.Blockchain.Database.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(append(append([]byte{}, utxoPrefix...), txID...))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil // Every output of the transaction is spent (or it never existed)
+		} else if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			_, unspent = DeserializeOutputs(val).Find(outIdx)
+			return nil
+		})
+	})
+	return unspent, err
+}
+
+// InputTotal adds up the values of the outputs inputs spend, as the UTXO set has them
+// Returns ErrOutputSpent for an input whose output isn't in the set
+func (u UTXOSet) InputTotal(inputs []TxInput) (int, error) {
+	total := 0
+	err := u.Blockchain.Database.View(func(txn *badger.Txn) error {
+		for _, in := range inputs {
+			item, err := txn.Get(append(append([]byte{}, utxoPrefix...), in.ID...))
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				return fmt.Errorf("%w: %s", ErrOutputSpent, in.Outpoint())
+			} else if err != nil {
+				return err
+			}
+
+			err = item.Value(func(val []byte) error {
+				out, ok := DeserializeOutputs(val).Find(in.Out)
+				if !ok {
+					return fmt.Errorf("%w: %s", ErrOutputSpent, in.Outpoint())
+				}
+				total += out.Value
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return total, err
+}
+
+// CheckUnspent makes sure every output tx spends is still in the UTXO set
+// Returns ErrOutputSpent for a transaction spending an output the chain has already spent
+func (u UTXOSet) CheckUnspent(tx *Transaction) error {
+	if tx.IsCoinbase() {
+		return nil
+	}
+	for _, in := range tx.Inputs {
+		unspent, err := u.IsUnspent(in.ID, in.Out)
+		if err != nil {
+			return err
+		}
+		if !unspent {
+			return fmt.Errorf("%w: %s", ErrOutputSpent, in.Outpoint())
+		}
+	}
+	return nil
+}
+
+// GetBalance returns the total value of the unspent outputs locked to a Base58 address
+// Returns ErrInvalidAddress if the address is malformed or its checksum doesn't match
+func (u UTXOSet) GetBalance(address string) (int, error) {
+	if !wallet.ValidateAddress(address) {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+
+	// Remove version [1 first byte] and checksum [4 last bytes]
+	pubKeyHash := wallet.Base58Decode([]byte(address))
+	pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-4]
+
+	balance := 0
+	for _, out := range u.FindUnspentTransactions(pubKeyHash) {
+		balance += out.Value
+	}
+	return balance, nil
+}
+
+// GetBalanceWithMempool returns the confirmed balance of a Base58 address (as GetBalance)
+// and the unconfirmed one: what it will be once every transaction in the persistent memory
+// pool confirms. Pending transactions spending the address's outputs lower it, their
+// outputs to the address raise it; an output already spent by another pending
+// transaction (a chain of unconfirmed payments) counts for neither
+func (u UTXOSet) GetBalanceWithMempool(address string) (confirmed, unconfirmed int, err error) {
+	if !wallet.ValidateAddress(address) {
+		return 0, 0, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+
+	// Remove version [1 first byte] and checksum [4 last bytes]
+	pubKeyHash := wallet.Base58Decode([]byte(address))
+	pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-4]
+
+	pending, err := u.Blockchain.LoadMempool()
+	if err != nil {
+		return 0, 0, err
+	}
+	spent := make(map[string]bool) // Outpoints spent by pending transactions
+	for _, tx := range pending {
+		for _, in := range tx.Inputs {
+			spent[in.Outpoint()] = true
+		}
+	}
+
+	// Confirmed outputs: all count as confirmed, those a pending transaction spends are gone once it confirms
+	utxos, err := u.ListUnspent(pubKeyHash, 0, math.MaxInt)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, utxo := range utxos {
+		confirmed += utxo.Value
+		outpoint := TxInput{ID: utxo.TxID, Out: utxo.OutIdx}
+		if !spent[outpoint.Outpoint()] {
+			unconfirmed += utxo.Value
+		}
+	}
+
+	// Pending outputs to the address that no other pending transaction spends
+	for _, tx := range pending {
+		for outIdx, out := range tx.Outputs {
+			outpoint := TxInput{ID: tx.ID, Out: outIdx}
+			if out.IsLockedWithKey(pubKeyHash) && !spent[outpoint.Outpoint()] {
+				unconfirmed += out.Value
+			}
+		}
+	}
+	return confirmed, unconfirmed, nil
+}
+
 // CountTransactions returns the total number of transactions with unspent outputs
 // Useful for monitoring and debugging
 func (u UTXOSet) CountTransactions() int {
@@ -152,102 +386,221 @@ func (u UTXOSet) CountTransactions() int {
 // 1. Initial setup
 // 2. Database corruption recovery
 // 3. Major blockchain reorganization
-func (u UTXOSet) Reindex() {
-	db := u.Blockchain.Database
+// A pruned chain no longer holds the spending transactions, so it can't be reindexed:
+// ErrChainPruned is returned and the set is left as it is
+func (u UTXOSet) Reindex() error {
+	if u.Blockchain.IsPruned() {
+		return ErrChainPruned
+	}
 
 	// Clear existing UTXO data
 	u.DeleteByPrefix(utxoPrefix)
 
-	// Scan the entire blockchain to find current UTXOs
-	UTXO := u.Blockchain.FindUTXO()
-
-	// Write a new UTXO set to a database
-	err := db.Update(func(txn *badger.Txn) error {
-		for txId, outs := range UTXO {
-			// Convert hex transaction ID to bytes
-			key, err := hex.DecodeString(txId)
-			if err != nil {
-				return err
-			}
+	// Scan the entire blockchain to find current UTXOs, several height ranges at once
+	UTXO, err := u.Blockchain.findUTXOParallel()
+	if err != nil {
+		logger.Warn("Parallel UTXO scan failed, scanning the chain serially", "err", err)
+		UTXO = u.Blockchain.FindUTXO()
+	}
 
-			// Add the "utxo-" prefix to create a database key
-			key = append(utxoPrefix, key...)
+	// Write the new UTXO set to the database in bounded batches
+	return u.writeUTXO(UTXO, reindexBatchSize)
+}
 
-			// Store serialized outputs
-			err = txn.Set(key, outs.Serialize())
-			Handle(err)
-		}
-		return nil
+// Update modifies the UTXO set when a new block is added to the blockchain
+// This is the most performance-critical function - called for every new block
+// Returns ErrOutputSpent, and changes nothing, when the block spends an output the set doesn't hold
+func (u *UTXOSet) Update(block *Block) error {
+	return u.Blockchain.Database.Update(func(txn *badger.Txn) error {
+		return applyBlock(txn, block)
 	})
+}
 
-	Handle(err)
+// utxoKey returns the database key of the UTXO entry of a transaction: "utxo-" + txID
+func utxoKey(txID []byte) []byte {
+	return append(append([]byte{}, utxoPrefix...), txID...)
 }
 
-// Update modifies the UTXO set when a new block is added to the blockchain
-// This is the most performance-critical function - called for every new block
-func (u *UTXOSet) Update(block *Block) {
-	db := u.Blockchain.Database
+// applyBlock makes the changes Update makes within txn, so callers can commit them
+// together with the tip that block becomes (see storeBlock and reorganize)
+// block must extend the chain the set in txn describes; every input must spend an
+// output of the set, or one created earlier in the block, or ErrOutputSpent is returned
+// and the caller must discard txn
+func applyBlock(txn *badger.Txn, block *Block) error {
+	// Process each transaction in the new block
+	for _, tx := range block.Transactions {
+		// For regular transactions (not coinbase):
+		// Remove outputs that were spent by this transaction's inputs
+		if tx.IsCoinbase() == false {
+			for _, in := range tx.Inputs {
+				updateOuts := TxOutputs{}
+
+				// Create a database key: "utxo-" + spentTransactionID
+				inID := utxoKey(in.ID)
+
+				// Get the outputs for the transaction being spent from
+				// A missing entry means every output of it was already spent (or never existed)
+				item, err := txn.Get(inID)
+				if errors.Is(err, badger.ErrKeyNotFound) {
+					return fmt.Errorf("%w: %s (transaction %x)", ErrOutputSpent, in.Outpoint(), tx.ID)
+				} else if err != nil {
+					return err
+				}
 
-	err := db.Update(func(txn *badger.Txn) error {
-		// Process each transaction in the new block
-		for _, tx := range block.Transactions {
-			// For regular transactions (not coinbase):
-			// Remove outputs that were spent by this transaction's inputs
-			if tx.IsCoinbase() == false {
-				for _, in := range tx.Inputs {
-					updateOuts := TxOutputs{}
-
-					// Create a database key: "utxo-" + spentTransactionID
-					inID := append(utxoPrefix, in.ID...)
-
-					// Get the outputs for the transaction being spent from
-					item, err := txn.Get(inID)
-					Handle(err)
-
-					outs := TxOutputs{}
-					err = item.Value(func(val []byte) error {
-						outs = DeserializeOutputs(val)
-						return nil
-					})
-					Handle(err)
-
-					// Keep all outputs EXCEPT the one being spent
-					for outIdx, out := range outs.Outputs {
-						if outIdx != in.Out { // Skip the spent output
-							updateOuts.Outputs = append(updateOuts.Outputs, out)
-						}
+				outs := TxOutputs{}
+				err = item.Value(func(val []byte) error {
+					outs, err = DeserializeOutputsE(val)
+					return err
+				})
+				if err != nil {
+					return fmt.Errorf("UTXO entry of %x: %w", in.ID, err)
+				}
+				if _, ok := outs.Find(in.Out); !ok {
+					return fmt.Errorf("%w: %s (transaction %x)", ErrOutputSpent, in.Outpoint(), tx.ID)
+				}
+				updateOuts.Height, updateOuts.Coinbase = outs.Height, outs.Coinbase
+
+				// Keep all outputs EXCEPT the one being spent (with their original indices)
+				for i, out := range outs.Outputs {
+					if outs.Index(i) != in.Out { // Skip the spent output
+						updateOuts.Outputs = append(updateOuts.Outputs, out)
+						updateOuts.Indices = append(updateOuts.Indices, outs.Index(i))
 					}
+				}
 
-					// If no outputs remain, delete the entire entry
-					// Otherwise, update with remaining outputs
-					if len(updateOuts.Outputs) == 0 {
-						if err := txn.Delete(inID); err != nil {
-							log.Panic(err)
-						}
-					} else {
-						if err := txn.Set(inID, updateOuts.Serialize()); err != nil {
-							log.Panic(err)
-						}
+				// If no outputs remain, delete the entire entry
+				// Otherwise, update with remaining outputs
+				if len(updateOuts.Outputs) == 0 {
+					if err := txn.Delete(inID); err != nil {
+						return err
+					}
+				} else {
+					if err := txn.Set(inID, updateOuts.Serialize()); err != nil {
+						return err
 					}
 				}
 			}
+		}
 
-			// Add new outputs created by this transaction
-			newOutputs := TxOutputs{}
-			for _, out := range tx.Outputs {
-				newOutputs.Outputs = append(newOutputs.Outputs, out)
+		// Add new outputs created by this transaction
+		// Data outputs are unspendable and stay out of the set
+		newOutputs := TxOutputs{Height: block.Height, Coinbase: tx.IsCoinbase()}
+		for outIdx, out := range tx.Outputs {
+			if out.IsData() {
+				continue
 			}
+			newOutputs.Outputs = append(newOutputs.Outputs, out)
+			newOutputs.Indices = append(newOutputs.Indices, outIdx)
+		}
+		if len(newOutputs.Outputs) == 0 {
+			continue
+		}
 
-			// Store new outputs with a key: "utxo-" + newTransactionID
-			txID := append(utxoPrefix, tx.ID...)
-			if err := txn.Set(txID, newOutputs.Serialize()); err != nil {
-				log.Panic(err)
-			}
+		// Store new outputs with a key: "utxo-" + newTransactionID
+		if err := txn.Set(utxoKey(tx.ID), newOutputs.Serialize()); err != nil {
+			return err
 		}
-		return nil
+	}
+	return nil
+}
+
+// Revert undoes Update for a block that is being removed from the active chain
+// The block's outputs are deleted and the outputs its inputs spent become unspent again
+// Must be called while the block is still on the active chain, so the spent
+// transactions can be found; blocks are reverted tip first
+func (u *UTXOSet) Revert(block *Block) error {
+	undo, err := u.planRevert(block)
+	if err != nil {
+		return err
+	}
+	return u.Blockchain.Database.Update(func(txn *badger.Txn) error {
+		return revertBlock(txn, undo)
 	})
+}
 
-	Handle(err)
+// blockUndo holds what reverting a block gives back to the UTXO set, looked up while the
+// block was still on the active chain
+type blockUndo struct {
+	block    *Block
+	restored map[string]map[int]TxOutput // Spent TransactionID (raw) -> output index -> output to give back
+	origins  map[string]TxOutputs        // Spent TransactionID (raw) -> Height and Coinbase for a new entry
+}
+
+// planRevert looks up the outputs block spent, so revertBlock can give them back
+// Like Revert, it must be called while the block is still on the active chain
+func (u *UTXOSet) planRevert(block *Block) (*blockUndo, error) {
+	// Transactions created by this block disappear entirely
+	created := make(map[string]bool)
+	for _, tx := range block.Transactions {
+		created[string(tx.ID)] = true
+	}
+
+	undo := &blockUndo{
+		block:    block,
+		restored: make(map[string]map[int]TxOutput),
+		origins:  make(map[string]TxOutputs),
+	}
+	for _, tx := range block.Transactions {
+		if tx.IsCoinbase() {
+			continue
+		}
+		for _, in := range tx.Inputs {
+			if created[string(in.ID)] {
+				continue // Spent within the same block
+			}
+			prevTX, prevBlock, err := u.Blockchain.FindTransactionBlock(in.ID)
+			if err != nil {
+				return nil, fmt.Errorf("output %s spent by block %x: %w", in.Outpoint(), block.Hash, err)
+			}
+			if in.Out < 0 || in.Out >= len(prevTX.Outputs) {
+				return nil, fmt.Errorf("%w: %x has no output %d", ErrUnknownOutput, in.ID, in.Out)
+			}
+			if undo.restored[string(in.ID)] == nil {
+				undo.restored[string(in.ID)] = make(map[int]TxOutput)
+				undo.origins[string(in.ID)] = TxOutputs{Height: prevBlock.Height, Coinbase: prevTX.IsCoinbase()}
+			}
+			undo.restored[string(in.ID)][in.Out] = prevTX.Outputs[in.Out]
+		}
+	}
+	return undo, nil
+}
+
+// revertBlock makes the changes Revert makes within txn, so a reorganization can commit
+// them together with the new tip (see reorganize)
+func revertBlock(txn *badger.Txn, undo *blockUndo) error {
+	// Outputs created by the block never happened
+	for _, tx := range undo.block.Transactions {
+		if err := txn.Delete(utxoKey(tx.ID)); err != nil {
+			return err
+		}
+	}
+
+	// Outputs spent by the block are spendable again
+	for id, outputs := range undo.restored {
+		key := utxoKey([]byte(id))
+
+		outs := undo.origins[id] // Used when every output had been spent
+		item, err := txn.Get(key)
+		if err == nil {
+			err = item.Value(func(val []byte) error {
+				outs, err = DeserializeOutputsE(val)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("UTXO entry of %x: %w", id, err)
+			}
+		} else if !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+
+		for outIdx, out := range outputs {
+			outs.Add(outIdx, out)
+		}
+		if err := txn.Set(key, outs.Serialize()); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // DeleteByPrefix efficiently deletes all keys with a given prefix
@@ -0,0 +1,234 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 14:10
+ */
+
+// PARALLEL UTXO SCAN
+// FindUTXO walks the chain tip first so every spend is seen before the output it
+// spends. Reindex instead splits the active chain into height ranges, one per CPU,
+// found through the height index. Each worker records what its blocks create and
+// what they spend; once all are done, the UTXO set is every created output that no
+// range spends. Working with whole sets makes the order blocks are read in irrelevant.
+//
+// The ranges must join up into the active chain. If the height index is damaged
+// (a lookup fails or a PrevHash link doesn't match), the serial FindUTXO is used.
+const (
+	minBlocksPerWorker = 64    // Below this many blocks per CPU, fewer workers are started
+	reindexBatchSize   = 10000 // UTXO entries written per database transaction
+)
+
+// scanWorkers is how many ranges are scanned at once at most; a variable so tests can
+// split a chain into several ranges on a single CPU
+var scanWorkers = runtime.NumCPU()
+
+// rangeScan is what one worker learned about a range of heights
+type rangeScan struct {
+	created   map[string]TxOutputs // Transaction ID (hex) -> every output it created
+	spent     map[string]bool      // Outpoints spent in the range
+	firstPrev []byte               // PrevHash of the range's first block
+	lastHash  []byte               // Hash of the range's last block
+	err       error
+}
+
+// findUTXOParallel builds the same map as FindUTXO by scanning height ranges concurrently
+func (chain *BlockChain) findUTXOParallel() (map[string]TxOutputs, error) {
+	tipHash, err := chain.tipHash()
+	if err != nil {
+		return nil, err
+	}
+	tip := chain.GetBestHeight()
+
+	workers := scanWorkers
+	if limit := (tip + 1) / minBlocksPerWorker; limit < workers {
+		workers = limit
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Step 1: Scan consecutive ranges of heights, one goroutine each
+	scans := make([]rangeScan, workers)
+	perWorker := (tip + workers) / workers // Ceiling of (tip+1)/workers
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		from, to := i*perWorker, (i+1)*perWorker-1
+		if to > tip {
+			to = tip
+		}
+		wg.Add(1)
+		go func(i, from, to int) {
+			defer wg.Done()
+			scans[i] = chain.scanRange(from, to)
+		}(i, from, to)
+	}
+	wg.Wait()
+
+	// Step 2: Make sure the ranges join up into the chain ending at the tip
+	var prevHash []byte // Hash the next range must build on; genesis builds on nothing
+	for i, scan := range scans {
+		if scan.err != nil {
+			return nil, scan.err
+		}
+		if scan.created == nil {
+			continue // Empty range past the tip
+		}
+		if !bytes.Equal(scan.firstPrev, prevHash) {
+			return nil, fmt.Errorf("%w: range %d does not build on the previous one", ErrInvalidPrevHash, i)
+		}
+		prevHash = scan.lastHash
+	}
+	if !bytes.Equal(prevHash, tipHash) {
+		return nil, fmt.Errorf("%w: height index does not end at the tip", ErrInvalidPrevHash)
+	}
+
+	// Step 3: Keep every created output that no range spends
+	spent := make(map[string]bool)
+	for _, scan := range scans {
+		for outpoint := range scan.spent {
+			spent[outpoint] = true
+		}
+	}
+
+	UTXO := make(map[string]TxOutputs)
+	for _, scan := range scans {
+		for txID, created := range scan.created {
+			id, err := hex.DecodeString(txID)
+			if err != nil {
+				return nil, err
+			}
+
+			unspent := TxOutputs{Height: created.Height, Coinbase: created.Coinbase}
+			for i, out := range created.Outputs {
+				outpoint := TxInput{ID: id, Out: created.Index(i)}
+				if !spent[outpoint.Outpoint()] {
+					unspent.Add(created.Index(i), out)
+				}
+			}
+			if len(unspent.Outputs) > 0 {
+				UTXO[txID] = unspent
+			}
+		}
+	}
+	return UTXO, nil
+}
+
+// scanRange records the outputs created and spent by the active-chain blocks at heights from..to
+func (chain *BlockChain) scanRange(from, to int) rangeScan {
+	scan := rangeScan{created: make(map[string]TxOutputs), spent: make(map[string]bool)}
+	if from > to {
+		scan.created = nil
+		return scan
+	}
+
+	for height := from; height <= to; height++ {
+		block, err := chain.blockAtHeight(height)
+		if err != nil {
+			scan.err = err
+			return scan
+		}
+		if height == from {
+			scan.firstPrev = block.PrevHash
+		} else if !bytes.Equal(block.PrevHash, scan.lastHash) {
+			scan.err = fmt.Errorf("%w: height %d does not build on height %d", ErrInvalidPrevHash, height, height-1)
+			return scan
+		}
+		scan.lastHash = block.Hash
+
+		for _, tx := range block.Transactions {
+			outs := TxOutputs{Height: block.Height, Coinbase: tx.IsCoinbase()}
+			for outIdx, out := range tx.Outputs {
+				if !out.IsData() { // Unspendable, never in the UTXO set
+					outs.Add(outIdx, out)
+				}
+			}
+			if len(outs.Outputs) > 0 {
+				scan.created[hex.EncodeToString(tx.ID)] = outs
+			}
+
+			if !tx.IsCoinbase() {
+				for _, in := range tx.Inputs {
+					scan.spent[in.Outpoint()] = true
+				}
+			}
+		}
+	}
+	return scan
+}
+
+// tipHash reads the hash of the active chain's tip from the database
+func (chain *BlockChain) tipHash() ([]byte, error) {
+	var hash []byte
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte("lh"))
+		if err != nil {
+			return err
+		}
+		hash, err = item.ValueCopy(nil)
+		return err
+	})
+	return hash, err
+}
+
+// writeUTXO stores a freshly computed UTXO set, batchSize entries per transaction
+// One transaction for a whole chain could exceed badger's transaction size limit.
+// A batch of unusually large entries that still doesn't fit is committed early
+func (u UTXOSet) writeUTXO(UTXO map[string]TxOutputs, batchSize int) error {
+	var batch []string
+	flush := func() error {
+		txn := u.Blockchain.Database.NewTransaction(true)
+		defer func() { txn.Discard() }()
+
+		for _, txID := range batch {
+			id, err := hex.DecodeString(txID)
+			if err != nil {
+				return err
+			}
+			key := append(append([]byte{}, utxoPrefix...), id...)
+			value := UTXO[txID].Serialize()
+
+			err = txn.Set(key, value)
+			if errors.Is(err, badger.ErrTxnTooBig) {
+				// Commit what fits and carry on in a fresh transaction
+				if err := txn.Commit(); err != nil {
+					return err
+				}
+				txn = u.Blockchain.Database.NewTransaction(true)
+				err = txn.Set(key, value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return txn.Commit()
+	}
+
+	for txID := range UTXO {
+		batch = append(batch, txID)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(batch) > 0 {
+		return flush()
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 09:10
+ */
+
+// storedUTXO reads the whole UTXO set back from the database
+func storedUTXO(t *testing.T, chain *BlockChain) map[string]TxOutputs {
+	t.Helper()
+
+	stored := make(map[string]TxOutputs)
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(utxoPrefix); it.ValidForPrefix(utxoPrefix); it.Next() {
+			value, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			txID := hex.EncodeToString(bytes.TrimPrefix(it.Item().KeyCopy(nil), utxoPrefix))
+			stored[txID] = DeserializeOutputs(value)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stored
+}
+
+func TestWriteUTXOInSmallBatches(t *testing.T) {
+	miner := wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{miner: 100})
+	for i := 0; i < 7; i++ {
+		mineTestBlock(t, chain, miner)
+	}
+
+	utxo := chain.FindUTXO()
+	if len(utxo) <= 3 {
+		t.Fatalf("need more than one batch of entries, have %d", len(utxo))
+	}
+
+	utxoSet := UTXOSet{Blockchain: chain}
+	utxoSet.DeleteByPrefix(utxoPrefix)
+	if err := utxoSet.writeUTXO(utxo, 3); err != nil {
+		t.Fatalf("writeUTXO: %v", err)
+	}
+
+	stored := storedUTXO(t, chain)
+	if len(stored) != len(utxo) {
+		t.Fatalf("stored %d entries, want %d", len(stored), len(utxo))
+	}
+	for txID, outs := range utxo {
+		if !bytes.Equal(stored[txID].Serialize(), outs.Serialize()) {
+			t.Errorf("entry %s differs after the batched write", txID)
+		}
+	}
+}
+
+// busyChain mines blocks blocks, each paying one of a few recipients out of the miner's
+// earlier rewards and change, so outputs are spent many blocks after they were created
+func busyChain(t testing.TB, blocks int) *BlockChain {
+	t.Helper()
+
+	miner := wallet.MakeWallet()
+	recipients := []*wallet.Wallet{wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()}
+	chain := newTestChain(t, map[*wallet.Wallet]int{miner: 100})
+	mineTestBlock(t, chain, miner)
+	for i := 1; i < blocks; i++ {
+		mineTestBlock(t, chain, miner, newTestTransfer(t, chain, miner, recipients[i%len(recipients)], 1+i%7, i%2))
+	}
+	return chain
+}
+
+// withScanWorkers makes findUTXOParallel split the chain into up to n ranges
+func withScanWorkers(t testing.TB, n int) {
+	workers := scanWorkers
+	scanWorkers = n
+	t.Cleanup(func() { scanWorkers = workers })
+}
+
+func TestParallelScanMatchesSerial(t *testing.T) {
+	chain := busyChain(t, 4*minBlocksPerWorker)
+	withScanWorkers(t, 4)
+
+	serial := chain.FindUTXO()
+	parallel, err := chain.findUTXOParallel()
+	if err != nil {
+		t.Fatalf("findUTXOParallel: %v", err)
+	}
+	if len(parallel) != len(serial) {
+		t.Fatalf("parallel scan found %d entries, serial %d", len(parallel), len(serial))
+	}
+	for txID, outs := range serial {
+		if !bytes.Equal(parallel[txID].Serialize(), outs.Serialize()) {
+			t.Errorf("entry %s differs between the parallel and serial scans", txID)
+		}
+	}
+
+	// Reindex stores what the serial scan finds
+	if err := (UTXOSet{Blockchain: chain}).Reindex(); err != nil {
+		t.Fatalf("Reindex: %v", err)
+	}
+	stored := storedUTXO(t, chain)
+	if len(stored) != len(serial) {
+		t.Fatalf("Reindex stored %d entries, want %d", len(stored), len(serial))
+	}
+	for txID, outs := range serial {
+		if !bytes.Equal(stored[txID].Serialize(), outs.Serialize()) {
+			t.Errorf("entry %s differs after Reindex", txID)
+		}
+	}
+}
+
+// BenchmarkFindUTXO compares the serial scan with the parallel one on this machine's CPUs
+func BenchmarkFindUTXO(b *testing.B) {
+	chain := busyChain(b, 8*minBlocksPerWorker)
+
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			chain.FindUTXO()
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := chain.findUTXOParallel(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
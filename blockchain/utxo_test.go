@@ -0,0 +1,118 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 14:40
+ */
+
+func TestCorruptUTXOEntryIsPassedOver(t *testing.T) {
+	sender, recipient := wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+	block := mineTestBlock(t, chain, sender)
+	reward := BlockReward(block.Height)
+
+	// The block reward's entry can no longer be decoded; the genesis allocation still can
+	key := append(append([]byte{}, utxoPrefix...), block.Transactions[0].ID...)
+	err := chain.Database.Update(func(txn *badger.Txn) error {
+		return txn.Set(key, []byte("not a gob"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	UTXO := UTXOSet{Blockchain: chain}
+	pubKeyHash := wallet.PublicKeyHash(sender.PublicKey)
+
+	acc, outputs, err := UTXO.FindSpendableOutputs(pubKeyHash, 60, BestFit)
+	if err != nil || acc != 100 || len(outputs) != 1 {
+		t.Errorf("FindSpendableOutputs(60) = %d, %v, %v; want the genesis output alone", acc, outputs, err)
+	}
+
+	// The missing funds may be in the skipped entry, so falling short says so
+	acc, _, err = UTXO.FindSpendableOutputs(pubKeyHash, 100+reward, BestFit)
+	if !errors.Is(err, ErrCorruptUTXO) || acc != 100 {
+		t.Errorf("FindSpendableOutputs(%d) = %d, %v; want 100, ErrCorruptUTXO", 100+reward, acc, err)
+	}
+
+	if _, err := NewTransaction(sender, string(recipient.Address()), 60, 1, &UTXO); err != nil {
+		t.Errorf("NewTransaction covered by the readable output: %v", err)
+	}
+	if _, err := NewTransaction(sender, string(recipient.Address()), 100+reward, 1, &UTXO); !errors.Is(err, ErrCorruptUTXO) {
+		t.Errorf("NewTransaction = %v, want ErrCorruptUTXO", err)
+	}
+}
+
+func TestListUnspentPagesNeitherOverlapNorSkip(t *testing.T) {
+	owner, other := wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{owner: 100, other: 50})
+	utxoSet := UTXOSet{Blockchain: chain}
+	pubKeyHash := wallet.PublicKeyHash(owner.PublicKey)
+
+	// One output from genesis and one from each block's reward; other's outputs are interleaved
+	for i := 0; i < 7; i++ {
+		mineTestBlock(t, chain, owner, newTestTransfer(t, chain, other, other, 1, 0))
+	}
+	const outputs = 8
+
+	all, err := utxoSet.ListUnspent(pubKeyHash, 0, 100)
+	if err != nil || len(all) != outputs {
+		t.Fatalf("ListUnspent of everything = %d entries, %v; want %d", len(all), err, outputs)
+	}
+	total := 0
+	for i, ref := range all {
+		total += ref.Value
+		if i > 0 {
+			prev := all[i-1]
+			if order := bytes.Compare(prev.TxID, ref.TxID); order > 0 || order == 0 && prev.OutIdx >= ref.OutIdx {
+				t.Errorf("entry %d (%x:%d) sorts before entry %d (%x:%d)", i, ref.TxID, ref.OutIdx, i-1, prev.TxID, prev.OutIdx)
+			}
+		}
+	}
+	if want := balance(t, chain, owner); total != want {
+		t.Errorf("listed outputs hold %d, the balance is %d", total, want)
+	}
+
+	// Pages of 3 put back together give the same list
+	var paged []UTXORef
+	for offset := 0; offset < outputs; offset += 3 {
+		page, err := utxoSet.ListUnspent(pubKeyHash, offset, 3)
+		if err != nil {
+			t.Fatalf("ListUnspent(%d, 3): %v", offset, err)
+		}
+		if want := min(3, outputs-offset); len(page) != want {
+			t.Errorf("page at %d holds %d entries, want %d", offset, len(page), want)
+		}
+		paged = append(paged, page...)
+	}
+	if len(paged) != len(all) {
+		t.Fatalf("pages hold %d entries, want %d", len(paged), len(all))
+	}
+	for i := range all {
+		if !bytes.Equal(paged[i].TxID, all[i].TxID) || paged[i].OutIdx != all[i].OutIdx || paged[i].Value != all[i].Value {
+			t.Errorf("paged entry %d = %x:%d, want %x:%d", i, paged[i].TxID, paged[i].OutIdx, all[i].TxID, all[i].OutIdx)
+		}
+	}
+
+	for _, offset := range []int{outputs, outputs + 1, 1000} {
+		if page, err := utxoSet.ListUnspent(pubKeyHash, offset, 3); err != nil || len(page) != 0 {
+			t.Errorf("ListUnspent(%d, 3) past the end = %d entries, %v; want an empty page", offset, len(page), err)
+		}
+	}
+	for _, bad := range [][2]int{{-1, 3}, {0, 0}, {0, -1}} {
+		if _, err := utxoSet.ListUnspent(pubKeyHash, bad[0], bad[1]); err == nil {
+			t.Errorf("ListUnspent(%d, %d) succeeded", bad[0], bad[1])
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 18/12/2025
+ * Time: 14:50
+ */
+
+// BLOCK VALIDATION RULES
+// Each rule is a small helper returning a descriptive error. AddBlock (through
+// ValidateBlock) stops at the first failure; VerifyBlock runs every rule on its
+// own so the verifyblock command can report exactly which one a block breaks.
+
+const (
+	// MaxFutureBlockTime is how far ahead of our clock a block's timestamp may be
+	MaxFutureBlockTime = 2 * time.Hour
+
+	// medianTimeSpan is how many preceding blocks the median time past is taken over
+	medianTimeSpan = 11
+)
+
+// Errors returned when a block fails validation
+var (
+	ErrInvalidProofOfWork      = errors.New("invalid proof of work")
+	ErrInvalidMerkleRoot       = errors.New("merkle root does not match the block hash")
+	ErrInvalidPrevHash         = errors.New("invalid previous block link")
+	ErrInvalidTimestamp        = errors.New("invalid block timestamp")
+	ErrInvalidCoinbase         = errors.New("invalid coinbase")
+	ErrInvalidBlockTransaction = errors.New("block contains an invalid transaction")
+	ErrInvalidBlockVersion     = errors.New("invalid block version")
+	ErrDoubleSpend             = errors.New("output spent twice in the same block")
+	ErrPrunedBlock             = errors.New("block has been pruned")
+)
+
+// BlockCheck is the outcome of one validation rule applied by VerifyBlock
+type BlockCheck struct {
+	Rule string // Name of the rule, e.g. "proof of work"
+	Err  error  // Why the rule failed, nil when it passed
+}
+
+// VerifyBlock applies every validation rule to a single block and reports each outcome
+// Unlike ValidateBlock it doesn't stop at the first failure
+func (chain *BlockChain) VerifyBlock(block *Block) []BlockCheck {
+	checks := []BlockCheck{
+		{"version", chain.checkVersion(block)},
+		{"proof of work", chain.checkProofOfWork(block)},
+		{"merkle root", chain.checkMerkleRoot(block)},
+		{"previous hash", chain.checkPrevHash(block)},
+		{"timestamp", chain.checkTimestamp(block)},
+		{"coinbase", chain.checkCoinbase(block)},
+		{"double spends", checkDoubleSpends(block.Transactions)},
+		{"block size", checkBlockSize(block)},
+	}
+
+	for _, tx := range block.Transactions {
+		checks = append(checks, BlockCheck{fmt.Sprintf("transaction %x", tx.ID), chain.checkTransaction(tx, block.Version)})
+	}
+	return checks
+}
+
+// validateProofOfWork checks that the block's nonce meets the target and produces its hash
+// A pruned block's transactions can't be checked against its root, so it is never accepted
+func (chain *BlockChain) validateProofOfWork(block *Block) error {
+	if block.IsPruned() {
+		return fmt.Errorf("%w: block %x", ErrPrunedBlock, block.Hash)
+	}
+	if err := chain.checkProofOfWork(block); err != nil {
+		return err
+	}
+	return chain.checkMerkleRoot(block)
+}
+
+// checkVersion checks that the block's version is one this node knows and that versions
+// never go back along a chain: once blocks commit to their version, no legacy block can follow
+func (chain *BlockChain) checkVersion(block *Block) error {
+	if block.Version < legacyBlockVersion || block.Version > currentBlockVersion {
+		return fmt.Errorf("%w: block %x has version %d, want at most %d", ErrInvalidBlockVersion, block.Hash, block.Version, currentBlockVersion)
+	}
+
+	if len(block.PrevHash) == 0 {
+		return nil
+	}
+	parent, err := chain.GetBlock(block.PrevHash)
+	if err != nil {
+		return nil // Reported by the previous hash rule
+	}
+	if block.Version < parent.Version {
+		return fmt.Errorf("%w: block %x has version %d, its parent %d", ErrInvalidBlockVersion, block.Hash, block.Version, parent.Version)
+	}
+	return nil
+}
+
+// checkProofOfWork checks the block's seal with the chain's consensus engine
+// For the default proof of work: the nonce must yield a hash under the difficulty target
+func (chain *BlockChain) checkProofOfWork(block *Block) error {
+	if !chain.ConsensusEngine().Verify(block) {
+		return fmt.Errorf("%w: block %x", ErrInvalidProofOfWork, block.Hash)
+	}
+	return nil
+}
+
+// checkMerkleRoot recomputes the block hash from the Merkle root of its transactions
+// The hash commits to the root, so any altered, added or removed transaction breaks it
+func (chain *BlockChain) checkMerkleRoot(block *Block) error {
+	hash := sha256.Sum256(block.headerBytes(chain.Difficulty))
+	if !bytes.Equal(hash[:], block.Hash) {
+		return fmt.Errorf("%w: block %x hashes to %x (merkle root %x)",
+			ErrInvalidMerkleRoot, block.Hash, hash, block.HashTransactions())
+	}
+	return nil
+}
+
+// checkPrevHash checks that the parent block is stored and sits exactly one height below
+func (chain *BlockChain) checkPrevHash(block *Block) error {
+	if len(block.PrevHash) == 0 {
+		if block.Height != 0 {
+			return fmt.Errorf("%w: block at height %d has no parent", ErrInvalidPrevHash, block.Height)
+		}
+		return nil // Genesis
+	}
+
+	parent, err := chain.GetBlock(block.PrevHash)
+	if err != nil {
+		return fmt.Errorf("%w: parent %x not found", ErrInvalidPrevHash, block.PrevHash)
+	}
+	if parent.Height != block.Height-1 {
+		return fmt.Errorf("%w: parent is at height %d, block claims %d", ErrInvalidPrevHash, parent.Height, block.Height)
+	}
+	return nil
+}
+
+// checkTimestamp checks that the block isn't too far in the future and is not earlier than
+// the median timestamp of the medianTimeSpan blocks before it. Using the median rather
+// than the parent lets honest miners with slightly skewed clocks keep building
+func (chain *BlockChain) checkTimestamp(block *Block) error {
+	if limit := time.Now().Add(MaxFutureBlockTime).Unix(); block.Timestamp > limit {
+		return fmt.Errorf("%w: %d is more than %s in the future", ErrInvalidTimestamp, block.Timestamp, MaxFutureBlockTime)
+	}
+
+	if len(block.PrevHash) == 0 {
+		return nil
+	}
+	median, err := chain.medianTimePast(block.PrevHash)
+	if err != nil {
+		return nil // Reported by the previous hash rule
+	}
+	if block.Timestamp < median {
+		return fmt.Errorf("%w: %d is before the median time past %d", ErrInvalidTimestamp, block.Timestamp, median)
+	}
+	return nil
+}
+
+// medianTimePast returns the median timestamp of the block with the given hash and
+// up to medianTimeSpan-1 of its ancestors
+func (chain *BlockChain) medianTimePast(hash []byte) (int64, error) {
+	var timestamps []int64
+	for len(hash) > 0 && len(timestamps) < medianTimeSpan {
+		block, err := chain.GetBlock(hash)
+		if err != nil {
+			return 0, err
+		}
+		timestamps = append(timestamps, block.Timestamp)
+		hash = block.PrevHash
+	}
+
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+	return timestamps[len(timestamps)/2], nil
+}
+
+// checkCoinbase checks that the block has exactly one coinbase and that it pays the reward plus fees, no more, no less
+func (chain *BlockChain) checkCoinbase(block *Block) error {
+	count := 0
+	for _, tx := range block.Transactions {
+		if tx.IsCoinbase() {
+			count++
+		}
+	}
+	if count != 1 {
+		return fmt.Errorf("%w: block has %d coinbase transactions, want 1", ErrInvalidCoinbase, count)
+	}
+	if len(block.PrevHash) == 0 {
+		return nil // The genesis coinbase may pay any allocations (see GenesisTx)
+	}
+	return chain.verifyCoinbase(block.Transactions, block.Height, block.Version)
+}
+
+// checkDoubleSpends checks that no two inputs among the transactions spend the same output
+// Each transaction is verified on its own against the chain, so only this catches two
+// conflicting transfers (or one transaction listing an input twice) in the same block
+func checkDoubleSpends(transactions []*Transaction) error {
+	spent := make(map[string]bool) // Outpoint -> already spent in this block
+	for _, tx := range transactions {
+		if tx.IsCoinbase() {
+			continue
+		}
+		for _, in := range tx.Inputs {
+			outpoint := in.Outpoint()
+			if spent[outpoint] {
+				return fmt.Errorf("%w: %s (transaction %x)", ErrDoubleSpend, outpoint, tx.ID)
+			}
+			spent[outpoint] = true
+		}
+	}
+	return nil
+}
+
+// checkTransaction checks a transaction's ID and, for a transfer, its signatures and that it
+// spends no more than its inputs, under the rules of a block of the given version
+// The coinbase amount is left to checkCoinbase
+func (chain *BlockChain) checkTransaction(tx *Transaction, version int) error {
+	if version != legacyBlockVersion {
+		if err := tx.CheckID(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidBlockTransaction, err)
+		}
+	}
+	if tx.IsCoinbase() {
+		return nil
+	}
+	if err := tx.CheckInputs(); err != nil {
+		return fmt.Errorf("%w: transaction %x: %v", ErrInvalidBlockTransaction, tx.ID, err)
+	}
+	if !chain.verifyTransaction(tx, version) {
+		return fmt.Errorf("%w: transaction %x has unknown inputs or a bad signature", ErrInvalidBlockTransaction, tx.ID)
+	}
+
+	fee, err := chain.Fee(tx)
+	if err != nil {
+		return fmt.Errorf("%w: transaction %x: %v", ErrInvalidBlockTransaction, tx.ID, err)
+	}
+	if fee < 0 {
+		return fmt.Errorf("%w: transaction %x: %v, outputs exceed inputs by %d",
+			ErrInvalidBlockTransaction, tx.ID, ErrUnbalancedTransaction, -fee)
+	}
+	return nil
+}
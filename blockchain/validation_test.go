@@ -0,0 +1,148 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 12:20
+ */
+
+func TestDoubleSpendWithinABlockIsRefused(t *testing.T) {
+	sender, alice, bob := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	// Both spend the sender's only output; each is valid on its own
+	toAlice := newTestTransfer(t, chain, sender, alice, 40, 0)
+	toBob := newTestTransfer(t, chain, sender, bob, 70, 0)
+	for _, tx := range []*Transaction{toAlice, toBob} {
+		if !chain.VerifyTransaction(tx) {
+			t.Fatalf("transaction %x does not verify on its own", tx.ID)
+		}
+	}
+
+	coinbase := CoinbaseTx(string(sender.Address()), "", chain.GetBestHeight()+1, 0)
+	txs := []*Transaction{coinbase, toAlice, toBob}
+	if err := checkDoubleSpends(txs); !errors.Is(err, ErrDoubleSpend) {
+		t.Errorf("checkDoubleSpends = %v, want ErrDoubleSpend", err)
+	}
+	if _, err := chain.MineBlockWithContext(t.Context(), txs); !errors.Is(err, ErrDoubleSpend) {
+		t.Errorf("MineBlockWithContext = %v, want ErrDoubleSpend", err)
+	}
+	if height := chain.GetBestHeight(); height != 0 {
+		t.Errorf("chain grew to height %d", height)
+	}
+
+	// Either one alone is fine
+	if err := checkDoubleSpends([]*Transaction{coinbase, toAlice}); err != nil {
+		t.Errorf("checkDoubleSpends of one spend = %v", err)
+	}
+}
+
+func TestTransactionSpendingAnOutputTwiceIsRefused(t *testing.T) {
+	sender, recipient := wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	// Counting the same 100 twice would let it pay out 200
+	tx := newTestTransfer(t, chain, sender, recipient, 40, 0)
+	tx.Inputs = append(tx.Inputs, tx.Inputs[0])
+	tx.Outputs[len(tx.Outputs)-1].Value += 100
+	if err := chain.SignTransaction(tx, sender.PrivateKey); err != nil {
+		t.Fatal(err)
+	}
+	tx.SetID()
+
+	if err := tx.CheckInputs(); !errors.Is(err, ErrDuplicateInput) {
+		t.Errorf("CheckInputs = %v, want ErrDuplicateInput", err)
+	}
+	if chain.VerifyTransaction(tx) {
+		t.Error("VerifyTransaction accepted a transaction spending an output twice")
+	}
+	if err := checkDoubleSpends([]*Transaction{tx}); !errors.Is(err, ErrDoubleSpend) {
+		t.Errorf("checkDoubleSpends = %v, want ErrDoubleSpend", err)
+	}
+}
+
+// datedBlock seals a block paying miner the reward on top of chain's tip, stamped at timestamp
+func datedBlock(t *testing.T, chain *BlockChain, miner *wallet.Wallet, timestamp int64) *Block {
+	t.Helper()
+
+	height := chain.GetBestHeight() + 1
+	coinbase := CoinbaseTx(string(miner.Address()), "", height, 0)
+	engine := chain.ConsensusEngine()
+	block := CreateBlock(engine, []*Transaction{coinbase}, chain.LastHash, height)
+	block.Timestamp = timestamp
+	engine.Prepare(block)
+	if err := engine.Seal(block); err != nil { // The hash covers the timestamp
+		t.Fatal(err)
+	}
+	return block
+}
+
+func TestBlocksOutsideTheTimestampWindowAreRefused(t *testing.T) {
+	miner := wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{miner: 100})
+	genesis, err := chain.GetBlock(chain.LastHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Three blocks dated g, g+100 and g+200 put the median time past at g+100
+	g := genesis.Timestamp
+	for _, ts := range []int64{g + 100, g + 200} {
+		if err := chain.AddBlock(datedBlock(t, chain, miner, ts)); err != nil {
+			t.Fatalf("AddBlock at %d: %v", ts, err)
+		}
+	}
+
+	refused := map[string]int64{
+		"before the median time past": g + 99, // Still after its grandparent
+		"beyond the future limit":     time.Now().Add(MaxFutureBlockTime + time.Minute).Unix(),
+	}
+	for name, ts := range refused {
+		if err := chain.AddBlock(datedBlock(t, chain, miner, ts)); !errors.Is(err, ErrInvalidTimestamp) {
+			t.Errorf("%s: AddBlock = %v, want ErrInvalidTimestamp", name, err)
+		}
+	}
+	if height := chain.GetBestHeight(); height != 2 {
+		t.Fatalf("chain grew to height %d", height)
+	}
+
+	// Dated exactly at the median, or before its parent, is fine
+	if err := chain.AddBlock(datedBlock(t, chain, miner, g+100)); err != nil {
+		t.Errorf("AddBlock at the median time past: %v", err)
+	}
+}
+
+func TestVerifyBlockPinpointsABadMerkleRoot(t *testing.T) {
+	alice, bob, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{alice: 100})
+	block := mineTestBlock(t, chain, miner, newTestTransfer(t, chain, alice, bob, 30, 1))
+
+	for _, check := range chain.VerifyBlock(block) {
+		if check.Err != nil {
+			t.Errorf("good block fails %s: %v", check.Rule, check.Err)
+		}
+	}
+
+	// A differently tagged coinbase is just as valid, but not the one the hash commits to
+	tampered := *block
+	tampered.Transactions = append([]*Transaction{CoinbaseTx(string(miner.Address()), "other", block.Height, 1)}, block.Transactions[1:]...)
+	var failed []BlockCheck
+	for _, check := range chain.VerifyBlock(&tampered) {
+		if check.Err != nil {
+			failed = append(failed, check)
+		}
+	}
+	if len(failed) != 1 || failed[0].Rule != "merkle root" || !errors.Is(failed[0].Err, ErrInvalidMerkleRoot) {
+		t.Errorf("tampered block fails %v, want only the merkle root", failed)
+	}
+}
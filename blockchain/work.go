@@ -0,0 +1,92 @@
+package blockchain
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 24/12/2025
+ * Time: 11:05
+ */
+
+// CHAIN WORK
+// The active chain is the one that took the most hashing to build, not simply the
+// highest one: once the target varies, a long branch of easy blocks must not beat a
+// shorter branch of hard ones. Each block's expected work is 2^256 / target, and
+// "work-{hash}" stores the sum of that over the block and all of its ancestors.
+var workPrefix = []byte("work-") // Database key prefix for cumulative work entries
+
+// workKey builds the database key for a block's cumulative work
+func workKey(blockHash []byte) []byte {
+	return append(append([]byte{}, workPrefix...), blockHash...)
+}
+
+// BlockWork returns the expected number of hashes needed to mine a block at the given
+// difficulty: 2^256 / target
+func BlockWork(difficulty int) *big.Int {
+	work := new(big.Int).Lsh(big.NewInt(1), 256)
+	return work.Div(work, NewProof(&Block{}, difficulty).Target)
+}
+
+// totalWork returns the cumulative work of the chain ending in blockHash
+// Blocks stored before the index existed have no entry; their work is summed from the
+// nearest ancestor that has one, and written back when txn allows updates
+func totalWork(txn *badger.Txn, blockHash []byte, difficulty int, writable bool) (*big.Int, error) {
+	// Walk back until we reach a block whose total is known (or pass genesis)
+	var missing []*Block
+	total := new(big.Int)
+	for hash := blockHash; len(hash) > 0; {
+		item, err := txn.Get(workKey(hash))
+		if err == nil {
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				return nil, err
+			}
+			total.SetBytes(value)
+			break
+		} else if !errors.Is(err, badger.ErrKeyNotFound) {
+			return nil, err
+		}
+
+		item, err = txn.Get(hash)
+		if err != nil {
+			return nil, err
+		}
+		blockData, err := item.ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+		block := Deserialize(blockData)
+		missing = append(missing, block)
+		hash = block.PrevHash
+	}
+
+	// Add the missing blocks back on, oldest first
+	for i := len(missing) - 1; i >= 0; i-- {
+		total.Add(total, BlockWork(difficulty))
+		if writable {
+			if err := txn.Set(workKey(missing[i].Hash), total.Bytes()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return total, nil
+}
+
+// GetTotalWork returns the cumulative work of the active chain
+func (chain *BlockChain) GetTotalWork() *big.Int {
+	var work *big.Int
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		var err error
+		work, err = totalWork(txn, chain.tip(), chain.Difficulty, false)
+		return err
+	})
+	Handle(err)
+	return work
+}
@@ -0,0 +1,107 @@
+package blockchain
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 12:50
+ */
+
+// branchBlock seals a block of txs paying miner the reward and fees on top of prevHash,
+// which need not be the tip
+func branchBlock(chain *BlockChain, miner *wallet.Wallet, prevHash []byte, height int, txs ...*Transaction) *Block {
+	fees, err := chain.Fees(txs)
+	Handle(err)
+	coinbase := CoinbaseTx(string(miner.Address()), "", height, fees)
+	return CreateBlock(chain.ConsensusEngine(), append([]*Transaction{coinbase}, txs...), prevHash, height)
+}
+
+func TestBlockWorkDoublesWithEachBitOfDifficulty(t *testing.T) {
+	for difficulty := MinDifficulty; difficulty < MinDifficulty+8; difficulty++ {
+		want := new(big.Int).Lsh(big.NewInt(1), uint(difficulty))
+		if got := BlockWork(difficulty); got.Cmp(want) != 0 {
+			t.Errorf("BlockWork(%d) = %s, want %s", difficulty, got, want)
+		}
+	}
+}
+
+func TestForkChoiceFollowsTheMostWork(t *testing.T) {
+	mainMiner, sideMiner := wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{wallet.MakeWallet(): 100})
+	genesis := chain.LastHash
+	blockWork := BlockWork(chain.Difficulty)
+
+	// A branch of equal work and height does not displace the tip it arrived after
+	main1 := mineTestBlock(t, chain, mainMiner)
+	side1 := branchBlock(chain, sideMiner, genesis, 1)
+	if err := chain.AddBlock(side1); err != nil {
+		t.Fatalf("AddBlock(side 1): %v", err)
+	}
+	if !bytes.Equal(chain.LastHash, main1.Hash) {
+		t.Fatal("a branch of equal work took over the tip")
+	}
+
+	// One more block's work and the side branch wins
+	side2 := branchBlock(chain, sideMiner, side1.Hash, 2)
+	if err := chain.AddBlock(side2); err != nil {
+		t.Fatalf("AddBlock(side 2): %v", err)
+	}
+	if !bytes.Equal(chain.LastHash, side2.Hash) {
+		t.Fatalf("tip %x, want the branch with more work's %x", chain.LastHash, side2.Hash)
+	}
+	want := new(big.Int).Mul(blockWork, big.NewInt(3)) // Genesis and two blocks
+	if got := chain.GetTotalWork(); got.Cmp(want) != 0 {
+		t.Errorf("GetTotalWork = %s, want %s", got, want)
+	}
+
+	// The UTXO set followed: only the side branch's rewards exist
+	checkBalances(t, chain, map[*wallet.Wallet]int{mainMiner: 0, sideMiner: BlockReward(1) + BlockReward(2)})
+
+	// Totals missing from a database older than the work index are summed again
+	if err := chain.Database.DropPrefix(workPrefix); err != nil {
+		t.Fatal(err)
+	}
+	if got := chain.GetTotalWork(); got.Cmp(want) != 0 {
+		t.Errorf("GetTotalWork without the index = %s, want %s", got, want)
+	}
+}
+
+func TestHarderChainHoldsMoreWorkAtTheSameHeight(t *testing.T) {
+	miner := wallet.MakeWallet()
+	easy := newTestChain(t, map[*wallet.Wallet]int{miner: 100})
+
+	// Difficulty is set per chain, so the harder branch is a chain of its own
+	const harder = 4
+	hard, err := InitBlockChainWithGenesis(map[string]int{string(miner.Address()): 100}, easy.Difficulty+harder, "hard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { hard.Database.Close() })
+	hard.Consensus = NoOpConsensus{Difficulty: hard.Difficulty}
+	if err := (UTXOSet{Blockchain: hard}).Reindex(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		mineTestBlock(t, easy, miner)
+		mineTestBlock(t, hard, miner)
+	}
+	if easy.GetBestHeight() != hard.GetBestHeight() {
+		t.Fatalf("heights %d and %d, want them equal", easy.GetBestHeight(), hard.GetBestHeight())
+	}
+
+	// Each block is 2^4 times the work, so the whole chain is too
+	easyWork, hardWork := easy.GetTotalWork(), hard.GetTotalWork()
+	if want := new(big.Int).Lsh(easyWork, harder); hardWork.Cmp(want) != 0 {
+		t.Errorf("harder chain's work %s, want %s (16 times %s)", hardWork, want, easyWork)
+	}
+}
@@ -1,15 +1,22 @@
 package cli
 
 import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
 	"os"
 	"runtime"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dgraph-io/badger/v4"
 	"github.com/golang-blockchain/blockchain"
+	"github.com/golang-blockchain/logger"
 	"github.com/golang-blockchain/network"
 	"github.com/golang-blockchain/wallet"
 )
@@ -22,18 +29,47 @@ import (
  * Time: 16:42
  */
 
+// defaultConfigFile is the optional config file consulted for the node ID
+// It holds KEY=VALUE lines, e.g. NODE_ID=3000 (lines starting with # are comments)
+const defaultConfigFile = "./node.conf"
+
+// maxListBlocksRange caps how many blocks a single listblocks call may return
+const maxListBlocksRange = 500
+
+// errNodeIDNotSet is returned when no node ID is given by flag, env. var. or config file
+var errNodeIDNotSet = errors.New("node ID is not set: pass -nodeid ID, set the NODE_ID env. var. or add NODE_ID=ID to " + defaultConfigFile)
+
 type CommandLine struct{}
 
 func (cli *CommandLine) printUsage() {
 	fmt.Println("Usage:")
 	fmt.Println(" getbalance -address ADDRESS - get the balance of an address")
-	fmt.Println(" createblockchain -address ADDRESS - create a blockchain")
+	fmt.Println(" createblockchain -address ADDRESS -premine FILE -difficulty N - create a blockchain (-premine: JSON {\"ADDRESS\": AMOUNT, ...} paid by the genesis block instead; -difficulty: leading zero bits, default " + strconv.Itoa(blockchain.Difficulty) + ")")
 	fmt.Println(" printblockchain - Print the blocks in a the chain")
-	fmt.Println(" send -from FROM -to TO -amount AMOUNT -mine - Send coins from one address to another. Then -mine flag is set, mine off of this node")
+	fmt.Println(" send -from FROM -to TO -amount AMOUNT -fee FEE -data TEXT -mine -dryrun - Send coins from one address to another, leaving FEE (default 0) for the miner and embedding TEXT in an unspendable output. Then -mine flag is set, mine off of this node; -dryrun only prints the signed transaction")
+	fmt.Println(" buildtx -from FROM -to TO -amount AMOUNT -fee FEE -out FILE - Write an unsigned transaction (no wallet needed) for signtx")
+	fmt.Println(" signtx -file FILE -out FILE - Sign a buildtx transaction with this node's wallet, offline")
+	fmt.Println(" broadcasttx -file FILE - Send a transaction signed by signtx to the network")
+	fmt.Println(" sendbatch -file PAYMENTS.json -mine - Make every payment in a JSON list of {\"from\", \"to\", \"amount\"}; -mine puts them all in one block")
 	fmt.Println(" createwallet - Create a new wallet")
 	fmt.Println(" listaddresses - Lists the addresses in our wallet file")
-	fmt.Println(" reindexutxo - Rebuilds the UTXO set")
-	fmt.Println(" startnode -miner ADDRESS - Start a node specified in NODE_ID env. var. -miner enables mining")
+	fmt.Println(" setlabel -address ADDRESS -label NAME - Name an address; send accepts NAME for -from and -to")
+	fmt.Println(" exportkey -address ADDRESS - Print the private key of a wallet (keep it secret!)")
+	fmt.Println(" importkey -key KEY - Add a wallet from a key printed by exportkey")
+	fmt.Println(" listblocks -start H1 -end H2 - Print block summaries for heights H1..H2 as JSON (-end defaults to the tip)")
+	fmt.Println(" printblock -hash HASH | -height N -json - Print one block, by hash or by height on the active chain (-json prints it as JSON)")
+	fmt.Println(" gettransaction -id TXID -json - Print a confirmed transaction and the block holding it")
+	fmt.Println(" verifyblock -hash HASH - Run every validation rule on one block and report which ones fail")
+	fmt.Println(" verifychain - Check every block from the tip to genesis and report the first corrupt one")
+	fmt.Println(" exportchain -file PATH - Write every block, genesis first, to a portable file")
+	fmt.Println(" importchain -file PATH - Create this node's blockchain from a file written by exportchain")
+	fmt.Printf(" prunechain -keep N - Drop spent transaction data from blocks more than N (at least %d) below the tip\n", blockchain.MinPruneKeep)
+	fmt.Println(" reindexutxo - Rebuilds the UTXO set, the block height index and the transaction index")
+	fmt.Println(" mempool - Print the transactions waiting in the memory pool of the running node")
+	fmt.Println(" estimatefee -blocks N - Suggest a fee per byte that gets a transaction mined within N blocks, given the running node's memory pool")
+	fmt.Println(" startnode -miner ADDRESS -tag TAG -http PORT - Start a node specified in NODE_ID env. var. -miner enables mining (-tag signs the mined blocks), -http serves the JSON API")
+	fmt.Println()
+	fmt.Println("Every command accepts -nodeid ID (overrides the NODE_ID env. var.) and -config FILE (default " + defaultConfigFile + ")")
 }
 
 // Special function for validating the arguments passed in CLI
@@ -44,18 +80,67 @@ func (cli *CommandLine) validateArgs() {
 	}
 }
 
-func (cli *CommandLine) StartNode(nodeID, minerAddress string) {
+// resolveNodeID picks the node ID to run as
+// The -nodeid flag wins, then the NODE_ID env. var., then NODE_ID from the config file
+func resolveNodeID(flagValue, configPath string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+
+	if envValue := os.Getenv("NODE_ID"); envValue != "" {
+		return envValue, nil
+	}
+
+	if configPath != "" {
+		nodeID, err := readNodeIDFromConfig(configPath)
+		// The default config file is optional, but one passed explicitly must exist
+		if err != nil && !(os.IsNotExist(err) && configPath == defaultConfigFile) {
+			return "", fmt.Errorf("reading config file %s: %w", configPath, err)
+		}
+		if nodeID != "" {
+			return nodeID, nil
+		}
+	}
+
+	return "", errNodeIDNotSet
+}
+
+// readNodeIDFromConfig returns the NODE_ID entry of a KEY=VALUE config file ("" if absent)
+func readNodeIDFromConfig(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue // Skip blank lines and comments
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if found && strings.TrimSpace(key) == "NODE_ID" {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", nil
+}
+
+func (cli *CommandLine) StartNode(nodeID, minerAddress, minerTag, httpPort string) {
 	fmt.Printf("Starting Node %s\n", nodeID)
 
 	if len(minerAddress) > 0 {
 		if wallet.ValidateAddress(minerAddress) {
 			fmt.Println("Mining is on. Address to receive rewards: ", minerAddress)
 		} else {
-			log.Panic("Wrong miner address!", minerAddress)
+			fmt.Println("Error: invalid miner address", minerAddress)
+			return
 		}
 	}
 
-	network.StartServer(nodeID, minerAddress)
+	if err := network.StartServer(nodeID, minerAddress, minerTag, httpPort); err != nil {
+		fmt.Println("Error:", err)
+	}
 }
 
 func (cli *CommandLine) printChain(nodeID string) {
@@ -64,46 +149,72 @@ func (cli *CommandLine) printChain(nodeID string) {
 
 	iter := chain.Iterator()
 
-	for {
-		block := iter.Next()
+	for iter.HasNext() {
+		block, err := iter.NextBlock()
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
 
 		fmt.Printf("Prev. hash: %x\n", block.PrevHash)
 		fmt.Printf("Hash: %v\n", block.Hash)
-		pow := blockchain.NewProof(block)
-		fmt.Printf("PoW: %s\n", strconv.FormatBool(pow.Validate()))
+		fmt.Printf("PoW: %s\n", strconv.FormatBool(chain.ConsensusEngine().Verify(block)))
+		if tag := block.MinerTag(); tag != "" {
+			fmt.Printf("Miner tag: %q\n", tag)
+		}
 		for _, tx := range block.Transactions {
 			fmt.Printf("Transaction: %s\n", tx)
 		}
 		fmt.Println()
-
-		if len(block.PrevHash) == 0 {
-			break // Means we have reached the genesis block
-		}
 	}
 }
 
-func (cli *CommandLine) createBlockChain(address, nodeID string) {
-	if !wallet.ValidateAddress(address) {
-		log.Panic("Invalid address!")
+func (cli *CommandLine) createBlockChain(address, premineFile string, difficulty int, nodeID string) {
+	var allocations map[string]int
+	if premineFile != "" {
+		// A premine file maps each address to the amount the genesis block pays it
+		content, err := os.ReadFile(premineFile)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if err := json.Unmarshal(content, &allocations); err != nil {
+			fmt.Printf("Error: %s: %v\n", premineFile, err)
+			return
+		}
+	} else {
+		if !wallet.ValidateAddress(address) {
+			fmt.Println("Error: invalid address", address)
+			return
+		}
+		allocations = map[string]int{address: blockchain.BlockReward(0)}
 	}
 
-	chain := blockchain.InitBlockChain(address, nodeID)
+	chain, err := blockchain.InitBlockChainWithGenesis(allocations, difficulty, nodeID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
 	defer func(Database *badger.DB) {
 		err := Database.Close()
 		if err != nil {
-			fmt.Println(err)
+			logger.Error("Could not close the database", "err", err)
 		}
 	}(chain.Database)
 
 	UTXOSet := blockchain.UTXOSet{Blockchain: chain}
-	UTXOSet.Reindex()
+	if err := UTXOSet.Reindex(); err != nil {
+		fmt.Println("Error: could not index the UTXO set:", err)
+		return
+	}
 
 	fmt.Println("Finished creating blockchain!")
 }
 
 func (cli *CommandLine) getBalance(address, nodeID string) {
 	if !wallet.ValidateAddress(address) {
-		log.Panic("Invalid address!")
+		fmt.Printf("Error: invalid address %s\n", address)
+		return
 	}
 
 	chain := blockchain.ContinueBlockChain(nodeID)
@@ -111,30 +222,42 @@ func (cli *CommandLine) getBalance(address, nodeID string) {
 	defer func(Database *badger.DB) {
 		err := Database.Close()
 		if err != nil {
-			fmt.Println(err)
+			logger.Error("Could not close the database", "err", err)
 		}
 	}(chain.Database)
 
-	balance := 0
-
-	pubKeyHash := wallet.Base58Decode([]byte(address))
-	pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-4] // Remove version [1 first byte] and checksum [4 last bytes]
-	UTXOs := UTXOSet.FindUnspentTransactions(pubKeyHash)
-
-	for _, out := range UTXOs {
-		balance += out.Value
+	balance, unconfirmed, err := UTXOSet.GetBalanceWithMempool(address)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
 	}
 
-	fmt.Printf("Balance of %s: TZS %d\n", address, balance)
+	fmt.Printf("Balance of %s: %s\n", address, blockchain.Params.FormatAmount(balance))
+	if unconfirmed != balance {
+		fmt.Printf("Including pending transactions: %s\n", blockchain.Params.FormatAmount(unconfirmed))
+	}
 }
 
-func (cli *CommandLine) send(from, to string, amount int, nodeID string, mineNow bool) {
+// send pays amount from one wallet to an address, leaving fee for the miner
+// With dryRun the signed transaction is only printed: nothing is mined, broadcast or
+// written to the chain
+func (cli *CommandLine) send(from, to string, amount, fee int, data, nodeID string, mineNow, dryRun bool) {
+	// Either side may be given as a label (see setlabel)
+	labels, err := wallet.LoadLabels(nodeID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	from, to = labels.Resolve(from), labels.Resolve(to)
+
 	if !wallet.ValidateAddress(from) {
-		log.Panic("Invalid from address!")
+		fmt.Println("Error: invalid from address", from)
+		return
 	}
 
 	if !wallet.ValidateAddress(to) {
-		log.Panic("Invalid to address!")
+		fmt.Println("Error: invalid to address", to)
+		return
 	}
 
 	chain := blockchain.ContinueBlockChain(nodeID)
@@ -142,88 +265,874 @@ func (cli *CommandLine) send(from, to string, amount int, nodeID string, mineNow
 	defer func(Database *badger.DB) {
 		err := Database.Close()
 		if err != nil {
-			fmt.Println(err)
+			logger.Error("Could not close the database", "err", err)
 		}
 	}(chain.Database)
 
 	wallets, err := wallet.CreateWallets(nodeID)
 	if err != nil {
-		log.Panic(err)
+		fmt.Println("Error:", err)
+		return
+	}
+	w, err := wallets.GetWallet(from)
+	if err != nil {
+		fmt.Printf("Error: %v on this node\n", err)
+		return
 	}
-	w := wallets.GetWallet(from)
 
-	tx := blockchain.NewTransaction(&w, to, amount, &UTXOSet)
+	tx, err := blockchain.NewTransactionWithData(&w, to, amount, fee, []byte(data), &UTXOSet)
+	switch {
+	case errors.Is(err, blockchain.ErrInsufficientFunds):
+		fmt.Printf("Not enough funds to send %s with a fee of %s: %v\n", blockchain.Params.FormatAmount(amount), blockchain.Params.FormatAmount(fee), err)
+		return
+	case err != nil:
+		fmt.Println("Error:", err)
+		return
+	}
+	if dryRun {
+		raw := tx.Serialize()
+		fmt.Println(tx)
+		fmt.Printf("Fee: %s\n", blockchain.Params.FormatAmount(fee))
+		fmt.Printf("Raw transaction (%d B): %x\n", len(raw), raw)
+		fmt.Println("Dry run: the transaction was neither mined nor broadcast")
+		return
+	}
 	if mineNow {
-		cbTx := blockchain.CoinbaseTx(from, "")
+		// The miner, here the sender, claims the fee back through the coinbase
+		fees, err := chain.Fees([]*blockchain.Transaction{tx})
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		cbTx := blockchain.CoinbaseTx(from, "", chain.GetBestHeight()+1, fees)
 		txs := []*blockchain.Transaction{cbTx, tx}
 		block := chain.MineBlock(txs)
-		UTXOSet.Update(block)
+		if err := UTXOSet.Update(block); err != nil {
+			fmt.Println("Error: the block was mined but the UTXO set could not be updated, run reindexutxo:", err)
+			return
+		}
 	} else {
-		network.SendTx(network.KnownNodes[0], tx)
+		err := network.BroadcastTx(tx)
+		switch {
+		case errors.Is(err, network.ErrTxRejected):
+			fmt.Println("Error: the network rejected the transaction:", err)
+			return
+		case err != nil:
+			fmt.Println("Error: transaction was not sent:", err)
+			return
+		}
 		fmt.Println("Send tx")
 	}
 
 	fmt.Println("Success!")
 }
 
+// buildTx writes an unsigned payment to path, for signtx to sign on a machine holding the key
+// Only the sender's address is needed here, not its wallet
+func (cli *CommandLine) buildTx(from, to string, amount, fee int, path, nodeID string) {
+	labels, err := wallet.LoadLabels(nodeID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	from, to = labels.Resolve(from), labels.Resolve(to)
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	defer chain.Database.Close()
+
+	unsigned, err := blockchain.NewUnsignedTransaction(from, to, amount, fee, &blockchain.UTXOSet{Blockchain: chain})
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	if err := os.WriteFile(path, unsigned.Serialize(), 0644); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("Wrote an unsigned transaction spending %d output(s) to %s\n", len(unsigned.Tx.Inputs), path)
+}
+
+// signTx signs the unsigned transaction in path with this node's wallet for its sender
+// and writes the signed transaction to out; no chain or network is needed
+func (cli *CommandLine) signTx(path, out, nodeID string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	unsigned, err := blockchain.DeserializeUnsignedTx(data)
+	if err != nil {
+		fmt.Printf("Error: %s: %v\n", path, err)
+		return
+	}
+
+	wallets, err := wallet.CreateWallets(nodeID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	w, err := wallets.GetWallet(unsigned.From)
+	if err != nil {
+		fmt.Printf("Error: %v on this node\n", err)
+		return
+	}
+
+	tx, err := unsigned.Sign(&w)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fee, err := unsigned.PaidFee()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	if err := os.WriteFile(out, tx.Serialize(), 0644); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	// Show what was signed, the online machine chose it
+	fmt.Println(tx)
+	fmt.Printf("Fee: %s\n", blockchain.Params.FormatAmount(fee))
+	fmt.Printf("Wrote the signed transaction to %s\n", out)
+}
+
+// broadcastTx sends the signed transaction in path (as written by signtx) to the network
+func (cli *CommandLine) broadcastTx(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	tx, err := blockchain.DeserializeTransactionE(data)
+	if err != nil {
+		fmt.Printf("Error: %s: %v\n", path, err)
+		return
+	}
+
+	err = network.BroadcastTx(&tx)
+	switch {
+	case errors.Is(err, network.ErrTxRejected):
+		fmt.Println("Error: the network rejected the transaction:", err)
+		return
+	case err != nil:
+		fmt.Println("Error: transaction was not sent:", err)
+		return
+	}
+	fmt.Printf("Sent transaction %x\n", tx.ID)
+}
+
+// batchPayment is one entry of a sendbatch file
+type batchPayment struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount int    `json:"amount"`
+}
+
+// sendBatch makes every payment listed in a JSON file with one open database
+// Each payment is built and reported on its own; a failed one doesn't stop the rest.
+// Outputs spent by earlier payments of the batch are never selected again, so every
+// payment must be covered by confirmed outputs the batch hasn't used yet
+func (cli *CommandLine) sendBatch(path, nodeID string, mineNow bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	var payments []batchPayment
+	if err := json.Unmarshal(content, &payments); err != nil {
+		fmt.Printf("Error: %s: %v\n", path, err)
+		return
+	}
+	if len(payments) == 0 {
+		fmt.Println("Error: no payments in", path)
+		return
+	}
+
+	labels, err := wallet.LoadLabels(nodeID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	wallets, err := wallet.CreateWallets(nodeID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	UTXOSet := blockchain.UTXOSet{Blockchain: chain}
+	defer func(Database *badger.DB) {
+		err := Database.Close()
+		if err != nil {
+			logger.Error("Could not close the database", "err", err)
+		}
+	}(chain.Database)
+
+	// Step 1: Build and sign every payment
+	var txs []*blockchain.Transaction
+	var rewardTo string // Sender of the first successful payment, paid the -mine reward
+	failed := 0
+	for i, payment := range payments {
+		tx, err := buildBatchPayment(payment, labels, wallets, &UTXOSet)
+		if err != nil {
+			fmt.Printf("[%d] %s -> %s %s: failed: %v\n", i+1, payment.From, payment.To,
+				blockchain.Params.FormatAmount(payment.Amount), err)
+			failed++
+			continue
+		}
+		UTXOSet.MarkSpent(tx) // Later payments must pick other outputs
+
+		// Step 2: Hand it to the network now, or keep it for the block mined below
+		if !mineNow {
+			if err := network.BroadcastTx(tx); err != nil {
+				fmt.Printf("[%d] %s -> %s %s: not sent: %v\n", i+1, payment.From, payment.To,
+					blockchain.Params.FormatAmount(payment.Amount), err)
+				failed++
+				continue
+			}
+		}
+		fmt.Printf("[%d] %s -> %s %s: ok, transaction %x\n", i+1, payment.From, payment.To,
+			blockchain.Params.FormatAmount(payment.Amount), tx.ID)
+		if rewardTo == "" {
+			rewardTo = labels.Resolve(payment.From)
+		}
+		txs = append(txs, tx)
+	}
+
+	// Step 3: With -mine, confirm all of them in a single block rewarding the first sender
+	if mineNow && len(txs) > 0 {
+		fees, err := chain.Fees(txs)
+		if err != nil {
+			fmt.Println("Error: could not mine the batch:", err)
+			return
+		}
+		cbTx := blockchain.CoinbaseTx(rewardTo, "", chain.GetBestHeight()+1, fees)
+		block, err := chain.MineBlockWithContext(context.Background(), append([]*blockchain.Transaction{cbTx}, txs...))
+		if err != nil {
+			fmt.Println("Error: could not mine the batch:", err)
+			return
+		}
+		if err := UTXOSet.Update(block); err != nil {
+			fmt.Println("Error: the block was mined but the UTXO set could not be updated, run reindexutxo:", err)
+			return
+		}
+		fmt.Printf("Mined block %x with %d payment(s)\n", block.Hash, len(txs))
+	}
+
+	fmt.Printf("%d of %d payment(s) succeeded\n", len(payments)-failed, len(payments))
+}
+
+// buildBatchPayment builds and signs one sendbatch payment, resolving labels on both sides
+func buildBatchPayment(payment batchPayment, labels wallet.Labels, wallets *wallet.Wallets, UTXOSet *blockchain.UTXOSet) (*blockchain.Transaction, error) {
+	from, to := labels.Resolve(payment.From), labels.Resolve(payment.To)
+	if !wallet.ValidateAddress(from) {
+		return nil, fmt.Errorf("invalid from address %s", from)
+	}
+	if payment.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive, got %d", payment.Amount)
+	}
+
+	w, err := wallets.GetWallet(from)
+	if err != nil {
+		return nil, fmt.Errorf("%w on this node", err)
+	}
+	return blockchain.NewTransaction(&w, to, payment.Amount, 0, UTXOSet)
+}
+
+// blockSummary is the JSON shape of a block in listblocks output
+type blockSummary struct {
+	Height    int    `json:"height"`
+	Hash      string `json:"hash"`
+	Timestamp int64  `json:"timestamp"`
+	TxCount   int    `json:"txCount"`
+}
+
+func (cli *CommandLine) listBlocks(nodeID string, start, end int) {
+	chain := blockchain.ContinueBlockChain(nodeID)
+	defer func(Database *badger.DB) {
+		err := Database.Close()
+		if err != nil {
+			logger.Error("Could not close the database", "err", err)
+		}
+	}(chain.Database)
+
+	// A negative end means "up to the current tip"
+	if end < 0 {
+		end = chain.GetBestHeight()
+	}
+	if start < 0 || start > end {
+		fmt.Printf("Error: invalid range %d..%d\n", start, end)
+		return
+	}
+	if end-start+1 > maxListBlocksRange {
+		fmt.Printf("Error: range too large, at most %d blocks per call\n", maxListBlocksRange)
+		return
+	}
+
+	// Look each height up through the height index (lowest height first)
+	summaries := make([]blockSummary, 0, end-start+1)
+	for height := start; height <= end; height++ {
+		hash, err := chain.GetBlockHashByHeight(height)
+		if errors.Is(err, blockchain.ErrHeightNotFound) {
+			break // Past the tip
+		}
+		blockchain.Handle(err)
+
+		block, err := chain.GetBlock(hash)
+		blockchain.Handle(err)
+
+		summaries = append(summaries, blockSummary{
+			Height:    block.Height,
+			Hash:      hex.EncodeToString(block.Hash),
+			Timestamp: block.Timestamp,
+			TxCount:   len(block.Transactions),
+		})
+	}
+
+	output, err := json.MarshalIndent(summaries, "", "  ")
+	blockchain.Handle(err)
+	fmt.Println(string(output))
+}
+
+// blockDetail is the JSON shape of a block in printblock output
+type blockDetail struct {
+	Height       int      `json:"height"`
+	Hash         string   `json:"hash"`
+	PrevHash     string   `json:"prevHash"`
+	Timestamp    int64    `json:"timestamp"`
+	Nonce        int      `json:"nonce"`
+	PoWValid     bool     `json:"powValid"`
+	Transactions []string `json:"transactions"` // Transaction IDs (hex)
+}
+
+// printBlock prints the block with the given hash, or the active-chain block at height
+// when blockHash is empty
+func (cli *CommandLine) printBlock(nodeID, blockHash string, height int, asJSON bool) {
+	var hash []byte
+	if blockHash != "" {
+		var err error
+		if hash, err = hex.DecodeString(blockHash); err != nil {
+			fmt.Println("Error: invalid block hash:", err)
+			return
+		}
+	}
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	defer func(Database *badger.DB) {
+		err := Database.Close()
+		if err != nil {
+			logger.Error("Could not close the database", "err", err)
+		}
+	}(chain.Database)
+
+	var block blockchain.Block
+	var err error
+	if hash != nil {
+		block, err = chain.GetBlock(hash)
+		if err != nil {
+			fmt.Printf("Error: block %s not found\n", blockHash)
+			return
+		}
+	} else {
+		block, err = chain.GetBlockByHeight(height)
+		if errors.Is(err, blockchain.ErrHeightNotFound) {
+			fmt.Printf("Error: no block at height %d, the tip is at %d\n", height, chain.GetBestHeight())
+			return
+		} else if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+	}
+	powValid := chain.ConsensusEngine().Verify(&block)
+
+	if asJSON {
+		detail := blockDetail{
+			Height:       block.Height,
+			Hash:         hex.EncodeToString(block.Hash),
+			PrevHash:     hex.EncodeToString(block.PrevHash),
+			Timestamp:    block.Timestamp,
+			Nonce:        block.Nonce,
+			PoWValid:     powValid,
+			Transactions: make([]string, 0, len(block.Transactions)),
+		}
+		for _, tx := range block.Transactions {
+			detail.Transactions = append(detail.Transactions, hex.EncodeToString(tx.ID))
+		}
+
+		output, err := json.MarshalIndent(detail, "", "  ")
+		blockchain.Handle(err)
+		fmt.Println(string(output))
+		return
+	}
+
+	fmt.Printf("Height: %d\n", block.Height)
+	fmt.Printf("Hash: %x\n", block.Hash)
+	fmt.Printf("Prev. hash: %x\n", block.PrevHash)
+	fmt.Printf("Timestamp: %s\n", time.Unix(block.Timestamp, 0).UTC().Format(time.RFC3339))
+	fmt.Printf("Nonce: %d\n", block.Nonce)
+	fmt.Printf("PoW: %s\n", strconv.FormatBool(powValid))
+	for _, tx := range block.Transactions {
+		fmt.Printf("Transaction: %s\n", tx)
+	}
+}
+
+// txInputDetail and txOutputDetail are the JSON shapes of a transaction's inputs and outputs
+type txInputDetail struct {
+	TxID   string `json:"txid"`
+	Out    int    `json:"out"`
+	PubKey string `json:"pubKey"`
+}
+
+type txOutputDetail struct {
+	Value      int    `json:"value"`
+	PubKeyHash string `json:"pubKeyHash"`
+	Data       string `json:"data,omitempty"` // Payload of a data output (hex)
+}
+
+// txDetail is the JSON shape of a transaction in gettransaction output
+type txDetail struct {
+	ID            string           `json:"id"`
+	BlockHash     string           `json:"blockHash"`
+	BlockHeight   int              `json:"blockHeight"`
+	Confirmations int              `json:"confirmations"`
+	Coinbase      bool             `json:"coinbase"`
+	Inputs        []txInputDetail  `json:"inputs"`
+	Outputs       []txOutputDetail `json:"outputs"`
+}
+
+func (cli *CommandLine) getTransaction(nodeID, txID string, asJSON bool) {
+	id, err := hex.DecodeString(txID)
+	if err != nil {
+		fmt.Println("Error: invalid transaction ID:", err)
+		return
+	}
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	defer func(Database *badger.DB) {
+		err := Database.Close()
+		if err != nil {
+			logger.Error("Could not close the database", "err", err)
+		}
+	}(chain.Database)
+
+	confirmations, err := chain.Confirmations(id)
+	if err != nil {
+		fmt.Printf("Error: transaction %s: %v\n", txID, err)
+		return
+	}
+	if confirmations == 0 {
+		fmt.Printf("Transaction %s is unconfirmed: it is waiting in the memory pool\n", txID)
+		return
+	}
+
+	tx, block, err := chain.FindTransactionBlock(id)
+	if err != nil {
+		fmt.Printf("Error: transaction %s: %v\n", txID, err)
+		return
+	}
+
+	if asJSON {
+		detail := txDetail{
+			ID:            hex.EncodeToString(tx.ID),
+			BlockHash:     hex.EncodeToString(block.Hash),
+			BlockHeight:   block.Height,
+			Confirmations: confirmations,
+			Coinbase:      tx.IsCoinbase(),
+			Inputs:        make([]txInputDetail, 0, len(tx.Inputs)),
+			Outputs:       make([]txOutputDetail, 0, len(tx.Outputs)),
+		}
+		for _, in := range tx.Inputs {
+			detail.Inputs = append(detail.Inputs, txInputDetail{
+				TxID:   hex.EncodeToString(in.ID),
+				Out:    in.Out,
+				PubKey: hex.EncodeToString(in.PubKey),
+			})
+		}
+		for _, out := range tx.Outputs {
+			detail.Outputs = append(detail.Outputs, txOutputDetail{
+				Value:      out.Value,
+				PubKeyHash: hex.EncodeToString(out.PubKeyHash),
+				Data:       hex.EncodeToString(out.Payload()),
+			})
+		}
+
+		output, err := json.MarshalIndent(detail, "", "  ")
+		blockchain.Handle(err)
+		fmt.Println(string(output))
+		return
+	}
+
+	fmt.Printf("Block: %x (height %d, %d confirmations)\n", block.Hash, block.Height, confirmations)
+	fmt.Println(tx)
+}
+
+func (cli *CommandLine) verifyBlock(nodeID, blockHash string) {
+	hash, err := hex.DecodeString(blockHash)
+	if err != nil {
+		fmt.Println("Error: invalid block hash:", err)
+		return
+	}
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	defer func(Database *badger.DB) {
+		err := Database.Close()
+		if err != nil {
+			logger.Error("Could not close the database", "err", err)
+		}
+	}(chain.Database)
+
+	block, err := chain.GetBlock(hash)
+	if err != nil {
+		fmt.Printf("Error: block %s: %v\n", blockHash, err)
+		return
+	}
+
+	// Report every rule, so a bad block shows exactly what is wrong with it
+	valid := true
+	for _, check := range chain.VerifyBlock(&block) {
+		if check.Err != nil {
+			valid = false
+			fmt.Printf("FAIL %s: %v\n", check.Rule, check.Err)
+		} else {
+			fmt.Printf("ok   %s\n", check.Rule)
+		}
+	}
+
+	if valid {
+		fmt.Printf("Block %x at height %d is valid\n", block.Hash, block.Height)
+	} else {
+		fmt.Printf("Block %x at height %d is INVALID\n", block.Hash, block.Height)
+	}
+}
+
+func (cli *CommandLine) verifyChain(nodeID string) {
+	// Read-only and without ContinueBlockChain's index rebuilds, so the damage is reported
+	// as found rather than panicked on or papered over
+	chain, err := blockchain.OpenBlockChainReadOnly(nodeID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	defer func(Database *badger.DB) {
+		err := Database.Close()
+		if err != nil {
+			logger.Error("Could not close the database", "err", err)
+		}
+	}(chain.Database)
+
+	err = chain.VerifyIntegrity()
+	switch {
+	case errors.Is(err, blockchain.ErrCorruptHeightIndex) && chain.IsPruned():
+		fmt.Println("Error:", err)
+		fmt.Println("The blocks are intact, but the chain is pruned so reindexutxo can't rebuild the indexes; delete the database and sync from a peer")
+	case errors.Is(err, blockchain.ErrCorruptHeightIndex):
+		fmt.Println("Error:", err)
+		fmt.Println("The blocks are intact; run reindexutxo to rebuild the indexes")
+	case err != nil:
+		fmt.Println("Error:", err)
+		fmt.Println("Restore the block from an exportchain file, or delete the database and sync from a peer")
+	default:
+		fmt.Println("Chain is intact")
+	}
+}
+
 func (cli *CommandLine) reindexUTXO(nodeID string) {
 	chain := blockchain.ContinueBlockChain(nodeID)
 	defer func(Database *badger.DB) {
 		err := Database.Close()
 		if err != nil {
-			fmt.Println(err)
+			logger.Error("Could not close the database", "err", err)
 		}
 	}(chain.Database)
 
+	if chain.IsPruned() {
+		fmt.Println("Error:", blockchain.ErrChainPruned, "- the UTXO set can't be rebuilt")
+		return
+	}
+
+	// The height index goes first: the UTXO scan looks blocks up through it
+	chain.ReindexHeights()
 	UTXOSet := blockchain.UTXOSet{Blockchain: chain}
-	UTXOSet.Reindex()
+	if err := UTXOSet.Reindex(); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	chain.ReindexTransactions()
 
 	count := UTXOSet.CountTransactions()
 	fmt.Printf("Done! There are %d transactions in the UTXO set.\n", count)
 }
 
+func (cli *CommandLine) exportChain(nodeID, path string) {
+	chain := blockchain.ContinueBlockChain(nodeID)
+	defer chain.Database.Close()
+
+	file, err := os.Create(path)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	writer := bufio.NewWriter(file)
+
+	err = chain.Export(writer)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("Exported %d blocks to %s\n", chain.GetBestHeight()+1, path)
+}
+
+func (cli *CommandLine) importChain(nodeID, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	defer file.Close()
+
+	if err := blockchain.ImportChain(nodeID, bufio.NewReader(file)); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("Imported the blockchain from %s\n", path)
+}
+
+func (cli *CommandLine) pruneChain(nodeID string, keep int) {
+	chain := blockchain.ContinueBlockChain(nodeID)
+	defer chain.Database.Close()
+
+	if err := chain.Prune(keep); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// showMempool prints the transactions waiting in the memory pool of the node running as nodeID
+func (cli *CommandLine) showMempool(nodeID string) {
+	address := fmt.Sprintf("localhost:%s", nodeID)
+	entries, err := network.QueryMempool(address)
+	if errors.Is(err, network.ErrNodeNotRunning) {
+		fmt.Printf("Error: no node is running on %s, start it with startnode first\n", address)
+		return
+	} else if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	fmt.Printf("%d transaction(s) in the memory pool of %s\n", len(entries), address)
+	for _, entry := range entries {
+		fmt.Printf("%x  fee %s  size %d B  inputs %d  outputs %d\n",
+			entry.TxID, blockchain.Params.FormatAmount(entry.Fee), entry.Size, entry.Inputs, entry.Outputs)
+	}
+}
+
+// estimateFee prints the fee per byte likely to get a transaction mined within blocks
+// blocks, judged from the memory pool of the node running as nodeID
+func (cli *CommandLine) estimateFee(nodeID string, blocks int) {
+	address := fmt.Sprintf("localhost:%s", nodeID)
+	entries, err := network.QueryMempool(address)
+	if errors.Is(err, network.ErrNodeNotRunning) {
+		fmt.Printf("Error: no node is running on %s, start it with startnode first\n", address)
+		return
+	} else if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	pending := make([]blockchain.PendingFee, 0, len(entries))
+	backlog := 0
+	for _, entry := range entries {
+		pending = append(pending, blockchain.PendingFee{Fee: entry.Fee, Size: entry.Size})
+		backlog += entry.Size
+	}
+
+	rate := blockchain.EstimateBacklogFee(pending, blocks)
+	fmt.Printf("Memory pool: %d transaction(s), %d B (blocks hold up to %d B)\n", len(entries), backlog, blockchain.MaxBlockSize)
+	fmt.Printf("Suggested fee to be mined within %d block(s): %.2f per byte\n", blocks, rate)
+}
+
 func (cli *CommandLine) listAddresses(nodeID string) {
-	wallets, _ := wallet.CreateWallets(nodeID)
+	wallets, err := wallet.CreateWallets(nodeID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
 	addresses := wallets.GetAllAddresses()
 
+	labels, err := wallet.LoadLabels(nodeID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
 	for _, address := range addresses {
-		fmt.Println(address)
+		if names := labels.LabelsOf(address); len(names) > 0 {
+			fmt.Printf("%s (%s)\n", address, strings.Join(names, ", "))
+		} else {
+			fmt.Println(address)
+		}
+	}
+}
+
+func (cli *CommandLine) setLabel(address, label, nodeID string) {
+	labels, err := wallet.LoadLabels(nodeID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	if err := labels.Set(label, address); err != nil {
+		fmt.Println("Error:", err)
+		return
 	}
+	if err := labels.Save(nodeID); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("Labeled %s as %s\n", address, label)
 }
 
 func (cli *CommandLine) createWallet(nodeID string) {
-	wallets, _ := wallet.CreateWallets(nodeID)
+	wallets, err := wallet.CreateWallets(nodeID)
+	if errors.Is(err, wallet.ErrCorruptWalletFile) {
+		// Keep the unreadable file (it may hold recoverable keys) and start a fresh one
+		backupPath, backupErr := wallet.BackupFile(nodeID)
+		if backupErr != nil {
+			fmt.Println("Error:", err)
+			fmt.Println("Could not back up the wallet file, refusing to overwrite it:", backupErr)
+			return
+		}
+		fmt.Println("Warning:", err)
+		fmt.Printf("The old wallet file was moved to %s\n", backupPath)
+	} else if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
 	address := wallets.AddWallet()
 	wallets.SaveFile(nodeID)
 	fmt.Printf("New wallet created with address: %s\n", address)
 }
 
-func (cli *CommandLine) Run() {
-	cli.validateArgs()
+func (cli *CommandLine) exportKey(address, nodeID string) {
+	wallets, err := wallet.CreateWallets(nodeID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
 
-	nodeID := os.Getenv("NODE_ID")
-	if nodeID == "" {
-		fmt.Printf("NODE_ID env is not set!")
-		runtime.Goexit()
+	w, err := wallets.GetWallet(address)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Println(w.ExportKey())
+}
+
+func (cli *CommandLine) importKey(key, nodeID string) {
+	w, err := wallet.ImportKey(key)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	wallets, err := wallet.CreateWallets(nodeID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
 	}
+	address := wallets.ImportWallet(w)
+	wallets.SaveFile(nodeID)
+	fmt.Printf("Imported wallet with address: %s\n", address)
+}
+
+func (cli *CommandLine) Run() {
+	cli.validateArgs()
 
 	// Parse the command line arguments
 	getBalanceCMD := flag.NewFlagSet("getbalance", flag.ExitOnError)
 	createBlockChainCMD := flag.NewFlagSet("createblockchain", flag.ExitOnError)
 	sendCMD := flag.NewFlagSet("send", flag.ExitOnError)
+	buildTxCMD := flag.NewFlagSet("buildtx", flag.ExitOnError)
+	signTxCMD := flag.NewFlagSet("signtx", flag.ExitOnError)
+	broadcastTxCMD := flag.NewFlagSet("broadcasttx", flag.ExitOnError)
 	printChainCMD := flag.NewFlagSet("printchain", flag.ExitOnError)
 	createWalletCMD := flag.NewFlagSet("createwallet", flag.ExitOnError)
 	listAddressesCMD := flag.NewFlagSet("listaddresses", flag.ExitOnError)
 	reindexUTXOCMD := flag.NewFlagSet("reindexutxo", flag.ExitOnError)
+	listBlocksCMD := flag.NewFlagSet("listblocks", flag.ExitOnError)
+	verifyBlockCMD := flag.NewFlagSet("verifyblock", flag.ExitOnError)
+	verifyChainCMD := flag.NewFlagSet("verifychain", flag.ExitOnError)
+	printBlockCMD := flag.NewFlagSet("printblock", flag.ExitOnError)
+	getTransactionCMD := flag.NewFlagSet("gettransaction", flag.ExitOnError)
+	exportKeyCMD := flag.NewFlagSet("exportkey", flag.ExitOnError)
+	importKeyCMD := flag.NewFlagSet("importkey", flag.ExitOnError)
+	exportChainCMD := flag.NewFlagSet("exportchain", flag.ExitOnError)
+	importChainCMD := flag.NewFlagSet("importchain", flag.ExitOnError)
+	pruneChainCMD := flag.NewFlagSet("prunechain", flag.ExitOnError)
+	mempoolCMD := flag.NewFlagSet("mempool", flag.ExitOnError)
+	estimateFeeCMD := flag.NewFlagSet("estimatefee", flag.ExitOnError)
+	setLabelCMD := flag.NewFlagSet("setlabel", flag.ExitOnError)
+	sendBatchCMD := flag.NewFlagSet("sendbatch", flag.ExitOnError)
 	startNodeCMD := flag.NewFlagSet("startnode", flag.ExitOnError)
 
 	getBalanceAddress := getBalanceCMD.String("address", "", "Wallet address to get the balance of")
 	createBlockChainAddress := createBlockChainCMD.String("address", "", "Wallet address to create the blockchain for")
+	createBlockChainPremine := createBlockChainCMD.String("premine", "", "JSON file of genesis allocations (address -> amount)")
+	createBlockChainDifficulty := createBlockChainCMD.Int("difficulty", blockchain.Difficulty, "Proof-of-work difficulty (leading zero bits) of every block")
 	sendFrom := sendCMD.String("from", "", "Source wallet address")
 	sendTo := sendCMD.String("to", "", "Destination wallet address")
 	sendAmount := sendCMD.Int("amount", 0, "Amount to send")
 	sendMine := sendCMD.Bool("mine", false, "Mine immediately no the same node")
+	sendFee := sendCMD.Int("fee", 0, "Fee left for the miner, taken from the change")
+	sendData := sendCMD.String("data", "", fmt.Sprintf("Text (up to %d bytes) to embed in an unspendable output", blockchain.MaxDataOutputSize))
+	sendDryRun := sendCMD.Bool("dryrun", false, "Print the signed transaction instead of mining or broadcasting it")
+	buildTxFrom := buildTxCMD.String("from", "", "Source wallet address")
+	buildTxTo := buildTxCMD.String("to", "", "Destination wallet address")
+	buildTxAmount := buildTxCMD.Int("amount", 0, "Amount to send")
+	buildTxFee := buildTxCMD.Int("fee", 0, "Fee left for the miner, taken from the change")
+	buildTxOut := buildTxCMD.String("out", "unsigned.tx", "File to write the unsigned transaction to")
+	signTxFile := signTxCMD.String("file", "", "Unsigned transaction written by buildtx")
+	signTxOut := signTxCMD.String("out", "signed.tx", "File to write the signed transaction to")
+	broadcastTxFile := broadcastTxCMD.String("file", "", "Signed transaction written by signtx")
 	startNodeMiner := startNodeCMD.String("miner", "", "Enable mining mode and send reward to ADDRESS")
+	startNodeHTTP := startNodeCMD.String("http", "", "Also serve the JSON HTTP API on PORT")
+	startNodeTag := startNodeCMD.String("tag", "", fmt.Sprintf("Tag (up to %d bytes) put in the coinbase of every block mined", blockchain.MaxMinerTagSize))
+	listBlocksStart := listBlocksCMD.Int("start", 0, "First block height to list")
+	listBlocksEnd := listBlocksCMD.Int("end", -1, "Last block height to list (defaults to the tip)")
+	verifyBlockHash := verifyBlockCMD.String("hash", "", "Hash (hex) of the block to verify")
+	printBlockHash := printBlockCMD.String("hash", "", "Hash (hex) of the block to print")
+	printBlockHeight := printBlockCMD.Int("height", -1, "Height of the active-chain block to print, instead of -hash")
+	printBlockJSON := printBlockCMD.Bool("json", false, "Print the block as JSON")
+	getTransactionID := getTransactionCMD.String("id", "", "ID (hex) of the transaction to print")
+	getTransactionJSON := getTransactionCMD.Bool("json", false, "Print the transaction as JSON")
+	exportKeyAddress := exportKeyCMD.String("address", "", "Wallet address to export the private key of")
+	importKeyKey := importKeyCMD.String("key", "", "Private key printed by exportkey")
+	exportChainFile := exportChainCMD.String("file", "", "File to write the blocks to")
+	importChainFile := importChainCMD.String("file", "", "File written by exportchain")
+	pruneChainKeep := pruneChainCMD.Int("keep", 0, "Number of recent blocks to leave intact")
+	sendBatchFile := sendBatchCMD.String("file", "", "JSON file listing the payments")
+	sendBatchMine := sendBatchCMD.Bool("mine", false, "Mine all payments into one block on this node")
+	setLabelAddress := setLabelCMD.String("address", "", "Address to name")
+	setLabelLabel := setLabelCMD.String("label", "", "Name for the address")
+	estimateFeeBlocks := estimateFeeCMD.Int("blocks", 1, "Number of blocks the transaction may wait")
+
+	// Every command can be pointed at a node without touching the environment
+	commands := []*flag.FlagSet{getBalanceCMD, createBlockChainCMD, sendCMD, printChainCMD,
+		createWalletCMD, listAddressesCMD, reindexUTXOCMD, startNodeCMD, listBlocksCMD, verifyBlockCMD,
+		exportKeyCMD, importKeyCMD, printBlockCMD, getTransactionCMD, exportChainCMD, importChainCMD, pruneChainCMD,
+		mempoolCMD, setLabelCMD, sendBatchCMD, verifyChainCMD, estimateFeeCMD, buildTxCMD, signTxCMD, broadcastTxCMD}
+	nodeIDFlags := make(map[*flag.FlagSet]*string)
+	configFlags := make(map[*flag.FlagSet]*string)
+	for _, cmd := range commands {
+		nodeIDFlags[cmd] = cmd.String("nodeid", "", "Node ID to use (overrides the NODE_ID env. var.)")
+		configFlags[cmd] = cmd.String("config", defaultConfigFile, "Config file providing NODE_ID when neither -nodeid nor the env. var. is set")
+	}
 
 	switch os.Args[1] {
 	case "getbalance":
@@ -235,6 +1144,15 @@ func (cli *CommandLine) Run() {
 	case "send":
 		err := sendCMD.Parse(os.Args[2:])
 		blockchain.Handle(err)
+	case "buildtx":
+		err := buildTxCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
+	case "signtx":
+		err := signTxCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
+	case "broadcasttx":
+		err := broadcastTxCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
 	case "printchain":
 		err := printChainCMD.Parse(os.Args[2:])
 		blockchain.Handle(err)
@@ -250,11 +1168,66 @@ func (cli *CommandLine) Run() {
 	case "startnode":
 		err := startNodeCMD.Parse(os.Args[2:])
 		blockchain.Handle(err)
+	case "listblocks":
+		err := listBlocksCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
+	case "verifyblock":
+		err := verifyBlockCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
+	case "verifychain":
+		err := verifyChainCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
+	case "printblock":
+		err := printBlockCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
+	case "gettransaction":
+		err := getTransactionCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
+	case "exportkey":
+		err := exportKeyCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
+	case "importkey":
+		err := importKeyCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
+	case "exportchain":
+		err := exportChainCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
+	case "importchain":
+		err := importChainCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
+	case "prunechain":
+		err := pruneChainCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
+	case "mempool":
+		err := mempoolCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
+	case "estimatefee":
+		err := estimateFeeCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
+	case "setlabel":
+		err := setLabelCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
+	case "sendbatch":
+		err := sendBatchCMD.Parse(os.Args[2:])
+		blockchain.Handle(err)
 	default:
 		cli.printUsage()
 		runtime.Goexit()
 	}
 
+	// Resolve the node ID for whichever command was parsed
+	var nodeID string
+	for _, cmd := range commands {
+		if cmd.Parsed() {
+			var err error
+			nodeID, err = resolveNodeID(*nodeIDFlags[cmd], *configFlags[cmd])
+			if err != nil {
+				fmt.Println("Error:", err)
+				runtime.Goexit()
+			}
+		}
+	}
+
 	if getBalanceCMD.Parsed() {
 		if *getBalanceAddress == "" {
 			getBalanceCMD.Usage()
@@ -264,11 +1237,11 @@ func (cli *CommandLine) Run() {
 	}
 
 	if createBlockChainCMD.Parsed() {
-		if *createBlockChainAddress == "" {
+		if *createBlockChainAddress == "" && *createBlockChainPremine == "" {
 			createBlockChainCMD.Usage()
 			runtime.Goexit()
 		}
-		cli.createBlockChain(*createBlockChainAddress, nodeID)
+		cli.createBlockChain(*createBlockChainAddress, *createBlockChainPremine, *createBlockChainDifficulty, nodeID)
 	}
 
 	if printChainCMD.Parsed() {
@@ -283,24 +1256,144 @@ func (cli *CommandLine) Run() {
 		cli.listAddresses(nodeID)
 	}
 
+	if listBlocksCMD.Parsed() {
+		cli.listBlocks(nodeID, *listBlocksStart, *listBlocksEnd)
+	}
+
+	if verifyBlockCMD.Parsed() {
+		if *verifyBlockHash == "" {
+			verifyBlockCMD.Usage()
+			runtime.Goexit()
+		}
+		cli.verifyBlock(nodeID, *verifyBlockHash)
+	}
+
+	if verifyChainCMD.Parsed() {
+		cli.verifyChain(nodeID)
+	}
+
+	if printBlockCMD.Parsed() {
+		// Exactly one of -hash and -height
+		if (*printBlockHash == "") == (*printBlockHeight < 0) {
+			printBlockCMD.Usage()
+			runtime.Goexit()
+		}
+		cli.printBlock(nodeID, *printBlockHash, *printBlockHeight, *printBlockJSON)
+	}
+
+	if getTransactionCMD.Parsed() {
+		if *getTransactionID == "" {
+			getTransactionCMD.Usage()
+			runtime.Goexit()
+		}
+		cli.getTransaction(nodeID, *getTransactionID, *getTransactionJSON)
+	}
+
+	if exportKeyCMD.Parsed() {
+		if *exportKeyAddress == "" {
+			exportKeyCMD.Usage()
+			runtime.Goexit()
+		}
+		cli.exportKey(*exportKeyAddress, nodeID)
+	}
+
+	if importKeyCMD.Parsed() {
+		if *importKeyKey == "" {
+			importKeyCMD.Usage()
+			runtime.Goexit()
+		}
+		cli.importKey(*importKeyKey, nodeID)
+	}
+
+	if exportChainCMD.Parsed() {
+		if *exportChainFile == "" {
+			exportChainCMD.Usage()
+			runtime.Goexit()
+		}
+		cli.exportChain(nodeID, *exportChainFile)
+	}
+
+	if importChainCMD.Parsed() {
+		if *importChainFile == "" {
+			importChainCMD.Usage()
+			runtime.Goexit()
+		}
+		cli.importChain(nodeID, *importChainFile)
+	}
+
+	if pruneChainCMD.Parsed() {
+		if *pruneChainKeep <= 0 {
+			pruneChainCMD.Usage()
+			runtime.Goexit()
+		}
+		cli.pruneChain(nodeID, *pruneChainKeep)
+	}
+
+	if setLabelCMD.Parsed() {
+		if *setLabelAddress == "" || *setLabelLabel == "" {
+			setLabelCMD.Usage()
+			runtime.Goexit()
+		}
+		cli.setLabel(*setLabelAddress, *setLabelLabel, nodeID)
+	}
+
+	if mempoolCMD.Parsed() {
+		cli.showMempool(nodeID)
+	}
+
+	if estimateFeeCMD.Parsed() {
+		if *estimateFeeBlocks < 1 {
+			estimateFeeCMD.Usage()
+			runtime.Goexit()
+		}
+		cli.estimateFee(nodeID, *estimateFeeBlocks)
+	}
+
 	if reindexUTXOCMD.Parsed() {
 		cli.reindexUTXO(nodeID)
 	}
 
 	if sendCMD.Parsed() {
-		if *sendFrom == "" || *sendTo == "" || *sendAmount <= 0 {
+		if *sendFrom == "" || *sendTo == "" || *sendAmount <= 0 || *sendFee < 0 {
 			sendCMD.Usage()
 			runtime.Goexit()
 		}
-		cli.send(*sendFrom, *sendTo, *sendAmount, nodeID, *sendMine)
+		cli.send(*sendFrom, *sendTo, *sendAmount, *sendFee, *sendData, nodeID, *sendMine, *sendDryRun)
 	}
 
-	if startNodeCMD.Parsed() {
-		nID := os.Getenv("NODE_ID")
-		if nID == "" {
-			startNodeCMD.Usage()
+	if buildTxCMD.Parsed() {
+		if *buildTxFrom == "" || *buildTxTo == "" || *buildTxAmount <= 0 || *buildTxFee < 0 || *buildTxOut == "" {
+			buildTxCMD.Usage()
 			runtime.Goexit()
 		}
-		cli.StartNode(nID, *startNodeMiner)
+		cli.buildTx(*buildTxFrom, *buildTxTo, *buildTxAmount, *buildTxFee, *buildTxOut, nodeID)
+	}
+
+	if signTxCMD.Parsed() {
+		if *signTxFile == "" || *signTxOut == "" {
+			signTxCMD.Usage()
+			runtime.Goexit()
+		}
+		cli.signTx(*signTxFile, *signTxOut, nodeID)
+	}
+
+	if broadcastTxCMD.Parsed() {
+		if *broadcastTxFile == "" {
+			broadcastTxCMD.Usage()
+			runtime.Goexit()
+		}
+		cli.broadcastTx(*broadcastTxFile)
+	}
+
+	if sendBatchCMD.Parsed() {
+		if *sendBatchFile == "" {
+			sendBatchCMD.Usage()
+			runtime.Goexit()
+		}
+		cli.sendBatch(*sendBatchFile, nodeID, *sendBatchMine)
+	}
+
+	if startNodeCMD.Parsed() {
+		cli.StartNode(nodeID, *startNodeMiner, *startNodeTag, *startNodeHTTP)
 	}
 }
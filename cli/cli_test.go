@@ -0,0 +1,281 @@
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/golang-blockchain/blockchain"
+	"github.com/golang-blockchain/network"
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 15:30
+ */
+
+// inDataDir points the node's data directory at a fresh temporary one until the test ends
+func inDataDir(t *testing.T) string {
+	t.Helper()
+
+	dataDir := wallet.DataDir
+	wallet.DataDir = t.TempDir()
+	t.Cleanup(func() { wallet.DataDir = dataDir })
+	return wallet.DataDir
+}
+
+// newTestChain creates nodeID's chain in a fresh data directory, its genesis paying each
+// wallet its allocation, mines blocks empty blocks on top and closes it for the commands to open
+// Coinbases mature at once. Returns the chain's blocks, genesis first
+func newTestChain(t *testing.T, nodeID string, allocations map[*wallet.Wallet]int, blocks int) []*blockchain.Block {
+	t.Helper()
+
+	inDataDir(t)
+	maturity := blockchain.CoinbaseMaturity
+	blockchain.CoinbaseMaturity = 0
+	t.Cleanup(func() { blockchain.CoinbaseMaturity = maturity })
+
+	addresses := make(map[string]int)
+	for w, amount := range allocations {
+		addresses[string(w.Address())] = amount
+	}
+	chain, err := blockchain.InitBlockChainWithGenesis(addresses, blockchain.MinDifficulty, nodeID)
+	if err != nil {
+		t.Fatalf("create chain: %v", err)
+	}
+	defer chain.Database.Close()
+
+	genesis, err := chain.GetBlock(chain.LastHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mined := []*blockchain.Block{&genesis}
+	miner := wallet.MakeWallet()
+	for i := 0; i < blocks; i++ {
+		coinbase := blockchain.CoinbaseTx(string(miner.Address()), "", chain.GetBestHeight()+1, 0)
+		mined = append(mined, chain.MineBlock([]*blockchain.Transaction{coinbase}))
+	}
+	if err := (blockchain.UTXOSet{Blockchain: chain}).Reindex(); err != nil {
+		t.Fatalf("index the UTXO set: %v", err)
+	}
+	return mined
+}
+
+// captureOutput returns what fn prints to standard output
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+	fn()
+	w.Close()
+	return <-output
+}
+
+func TestResolveNodeID(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "node.conf")
+	if err := os.WriteFile(config, []byte("# test node\nNODE_ID = 3002\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("NODE_ID", "3001")
+	if nodeID, err := resolveNodeID("3000", config); err != nil || nodeID != "3000" {
+		t.Errorf("with a flag and the env. var. = %q, %v; want the flag's 3000", nodeID, err)
+	}
+	if nodeID, err := resolveNodeID("", config); err != nil || nodeID != "3001" {
+		t.Errorf("with the env. var. = %q, %v; want 3001", nodeID, err)
+	}
+
+	t.Setenv("NODE_ID", "")
+	if nodeID, err := resolveNodeID("", config); err != nil || nodeID != "3002" {
+		t.Errorf("with only the config file = %q, %v; want 3002", nodeID, err)
+	}
+
+	// Neither: the error says how to set one
+	_, err := resolveNodeID("", "")
+	if !errors.Is(err, errNodeIDNotSet) {
+		t.Fatalf("with nothing set = %v, want errNodeIDNotSet", err)
+	}
+	for _, hint := range []string{"-nodeid", "NODE_ID"} {
+		if !strings.Contains(err.Error(), hint) {
+			t.Errorf("error %q does not mention %s", err, hint)
+		}
+	}
+
+	// A config file passed explicitly must exist
+	if _, err := resolveNodeID("", filepath.Join(t.TempDir(), "missing.conf")); err == nil || errors.Is(err, errNodeIDNotSet) {
+		t.Errorf("with a missing config file = %v, want the read error", err)
+	}
+}
+
+func TestCreateWalletBacksUpACorruptWalletFile(t *testing.T) {
+	dir := inDataDir(t)
+	t.Setenv("WALLET_PASSPHRASE", "")
+	const nodeID = "3000"
+	walletPath := wallet.DataPath("wallets_" + nodeID + ".data")
+	corrupt := []byte("not a wallet file")
+	if err := os.WriteFile(walletPath, corrupt, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cli := CommandLine{}
+	cli.createWallet(nodeID)
+
+	// The unreadable file is kept, byte for byte, next to the new one
+	backups, err := filepath.Glob(walletPath + ".corrupt-*")
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("backups of the corrupt file: %v, %v; want one", backups, err)
+	}
+	if kept, err := os.ReadFile(backups[0]); err != nil || string(kept) != string(corrupt) {
+		t.Errorf("backup %s holds %q, %v; want the corrupt file's contents", backups[0], kept, err)
+	}
+
+	wallets, err := wallet.CreateWallets(nodeID)
+	if err != nil {
+		t.Fatalf("the new wallet file in %s does not load: %v", dir, err)
+	}
+	if addresses := wallets.GetAllAddresses(); len(addresses) != 1 {
+		t.Errorf("the new wallet file holds %d wallets, want 1", len(addresses))
+	}
+}
+
+func TestListBlocksFollowsTheChainInHeightOrder(t *testing.T) {
+	const nodeID = "3000"
+	blocks := newTestChain(t, nodeID, map[*wallet.Wallet]int{wallet.MakeWallet(): 100}, 5)
+	cli := CommandLine{}
+
+	cases := []struct {
+		start, end int
+		want       []*blockchain.Block
+	}{
+		{0, -1, blocks},     // Up to the tip
+		{2, 4, blocks[2:5]}, // Inside the chain
+		{3, 10, blocks[3:]}, // Stopping at the tip
+		{5, 5, blocks[5:]},  // The tip alone
+	}
+	for _, c := range cases {
+		output := captureOutput(t, func() { cli.listBlocks(nodeID, c.start, c.end) })
+		var summaries []blockSummary
+		if err := json.Unmarshal([]byte(output), &summaries); err != nil {
+			t.Fatalf("listblocks %d..%d printed %q: %v", c.start, c.end, output, err)
+		}
+
+		if len(summaries) != len(c.want) {
+			t.Errorf("listblocks %d..%d listed %d blocks, want %d", c.start, c.end, len(summaries), len(c.want))
+			continue
+		}
+		for i, block := range c.want {
+			want := blockSummary{
+				Height:    block.Height,
+				Hash:      hex.EncodeToString(block.Hash),
+				Timestamp: block.Timestamp,
+				TxCount:   len(block.Transactions),
+			}
+			if summaries[i] != want {
+				t.Errorf("listblocks %d..%d entry %d = %+v, want %+v", c.start, c.end, i, summaries[i], want)
+			}
+		}
+	}
+
+	for _, bad := range [][2]int{{-1, 2}, {4, 2}, {0, maxListBlocksRange}} {
+		if output := captureOutput(t, func() { cli.listBlocks(nodeID, bad[0], bad[1]) }); !strings.HasPrefix(output, "Error:") {
+			t.Errorf("listblocks %d..%d printed %q, want an error", bad[0], bad[1], output)
+		}
+	}
+}
+
+// unreachableAddress returns a local address nothing listens on
+func unreachableAddress(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+func TestSendWithNoReachablePeersReportsFailure(t *testing.T) {
+	const nodeID = "3000"
+	t.Setenv("WALLET_PASSPHRASE", "")
+	sender := wallet.MakeWallet()
+	newTestChain(t, nodeID, map[*wallet.Wallet]int{sender: 100}, 0)
+
+	wallets, err := wallet.CreateWallets(nodeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := wallets.ImportWallet(sender)
+	wallets.SaveFile(nodeID)
+
+	nodes := network.KnownNodes
+	network.KnownNodes = []string{unreachableAddress(t), unreachableAddress(t)}
+	t.Cleanup(func() { network.KnownNodes = nodes })
+
+	cli := CommandLine{}
+	to := string(wallet.MakeWallet().Address())
+	output := captureOutput(t, func() { cli.send(from, to, 10, 1, "", nodeID, false, false) })
+	if !strings.Contains(output, "Error: transaction was not sent") {
+		t.Errorf("send printed %q, want a broadcast failure", output)
+	}
+	if strings.Contains(output, "Success!") {
+		t.Errorf("send printed %q, claiming success", output)
+	}
+}
+
+func TestVerifyBlockReportsValidAndPinpointsABadMerkleRoot(t *testing.T) {
+	const nodeID = "3000"
+	blocks := newTestChain(t, nodeID, map[*wallet.Wallet]int{wallet.MakeWallet(): 100}, 2)
+	cli := CommandLine{}
+
+	good := hex.EncodeToString(blocks[1].Hash)
+	output := captureOutput(t, func() { cli.verifyBlock(nodeID, good) })
+	if !strings.Contains(output, "is valid") || strings.Contains(output, "FAIL") {
+		t.Errorf("verifyblock of a good block printed:\n%s", output)
+	}
+
+	// Store block 2 with a coinbase other than the one its hash commits to
+	tampered := *blocks[2]
+	coinbase := blockchain.CoinbaseTx(string(wallet.MakeWallet().Address()), "", tampered.Height, 0)
+	tampered.Transactions = []*blockchain.Transaction{coinbase}
+	chain := blockchain.ContinueBlockChain(nodeID)
+	err := chain.Database.Update(func(txn *badger.Txn) error {
+		return txn.Set(tampered.Hash, tampered.Serialize())
+	})
+	chain.Database.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output = captureOutput(t, func() { cli.verifyBlock(nodeID, hex.EncodeToString(tampered.Hash)) })
+	if !strings.Contains(output, "FAIL merkle root") || !strings.Contains(output, "is INVALID") {
+		t.Errorf("verifyblock of a block with a bad merkle root printed:\n%s", output)
+	}
+	if strings.Contains(output, "FAIL coinbase") || strings.Contains(output, "FAIL previous hash") {
+		t.Errorf("verifyblock blamed rules the block keeps:\n%s", output)
+	}
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 25/12/2025
+ * Time: 16:00
+ */
+
+// NODE LOGGING
+// Diagnostics from the node (network traffic, chain events, recoverable failures) go
+// through one leveled log/slog logger writing key=value lines to stderr. LOG_LEVEL
+// picks the lowest level shown: debug, info (default), warn or error. Command output
+// meant for the user (balances, addresses, JSON) stays on stdout and isn't logged.
+const defaultLevel = slog.LevelInfo
+
+var logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: loadLevel()}))
+
+// loadLevel reads the log level from the LOG_LEVEL env. var.
+// Falls back to info when the variable is unset or names an unknown level
+func loadLevel() slog.Level {
+	value := os.Getenv("LOG_LEVEL")
+	if value == "" {
+		return defaultLevel
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.ToLower(value))); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid LOG_LEVEL %q, using %s\n", value, defaultLevel)
+		return defaultLevel
+	}
+	return level
+}
+
+// Debug logs a message only shown with LOG_LEVEL=debug, e.g. every message received
+func Debug(msg string, args ...any) { logger.Debug(msg, args...) }
+
+// Info logs a normal node event, e.g. a block added to the chain
+func Info(msg string, args ...any) { logger.Info(msg, args...) }
+
+// Warn logs something unexpected the node recovered from, e.g. a bad config value
+func Warn(msg string, args ...any) { logger.Warn(msg, args...) }
+
+// Error logs a failure, e.g. a message that couldn't be decoded
+func Error(msg string, args ...any) { logger.Error(msg, args...) }
+
+// DebugEnabled reports whether debug messages are shown
+// Lets callers skip building expensive debug output, like the mining progress line
+func DebugEnabled() bool {
+	return logger.Enabled(context.Background(), slog.LevelDebug)
+}
@@ -0,0 +1,160 @@
+//go:build !nometrics
+
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 25/12/2025
+ * Time: 14:30
+ */
+
+// NODE METRICS
+// Counters, gauges and one histogram describing the node's health, served by Handler
+// in the Prometheus text exposition format (version 0.0.4). Everything is kept in
+// this package with no third-party client; building with -tags nometrics swaps in
+// no-op versions (see noop.go) so the rest of the node doesn't change.
+//
+//	blockchain_height                 height of the chain tip
+//	mempool_size                      transactions waiting in the memory pool
+//	known_peers                       nodes in the known nodes list
+//	blocks_mined_total                blocks this node has mined
+//	transactions_received_total       transactions received from peers or the API
+//	pow_mining_duration_seconds       time spent finding each proof of work
+
+// miningDurationBuckets are the histogram's upper bounds, in seconds
+var miningDurationBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+var (
+	mu sync.Mutex // Guards every value below
+
+	gauges   = make(map[string]func() float64) // Gauge name -> current value, read on scrape
+	counters = map[string]float64{"blocks_mined_total": 0, "transactions_received_total": 0}
+
+	miningCounts = make([]uint64, len(miningDurationBuckets)) // Observations per bucket (not cumulative)
+	miningCount  uint64
+	miningSum    float64
+)
+
+// help texts for the HELP lines, by metric name
+var help = map[string]string{
+	"blockchain_height":           "Height of the active chain's tip.",
+	"mempool_size":                "Number of transactions in the memory pool.",
+	"known_peers":                 "Number of nodes in the known nodes list.",
+	"blocks_mined_total":          "Blocks mined by this node.",
+	"transactions_received_total": "Transactions received from peers or the HTTP API.",
+	"pow_mining_duration_seconds": "Time spent finding a proof of work.",
+}
+
+// SetChainHeight makes blockchain_height report the value returned by f at scrape time
+func SetChainHeight(f func() int) { setGauge("blockchain_height", f) }
+
+// SetMempoolSize makes mempool_size report the value returned by f at scrape time
+func SetMempoolSize(f func() int) { setGauge("mempool_size", f) }
+
+// SetKnownPeers records the current number of known peers
+func SetKnownPeers(n int) { setGauge("known_peers", func() int { return n }) }
+
+// IncBlocksMined counts a block mined by this node
+func IncBlocksMined() { addCounter("blocks_mined_total") }
+
+// IncTransactionsReceived counts a transaction received from a peer or the API
+func IncTransactionsReceived() { addCounter("transactions_received_total") }
+
+// ObserveMiningDuration records how long one proof of work took to find
+func ObserveMiningDuration(d time.Duration) {
+	seconds := d.Seconds()
+
+	mu.Lock()
+	defer mu.Unlock()
+	miningCount++
+	miningSum += seconds
+	for i, bound := range miningDurationBuckets {
+		if seconds <= bound {
+			miningCounts[i]++
+			break
+		}
+	}
+}
+
+// Handler serves every metric in the Prometheus text format
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		// Read the gauge sources outside the lock; they may query the database
+		mu.Lock()
+		var gaugeNames []string
+		sources := make(map[string]func() float64, len(gauges))
+		for name, f := range gauges {
+			gaugeNames = append(gaugeNames, name)
+			sources[name] = f
+		}
+		mu.Unlock()
+
+		sort.Strings(gaugeNames) // So scrapes list metrics in a stable order
+		for _, name := range gaugeNames {
+			writeMetric(w, name, "gauge", sources[name]())
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		var counterNames []string
+		for name := range counters {
+			counterNames = append(counterNames, name)
+		}
+		sort.Strings(counterNames)
+		for _, name := range counterNames {
+			writeMetric(w, name, "counter", counters[name])
+		}
+		writeMiningHistogram(w)
+	})
+}
+
+// setGauge installs the function read for a gauge
+func setGauge(name string, f func() int) {
+	mu.Lock()
+	defer mu.Unlock()
+	gauges[name] = func() float64 { return float64(f()) }
+}
+
+// addCounter increments a counter by one
+func addCounter(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	counters[name]++
+}
+
+// writeMetric writes the HELP, TYPE and sample lines of a single-valued metric
+func writeMetric(w http.ResponseWriter, name, kind string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, help[name], name, kind, name, formatValue(value))
+}
+
+// writeMiningHistogram writes pow_mining_duration_seconds; the caller holds mu
+func writeMiningHistogram(w http.ResponseWriter) {
+	const name = "pow_mining_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help[name], name)
+
+	var cumulative uint64
+	for i, bound := range miningDurationBuckets {
+		cumulative += miningCounts[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", name, formatValue(bound), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, miningCount)
+	fmt.Fprintf(w, "%s_sum %s\n%s_count %d\n", name, formatValue(miningSum), name, miningCount)
+}
+
+// formatValue renders a sample value the way Prometheus expects
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,30 @@
+//go:build nometrics
+
+package metrics
+
+import (
+	"net/http"
+	"time"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 25/12/2025
+ * Time: 14:30
+ */
+
+// Built with -tags nometrics: every recorder does nothing and /metrics answers 404
+
+func SetChainHeight(f func() int)           {}
+func SetMempoolSize(f func() int)           {}
+func SetKnownPeers(n int)                   {}
+func IncBlocksMined()                       {}
+func IncTransactionsReceived()              {}
+func ObserveMiningDuration(d time.Duration) {}
+
+// Handler reports that metrics were compiled out
+func Handler() http.Handler {
+	return http.NotFoundHandler()
+}
@@ -0,0 +1,138 @@
+package network
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/golang-blockchain/logger"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 20/12/2025
+ * Time: 15:40
+ */
+
+// MESSAGE FRAMING
+// Protocol version 1 sent a bare message and the receiver read until EOF, so a peer
+// could stream without end. From version 2 every message is framed:
+//
+//	[4 bytes big-endian payload length][payload = 12-byte command + gob data]
+//
+// The receiver rejects a declared length above maxMessageSize before allocating anything.
+// Old peers are still understood: a bare message starts with a lowercase command letter,
+// which no allowed length header can (see maxMessageSizeLimit), and is read with the same cap.
+// We only frame what we send to peers that announced version 2 in their "version" message.
+
+const (
+	messageHeaderLength   = 4    // Size of the big-endian length prefix
+	framedProtocolVersion = 2    // First protocol version that understands framing
+	defaultMaxMessageSize = 32   // MiB, overridden by the MAX_MESSAGE_SIZE_MB env. var.
+	maxMessageSizeLimit   = 1024 // MiB; larger limits could make a header look like a command letter
+)
+
+// ErrMessageTooLarge is returned when a peer sends or declares a message above maxMessageSize
+var ErrMessageTooLarge = errors.New("message too large")
+
+var (
+	maxMessageSize = loadMaxMessageSize() // Largest accepted message in bytes
+
+	peerVersionsMu sync.RWMutex
+	peerVersions   = make(map[string]int) // Protocol version each peer announced
+)
+
+// loadMaxMessageSize reads the message size limit from MAX_MESSAGE_SIZE_MB
+// Falls back to the default when the variable is unset, not a number or out of range
+func loadMaxMessageSize() int {
+	limit := defaultMaxMessageSize
+
+	if value := os.Getenv("MAX_MESSAGE_SIZE_MB"); value != "" {
+		mb, err := strconv.Atoi(value)
+		if err != nil || mb < 1 || mb > maxMessageSizeLimit {
+			logger.Warn("Invalid MAX_MESSAGE_SIZE_MB, using the default", "value", value, "maxMiB", maxMessageSizeLimit, "defaultMiB", defaultMaxMessageSize)
+		} else {
+			limit = mb
+		}
+	}
+	return limit << 20 // MiB -> bytes
+}
+
+// setPeerVersion remembers the protocol version a peer announced
+func setPeerVersion(addr string, version int) {
+	peerVersionsMu.Lock()
+	defer peerVersionsMu.Unlock()
+	peerVersions[addr] = version
+}
+
+// peerSupportsFraming reports whether a peer announced a protocol version that reads framed messages
+// Peers we haven't had a "version" message from yet get bare messages, which every version reads
+func peerSupportsFraming(addr string) bool {
+	peerVersionsMu.RLock()
+	defer peerVersionsMu.RUnlock()
+	return peerVersions[addr] >= framedProtocolVersion
+}
+
+// frameMessage prefixes a message with its length
+func frameMessage(data []byte) []byte {
+	framed := make([]byte, messageHeaderLength, messageHeaderLength+len(data))
+	binary.BigEndian.PutUint32(framed, uint32(len(data)))
+	return append(framed, data...)
+}
+
+// frameWriter frames every Write as one message, used to answer framed requests in kind
+type frameWriter struct {
+	w io.Writer
+}
+
+func (fw frameWriter) Write(p []byte) (int, error) {
+	if _, err := fw.w.Write(frameMessage(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// readMessage reads one message, framed or bare, never buffering more than maxMessageSize
+// framed reports which form the peer used, so replies can be sent the same way
+func readMessage(r io.Reader) (data []byte, framed bool, err error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(1)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Bare (version 1) message: read until EOF, but no further than the limit
+	if first[0] >= 'a' && first[0] <= 'z' {
+		data, err = io.ReadAll(io.LimitReader(br, int64(maxMessageSize)+1))
+		if err != nil {
+			return nil, false, err
+		}
+		if len(data) > maxMessageSize {
+			return nil, false, fmt.Errorf("%w: more than %d bytes", ErrMessageTooLarge, maxMessageSize)
+		}
+		return data, false, nil
+	}
+
+	// Framed message: check the declared length before allocating for it
+	var header [messageHeaderLength]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, true, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if uint64(size) > uint64(maxMessageSize) {
+		return nil, true, fmt.Errorf("%w: %d bytes declared, limit is %d", ErrMessageTooLarge, size, maxMessageSize)
+	}
+
+	data = make([]byte, size)
+	if _, err := io.ReadFull(br, data); err != nil {
+		return nil, true, err
+	}
+	return data, true, nil
+}
@@ -0,0 +1,49 @@
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 15:40
+ */
+
+func TestOversizedFrameIsRejectedBeforeAllocating(t *testing.T) {
+	// The header claims 512 MiB; nothing follows, as a peer would only send it once we read on
+	const declared = 512 << 20
+	header := make([]byte, messageHeaderLength)
+	binary.BigEndian.PutUint32(header, declared)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, framed, err := readMessage(bytes.NewReader(header))
+	runtime.ReadMemStats(&after)
+
+	if !errors.Is(err, ErrMessageTooLarge) || !framed {
+		t.Fatalf("readMessage = %v (framed %v), want ErrMessageTooLarge", err, framed)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("rejecting the frame allocated %d bytes", allocated)
+	}
+
+	// A frame within the limit still reads back whole
+	message := append(CmdToBytes("ping"), GobEncode(Ping{AddrFrom: "localhost:1"})...)
+	data, framed, err := readMessage(bytes.NewReader(frameMessage(message)))
+	if err != nil || !framed || !bytes.Equal(data, message) {
+		t.Errorf("readMessage of a small frame = %d bytes, %v (framed %v); want the message back", len(data), err, framed)
+	}
+
+	// A frame cut short is an error, not a shorter message
+	if _, _, err := readMessage(bytes.NewReader(frameMessage(message)[:10])); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("readMessage of a truncated frame = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
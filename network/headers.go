@@ -0,0 +1,104 @@
+package network
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/golang-blockchain/blockchain"
+	"github.com/golang-blockchain/logger"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 15:30
+ */
+
+// HEADER SYNC
+// A light client follows the chain by headers alone (see blockchain/header.go). It sends a
+// "getheaders" message with a block locator, like "getblocks", and the node answers on the
+// same connection with a "headers" message: the serialized headers of the active chain after
+// the last block the two share, oldest first. The client checks them with
+// blockchain.VerifyHeaders and asks again from the last one until a reply comes back short.
+const (
+	maxHeadersPerMessage = 2000 // Most headers sent in answer to one "getheaders"
+	headersQueryTimeout  = 10 * time.Second
+)
+
+// GetHeaders asks a node for the headers after the locator
+type GetHeaders struct {
+	AddrFrom string   // Requestor's address (empty for a light client)
+	Locator  [][]byte // Block locator of the requestor; empty to start from genesis
+}
+
+// Headers answers a GetHeaders
+type Headers struct {
+	AddrFrom string
+	Headers  [][]byte // Serialized blockchain.BlockHeader values, oldest first
+}
+
+// RequestHeaders fetches up to maxHeadersPerMessage headers after locator from the node at address
+// The headers are decoded but not verified; that is up to the caller
+func RequestHeaders(address string, locator [][]byte) ([]blockchain.BlockHeader, error) {
+	conn, err := net.DialTimeout(protocol, address, headersQueryTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w on %s: %v", ErrNodeNotRunning, address, err)
+	}
+	defer conn.Close()
+
+	request := append(CmdToBytes("getheaders"), GobEncode(GetHeaders{AddrFrom: nodeAddress, Locator: locator})...)
+	response, err := exchange(conn, address, request, "headers", headersQueryTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload Headers
+	if err := gob.NewDecoder(bytes.NewReader(response[commandLength:])).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("bad headers reply from %s: %w", address, err)
+	}
+	if len(payload.Headers) > maxHeadersPerMessage {
+		return nil, fmt.Errorf("%s sent %d headers, more than %d", address, len(payload.Headers), maxHeadersPerMessage)
+	}
+
+	headers := make([]blockchain.BlockHeader, 0, len(payload.Headers))
+	for _, data := range payload.Headers {
+		header, err := blockchain.DeserializeHeader(data)
+		if err != nil {
+			return nil, fmt.Errorf("bad header from %s: %w", address, err)
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
+
+// HandleGetHeaders answers a getheaders message with headers of the active chain
+func HandleGetHeaders(request []byte, chain *blockchain.BlockChain, reply io.Writer) {
+	var payload GetHeaders
+	if err := decodePayload(request, &payload); err != nil {
+		logger.Error("Dropped malformed message", "command", "getheaders", "err", err)
+		return
+	}
+
+	// Pruned blocks keep their original Merkle root, so their headers can be served too
+	var headers [][]byte
+	for _, hash := range chain.HashesAfterLocator(payload.Locator, maxHeadersPerMessage) {
+		block, err := chain.GetBlock(hash)
+		if err != nil {
+			logger.Warn("Could not load a block for its header", "hash", hex.EncodeToString(hash), "err", err)
+			break // Later headers wouldn't link up without this one
+		}
+		headers = append(headers, block.Header(chain.Difficulty).Serialize())
+	}
+
+	response := append(CmdToBytes("headers"), GobEncode(Headers{AddrFrom: nodeAddress, Headers: headers})...)
+	if _, err := reply.Write(response); err != nil {
+		logger.Error("Could not send headers", "from", payload.AddrFrom, "err", err)
+	}
+}
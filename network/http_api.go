@@ -0,0 +1,255 @@
+package network
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-blockchain/blockchain"
+	"github.com/golang-blockchain/logger"
+	"github.com/golang-blockchain/metrics"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 25/12/2025
+ * Time: 10:10
+ */
+
+// HTTP API
+// "startnode -http PORT" serves a small JSON API next to the P2P listener, for block
+// explorers and scripts that would otherwise shell out to the CLI:
+//
+//	GET  /balance/{address}   balance of an address, confirmed and including the memory pool
+//	GET  /block/{hash}        one block, hash in hex
+//	GET  /tx/{id}             one confirmed transaction, ID in hex
+//	GET  /height              height of the chain tip
+//	POST /tx                  body: a serialized transaction, added to the memory pool
+//	GET  /metrics             node metrics in the Prometheus text format (see metrics package)
+//
+// Errors come back as {"error": "..."} with a matching status code.
+const (
+	maxHTTPTxSize       = 1 << 20 // Largest transaction body POST /tx accepts (1 MiB)
+	httpShutdownTimeout = 5 * time.Second
+)
+
+// apiBlock is the JSON shape of a block
+type apiBlock struct {
+	Height       int      `json:"height"`
+	Hash         string   `json:"hash"`
+	PrevHash     string   `json:"prevHash"`
+	Timestamp    int64    `json:"timestamp"`
+	Nonce        int      `json:"nonce"`
+	Transactions []string `json:"transactions"` // Transaction IDs (hex)
+}
+
+// apiInput is the JSON shape of a transaction input
+type apiInput struct {
+	TxID   string `json:"txid"`
+	Out    int    `json:"out"`
+	PubKey string `json:"pubKey"`
+}
+
+// apiOutput is the JSON shape of a transaction output
+type apiOutput struct {
+	Value      int    `json:"value"`
+	PubKeyHash string `json:"pubKeyHash"`
+	Data       string `json:"data,omitempty"` // Payload of a data output (hex)
+}
+
+// apiTransaction is the JSON shape of a confirmed transaction
+type apiTransaction struct {
+	ID          string      `json:"id"`
+	BlockHash   string      `json:"blockHash"`
+	BlockHeight int         `json:"blockHeight"`
+	Coinbase    bool        `json:"coinbase"`
+	Inputs      []apiInput  `json:"inputs"`
+	Outputs     []apiOutput `json:"outputs"`
+}
+
+// NewHTTPServer builds the API server for chain, listening on localhost:port
+// The caller runs it with ListenAndServe and stops it with Shutdown
+func NewHTTPServer(port string, chain *blockchain.BlockChain) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/balance/", func(w http.ResponseWriter, r *http.Request) { handleBalance(w, r, chain) })
+	mux.HandleFunc("/block/", func(w http.ResponseWriter, r *http.Request) { handleBlock(w, r, chain) })
+	mux.HandleFunc("/tx/", func(w http.ResponseWriter, r *http.Request) { handleGetTx(w, r, chain) })
+	mux.HandleFunc("/tx", func(w http.ResponseWriter, r *http.Request) { handlePostTx(w, r, chain) })
+	mux.HandleFunc("/height", func(w http.ResponseWriter, r *http.Request) { handleHeight(w, r, chain) })
+	mux.Handle("/metrics", metrics.Handler())
+
+	return &http.Server{
+		Addr:              fmt.Sprintf("localhost:%s", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// handleBalance serves GET /balance/{address}
+func handleBalance(w http.ResponseWriter, r *http.Request, chain *blockchain.BlockChain) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	address := strings.TrimPrefix(r.URL.Path, "/balance/")
+
+	balance, unconfirmed, err := (blockchain.UTXOSet{Blockchain: chain}).GetBalanceWithMempool(address)
+	if errors.Is(err, blockchain.ErrInvalidAddress) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"address": address, "balance": balance, "unconfirmedBalance": unconfirmed})
+}
+
+// handleBlock serves GET /block/{hash}
+func handleBlock(w http.ResponseWriter, r *http.Request, chain *blockchain.BlockChain) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	hash, err := hex.DecodeString(strings.TrimPrefix(r.URL.Path, "/block/"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid block hash: %w", err))
+		return
+	}
+
+	block, err := chain.GetBlock(hash)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("block %x not found", hash))
+		return
+	}
+
+	result := apiBlock{
+		Height:       block.Height,
+		Hash:         hex.EncodeToString(block.Hash),
+		PrevHash:     hex.EncodeToString(block.PrevHash),
+		Timestamp:    block.Timestamp,
+		Nonce:        block.Nonce,
+		Transactions: make([]string, 0, len(block.Transactions)),
+	}
+	for _, tx := range block.Transactions {
+		result.Transactions = append(result.Transactions, hex.EncodeToString(tx.ID))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleGetTx serves GET /tx/{id}
+func handleGetTx(w http.ResponseWriter, r *http.Request, chain *blockchain.BlockChain) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	id, err := hex.DecodeString(strings.TrimPrefix(r.URL.Path, "/tx/"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid transaction ID: %w", err))
+		return
+	}
+
+	tx, block, err := chain.FindTransactionBlock(id)
+	if errors.Is(err, blockchain.ErrTransactionNotFound) || errors.Is(err, blockchain.ErrTransactionPruned) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	result := apiTransaction{
+		ID:          hex.EncodeToString(tx.ID),
+		BlockHash:   hex.EncodeToString(block.Hash),
+		BlockHeight: block.Height,
+		Coinbase:    tx.IsCoinbase(),
+		Inputs:      make([]apiInput, 0, len(tx.Inputs)),
+		Outputs:     make([]apiOutput, 0, len(tx.Outputs)),
+	}
+	for _, in := range tx.Inputs {
+		result.Inputs = append(result.Inputs, apiInput{
+			TxID:   hex.EncodeToString(in.ID),
+			Out:    in.Out,
+			PubKey: hex.EncodeToString(in.PubKey),
+		})
+	}
+	for _, out := range tx.Outputs {
+		result.Outputs = append(result.Outputs, apiOutput{
+			Value:      out.Value,
+			PubKeyHash: hex.EncodeToString(out.PubKeyHash),
+			Data:       hex.EncodeToString(out.Payload()),
+		})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handlePostTx serves POST /tx: the body is a transaction as produced by Transaction.Serialize
+// It goes through the same checks as a transaction received from a peer
+func handlePostTx(w http.ResponseWriter, r *http.Request, chain *blockchain.BlockChain) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPTxSize+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(body) > maxHTTPTxSize {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("transaction exceeds %d bytes", maxHTTPTxSize))
+		return
+	}
+
+	tx, err := blockchain.DeserializeTransactionE(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	metrics.IncTransactionsReceived()
+
+	isNew, reason := admitTx(chain, &tx)
+	if reason != "" {
+		writeError(w, http.StatusUnprocessableEntity, errors.New(reason))
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"txid": hex.EncodeToString(tx.ID)})
+
+	if isNew {
+		relayTx(chain, &tx, "")
+	}
+}
+
+// handleHeight serves GET /height
+func handleHeight(w http.ResponseWriter, r *http.Request, chain *blockchain.BlockChain) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"height": chain.GetBestHeight()})
+}
+
+// allowMethod answers 405 and returns false unless the request uses method
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	return false
+}
+
+// writeJSON sends value as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		logger.Error("Could not write HTTP response", "err", err)
+	}
+}
+
+// writeError sends err as a {"error": "..."} JSON response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
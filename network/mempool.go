@@ -0,0 +1,314 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-blockchain/blockchain"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 20/12/2025
+ * Time: 10:20
+ */
+
+// Errors returned when a transaction can't enter the memory pool
+var (
+	ErrAlreadyPooled = errors.New("already in the memory pool")
+	ErrPoolConflict  = errors.New("conflicts with a transaction that just entered the memory pool")
+	ErrMempoolFull   = errors.New("memory pool full")
+)
+
+// Mempool holds the unconfirmed transactions waiting to be mined
+// Every connection is handled in its own goroutine, so all access goes through a lock
+// No two pooled transactions spend the same output: a conflicting transaction only
+// gets in by replacing the others (see Replace)
+// The serialized transactions together take at most maxSize bytes: once full, a new
+// transaction only gets in by paying a higher fee rate than the ones it evicts
+type Mempool struct {
+	mu      sync.RWMutex
+	txs     map[string]blockchain.Transaction // Transaction ID (hex) -> transaction
+	heights map[string]int                    // Chain height when each transaction arrived
+	added   map[string]time.Time              // When each transaction entered the pool
+	spends  map[string]string                 // Outpoint spent by a pooled transaction -> its ID (hex)
+	sizes   map[string]int                    // Serialized size of each transaction, in bytes
+	rates   map[string]float64                // Fee per serialized byte of each transaction
+
+	size    int // Sum of sizes
+	maxSize int // Limit on size; 0 means unlimited
+
+	now func() time.Time // Clock stamping added; replaceable so expiry can be simulated
+}
+
+// NewMempool creates an empty memory pool holding at most maxSize bytes of transactions
+// A maxSize of 0 leaves the pool unbounded
+func NewMempool(maxSize int) *Mempool {
+	return &Mempool{
+		txs:     make(map[string]blockchain.Transaction),
+		heights: make(map[string]int),
+		added:   make(map[string]time.Time),
+		spends:  make(map[string]string),
+		sizes:   make(map[string]int),
+		rates:   make(map[string]float64),
+		maxSize: maxSize,
+		now:     time.Now,
+	}
+}
+
+// Add stores a transaction paying fee that arrived when the chain was at the given height
+// Returns ErrAlreadyPooled or ErrPoolConflict (and changes nothing) if the transaction
+// was already pooled or spends an output a pooled transaction spends; see Replace for
+// the IDs returned and ErrMempoolFull
+func (m *Mempool) Add(txID string, tx blockchain.Transaction, fee, height int) ([]string, error) {
+	return m.Replace(txID, tx, fee, height, nil)
+}
+
+// Replace stores tx, paying fee, in place of replaced, the pooled transactions spending
+// the same outputs
+// When the pool has no room left, the transactions with the lowest fee rate are evicted
+// to make some; their IDs are returned so the caller can drop them from disk as well
+// Returns an error and changes nothing when:
+//   - tx is already pooled (ErrAlreadyPooled)
+//   - its conflicts are no longer exactly replaced, e.g. because another transaction
+//     got in first (ErrPoolConflict)
+//   - room can only be made by evicting transactions paying as much as tx or more
+//     (ErrMempoolFull)
+func (m *Mempool) Replace(txID string, tx blockchain.Transaction, fee, height int, replaced map[string]blockchain.Transaction) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.txs[txID]; ok {
+		return nil, ErrAlreadyPooled
+	}
+	conflicts := m.conflicts(&tx)
+	if len(conflicts) != len(replaced) {
+		return nil, ErrPoolConflict
+	}
+	for id := range conflicts {
+		if _, ok := replaced[id]; !ok {
+			return nil, ErrPoolConflict
+		}
+	}
+
+	size := len(tx.Serialize())
+	rate := float64(fee) / float64(size)
+	evicted, err := m.makeRoom(size, rate, conflicts)
+	if err != nil {
+		return nil, err
+	}
+
+	for id := range conflicts {
+		m.remove(id)
+	}
+	for _, id := range evicted {
+		m.remove(id)
+	}
+	m.txs[txID] = tx
+	m.heights[txID] = height
+	m.added[txID] = m.now()
+	m.sizes[txID] = size
+	m.rates[txID] = rate
+	m.size += size
+	for _, in := range tx.Inputs {
+		m.spends[in.Outpoint()] = txID
+	}
+	return evicted, nil
+}
+
+// makeRoom picks the cheapest transactions to evict so that size more bytes fit in the
+// pool once the replaced transactions are gone
+// Only transactions paying less than rate per byte are picked; if those don't free
+// enough room, ErrMempoolFull is returned. Nothing is removed here
+// The caller holds m.mu
+func (m *Mempool) makeRoom(size int, rate float64, replaced map[string]bool) ([]string, error) {
+	if m.maxSize == 0 {
+		return nil, nil
+	}
+	if size > m.maxSize {
+		return nil, fmt.Errorf("%w: transaction of %d bytes exceeds the %d byte limit", ErrMempoolFull, size, m.maxSize)
+	}
+
+	used := m.size
+	for id := range replaced {
+		used -= m.sizes[id]
+	}
+	if used+size <= m.maxSize {
+		return nil, nil
+	}
+
+	candidates := make([]string, 0, len(m.txs))
+	for id := range m.txs {
+		if !replaced[id] {
+			candidates = append(candidates, id)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return m.rates[candidates[i]] < m.rates[candidates[j]]
+	})
+
+	var evicted []string
+	for _, id := range candidates {
+		if used+size <= m.maxSize {
+			break
+		}
+		if m.rates[id] >= rate {
+			break
+		}
+		evicted = append(evicted, id)
+		used -= m.sizes[id]
+	}
+	if used+size > m.maxSize {
+		return nil, fmt.Errorf("%w: fee rate %.2f is too low to evict any pooled transaction", ErrMempoolFull, rate)
+	}
+	return evicted, nil
+}
+
+// Shrink evicts the cheapest transactions until the pool holds at most limit bytes
+// Returns the IDs evicted, so the caller can drop them from disk as well
+func (m *Mempool) Shrink(limit int) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.size <= limit {
+		return nil
+	}
+
+	candidates := make([]string, 0, len(m.txs))
+	for id := range m.txs {
+		candidates = append(candidates, id)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return m.rates[candidates[i]] < m.rates[candidates[j]]
+	})
+
+	var evicted []string
+	for _, id := range candidates {
+		if m.size <= limit {
+			break
+		}
+		evicted = append(evicted, id)
+		m.remove(id)
+	}
+	return evicted
+}
+
+// Conflicts returns the pooled transactions spending any output tx spends, by ID (hex)
+func (m *Mempool) Conflicts(tx *blockchain.Transaction) map[string]blockchain.Transaction {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	conflicts := make(map[string]blockchain.Transaction)
+	for id := range m.conflicts(tx) {
+		conflicts[id] = m.txs[id]
+	}
+	return conflicts
+}
+
+// conflicts returns the IDs of the pooled transactions spending an output tx spends
+// The caller holds m.mu
+func (m *Mempool) conflicts(tx *blockchain.Transaction) map[string]bool {
+	ids := make(map[string]bool)
+	for _, in := range tx.Inputs {
+		if id, ok := m.spends[in.Outpoint()]; ok {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// Get returns a pooled transaction and whether it was found
+func (m *Mempool) Get(txID string) (blockchain.Transaction, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tx, ok := m.txs[txID]
+	return tx, ok
+}
+
+// EntryHeight returns the chain height when a pooled transaction arrived
+func (m *Mempool) EntryHeight(txID string) (int, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	height, ok := m.heights[txID]
+	return height, ok
+}
+
+// Expired returns the IDs of the transactions that entered the pool more than ttl ago
+func (m *Mempool) Expired(ttl time.Duration) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cutoff := m.now().Add(-ttl)
+	var ids []string
+	for id, added := range m.added {
+		if added.Before(cutoff) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Delete removes a transaction and returns it, if it was pooled
+func (m *Mempool) Delete(txID string) (blockchain.Transaction, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx, ok := m.txs[txID]
+	m.remove(txID)
+	return tx, ok
+}
+
+// remove drops a transaction and the outputs it spends from the pool
+// The caller holds m.mu for writing
+func (m *Mempool) remove(txID string) {
+	tx, ok := m.txs[txID]
+	if !ok {
+		return
+	}
+	for _, in := range tx.Inputs {
+		if m.spends[in.Outpoint()] == txID {
+			delete(m.spends, in.Outpoint())
+		}
+	}
+	m.size -= m.sizes[txID]
+	delete(m.txs, txID)
+	delete(m.heights, txID)
+	delete(m.added, txID)
+	delete(m.sizes, txID)
+	delete(m.rates, txID)
+}
+
+// Len returns the number of pooled transactions
+func (m *Mempool) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.txs)
+}
+
+// Size returns the serialized size of the pooled transactions together, in bytes
+func (m *Mempool) Size() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.size
+}
+
+// Snapshot returns a copy of the pool that the caller can range over without holding the lock
+func (m *Mempool) Snapshot() map[string]blockchain.Transaction {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	txs := make(map[string]blockchain.Transaction, len(m.txs))
+	for id, tx := range m.txs {
+		txs[id] = tx
+	}
+	return txs
+}
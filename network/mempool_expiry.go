@@ -0,0 +1,74 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang-blockchain/blockchain"
+	"github.com/golang-blockchain/logger"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 19:50
+ */
+
+// MEMORY POOL EXPIRY
+// A transaction that never gets mined would otherwise stay pooled (and on disk) forever.
+// Every mempoolSweepInterval the node evicts:
+//  1. transactions pooled longer than MEMPOOL_EXPIRY_HOURS, e.g. because their fee is
+//     too low for any miner to pick them;
+//  2. transactions spending an output the chain no longer has, i.e. a conflicting
+//     transaction was confirmed instead.
+//
+// The age restarts when a node restarts and reloads its pool from disk.
+const (
+	defaultMempoolExpiry = 72 // Hours, overridden by the MEMPOOL_EXPIRY_HOURS env. var.
+	mempoolSweepInterval = 5 * time.Minute
+)
+
+var mempoolExpiry = time.Duration(loadPositiveEnv("MEMPOOL_EXPIRY_HOURS", defaultMempoolExpiry)) * time.Hour
+
+// SweepMempool evicts stale transactions from the memory pool each interval until ctx is cancelled
+func SweepMempool(ctx context.Context, chain *blockchain.BlockChain, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sweepMempool(chain, mempoolExpiry)
+		}
+	}
+}
+
+// sweepMempool evicts the transactions older than ttl and those whose inputs are gone
+func sweepMempool(chain *blockchain.BlockChain, ttl time.Duration) {
+	expired := memoryPool.Expired(ttl)
+	for _, id := range expired {
+		removeMempoolTx(chain, id)
+	}
+
+	conflicted := 0
+	utxoSet := blockchain.UTXOSet{Blockchain: chain}
+	for id, tx := range memoryPool.Snapshot() {
+		err := utxoSet.CheckUnspent(&tx)
+		if errors.Is(err, blockchain.ErrOutputSpent) {
+			removeMempoolTx(chain, id)
+			conflicted++
+		} else if err != nil {
+			logger.Error("Could not check a pooled transaction's inputs", "txid", id, "err", err)
+		}
+	}
+
+	if len(expired) > 0 || conflicted > 0 {
+		logger.Info("Evicted stale transactions from the memory pool",
+			"expired", len(expired), "conflicted", conflicted, "poolSize", memoryPool.Len())
+	}
+}
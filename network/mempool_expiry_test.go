@@ -0,0 +1,73 @@
+package network
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/golang-blockchain/blockchain"
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 15:10
+ */
+
+// pooled reports whether tx is in the memory pool and on disk
+func pooled(t *testing.T, chain *blockchain.BlockChain, tx *blockchain.Transaction) (bool, bool) {
+	t.Helper()
+
+	onDisk, err := chain.IsMempoolTx(tx.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, inMemory := memoryPool.Get(hex.EncodeToString(tx.ID))
+	return inMemory, onDisk
+}
+
+func TestSweepMempoolEvictsExpiredAndConflictedTransactions(t *testing.T) {
+	first, second, third, recipient := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{first: 100, second: 100, third: 100})
+	const ttl = time.Hour
+
+	clock := time.Now()
+	memoryPool.now = func() time.Time { return clock }
+
+	old := newTestTransfer(t, chain, first, recipient, 10, 1)
+	poolTx(t, chain, *old, 1)
+
+	// Just short of the TTL nothing is evicted
+	clock = clock.Add(ttl - time.Minute)
+	young := newTestTransfer(t, chain, second, recipient, 10, 1)
+	poolTx(t, chain, *young, 1)
+	sweepMempool(chain, ttl)
+	if inMemory, onDisk := pooled(t, chain, old); !inMemory || !onDisk {
+		t.Fatalf("transaction evicted before its TTL (in memory %v, on disk %v)", inMemory, onDisk)
+	}
+
+	// Past it, only the older transaction goes
+	clock = clock.Add(2 * time.Minute)
+	sweepMempool(chain, ttl)
+	if inMemory, onDisk := pooled(t, chain, old); inMemory || onDisk {
+		t.Errorf("expired transaction still pooled (in memory %v, on disk %v)", inMemory, onDisk)
+	}
+	if inMemory, onDisk := pooled(t, chain, young); !inMemory || !onDisk {
+		t.Errorf("transaction evicted %v after arriving, before its TTL", 2*time.Minute)
+	}
+
+	// A transaction whose input a confirmed one spent instead can never be mined
+	conflicted := newTestTransfer(t, chain, third, recipient, 10, 1)
+	poolTx(t, chain, *conflicted, 1)
+	mineTestBlock(t, chain, wallet.MakeWallet(), newTestTransfer(t, chain, third, recipient, 20, 1))
+	sweepMempool(chain, ttl)
+	if inMemory, onDisk := pooled(t, chain, conflicted); inMemory || onDisk {
+		t.Errorf("conflicted transaction still pooled (in memory %v, on disk %v)", inMemory, onDisk)
+	}
+	if inMemory, _ := pooled(t, chain, young); !inMemory {
+		t.Error("a transaction with unspent inputs was evicted as conflicted")
+	}
+}
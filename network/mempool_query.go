@@ -0,0 +1,110 @@
+package network
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/golang-blockchain/blockchain"
+	"github.com/golang-blockchain/logger"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 12:15
+ */
+
+// MEMORY POOL QUERIES
+// The memory pool only exists inside the running node, and the node holds the database
+// lock, so the CLI can't read it from disk. Instead it sends the node a "getmempool"
+// message and the node answers on the same connection with a "mempool" message listing
+// every pending transaction, best fee rate (the mining order) first.
+const mempoolQueryTimeout = 10 * time.Second
+
+// ErrNodeNotRunning is returned when no node answers on the queried address
+var ErrNodeNotRunning = errors.New("no node is running")
+
+// GetMempool asks a node for the contents of its memory pool
+type GetMempool struct {
+	AddrFrom string // Requestor's address (empty for the CLI)
+}
+
+// MempoolEntry describes one pending transaction
+type MempoolEntry struct {
+	TxID    []byte
+	Fee     int // Inputs minus outputs; 0 if the inputs can't be resolved
+	Size    int // Serialized size in bytes
+	Inputs  int
+	Outputs int
+}
+
+// MempoolContents answers a GetMempool
+type MempoolContents struct {
+	AddrFrom string
+	Entries  []MempoolEntry
+}
+
+// QueryMempool fetches the memory pool of the node at address
+// Returns ErrNodeNotRunning if nothing is listening there
+func QueryMempool(address string) ([]MempoolEntry, error) {
+	// Dial directly: an unreachable local node must not be dropped from the peer list
+	conn, err := net.DialTimeout(protocol, address, mempoolQueryTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w on %s: %v", ErrNodeNotRunning, address, err)
+	}
+	defer conn.Close()
+
+	request := append(CmdToBytes("getmempool"), GobEncode(GetMempool{AddrFrom: nodeAddress})...)
+	response, err := exchange(conn, address, request, "mempool", mempoolQueryTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var contents MempoolContents
+	if err := gob.NewDecoder(bytes.NewReader(response[commandLength:])).Decode(&contents); err != nil {
+		return nil, fmt.Errorf("bad memory pool reply from %s: %w", address, err)
+	}
+	return contents.Entries, nil
+}
+
+// HandleGetMempool answers a getmempool message with this node's memory pool
+func HandleGetMempool(request []byte, chain *blockchain.BlockChain, reply io.Writer) {
+	var payload GetMempool
+	if err := decodePayload(request, &payload); err != nil {
+		logger.Error("Dropped malformed message", "command", "getmempool", "err", err)
+		return
+	}
+
+	var entries []MempoolEntry
+	for _, tx := range memoryPool.Snapshot() {
+		fee, err := chain.Fee(&tx)
+		if err != nil {
+			logger.Debug("Could not compute the fee of a pooled transaction", "txid", hex.EncodeToString(tx.ID), "err", err)
+			fee = 0
+		}
+		entries = append(entries, MempoolEntry{
+			TxID:    tx.ID,
+			Fee:     fee,
+			Size:    len(tx.Serialize()),
+			Inputs:  len(tx.Inputs),
+			Outputs: len(tx.Outputs),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Fee*entries[j].Size > entries[j].Fee*entries[i].Size // Fee rate, without float division
+	})
+
+	response := append(CmdToBytes("mempool"), GobEncode(MempoolContents{AddrFrom: nodeAddress, Entries: entries})...)
+	if _, err := reply.Write(response); err != nil {
+		logger.Error("Could not send the memory pool", "from", payload.AddrFrom, "err", err)
+	}
+}
@@ -0,0 +1,156 @@
+package network
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/golang-blockchain/blockchain"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 10:20
+ */
+
+// syntheticTx returns a distinct transaction spending output i of a made-up parent
+// Its payload makes it about size bytes long; nothing about it has to be valid to be pooled
+func syntheticTx(i, size int) blockchain.Transaction {
+	tx := blockchain.Transaction{
+		Inputs:  []blockchain.TxInput{{ID: []byte("parent"), Out: i}},
+		Outputs: []blockchain.TxOutput{{Value: 1, PubKeyHash: make([]byte, size)}},
+	}
+	tx.SetID()
+	return tx
+}
+
+// newTestPool swaps in an empty memory pool holding at most maxSize bytes and a chain
+// persisting it in memory, both restored when the test ends
+func newTestPool(t *testing.T, maxSize int) *blockchain.BlockChain {
+	t.Helper()
+
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	pool := memoryPool
+	memoryPool = NewMempool(maxSize)
+	t.Cleanup(func() { memoryPool = pool })
+	return &blockchain.BlockChain{Database: db}
+}
+
+// poolTx adds tx paying fee to the memory pool and to disk
+func poolTx(t *testing.T, chain *blockchain.BlockChain, tx blockchain.Transaction, fee int) string {
+	t.Helper()
+
+	txID := hex.EncodeToString(tx.ID)
+	if _, err := memoryPool.Add(txID, tx, fee, 0); err != nil {
+		t.Fatalf("pool %s: %v", txID, err)
+	}
+	if err := chain.SaveMempoolTx(&tx); err != nil {
+		t.Fatal(err)
+	}
+	return txID
+}
+
+func TestGuardMempoolMemoryShedsCheapestDownToTheWatermark(t *testing.T) {
+	chain := newTestPool(t, 0)
+
+	var ids []string // By fee rate, cheapest first
+	for i := 0; i < 10; i++ {
+		ids = append(ids, poolTx(t, chain, syntheticTx(i, 500), 10*(i+1)))
+	}
+	total := memoryPool.Size()
+
+	// Simulate high memory: a watermark holding only about half of the pool
+	limit := mempoolMemoryLimit
+	mempoolMemoryLimit = uint64(total / 2)
+	t.Cleanup(func() { mempoolMemoryLimit = limit })
+
+	GuardMempoolMemory(chain)
+
+	if size := memoryPool.Size(); uint64(size) > mempoolMemoryLimit {
+		t.Fatalf("pool holds %d bytes, above the %d byte watermark", size, mempoolMemoryLimit)
+	}
+	kept := memoryPool.Len()
+	if kept == 0 || kept == len(ids) {
+		t.Fatalf("kept %d of %d transactions, want only some evicted", kept, len(ids))
+	}
+	for i, id := range ids {
+		_, pooled := memoryPool.Get(id)
+		raw, _ := hex.DecodeString(id)
+		stored, err := chain.IsMempoolTx(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := i >= len(ids)-kept; pooled != want || stored != want {
+			t.Errorf("transaction %d: pooled %v, on disk %v, want %v", i, pooled, stored, want)
+		}
+	}
+}
+
+func TestGuardMempoolMemoryKeepsAPoolUnderTheWatermark(t *testing.T) {
+	chain := newTestPool(t, 0)
+	id := poolTx(t, chain, syntheticTx(0, 500), 10)
+
+	// The rest of the heap is far bigger than this watermark, but the pool fits under it
+	limit := mempoolMemoryLimit
+	mempoolMemoryLimit = uint64(memoryPool.Size())
+	t.Cleanup(func() { mempoolMemoryLimit = limit })
+
+	GuardMempoolMemory(chain)
+
+	if _, ok := memoryPool.Get(id); !ok {
+		t.Error("the transaction just accepted was evicted")
+	}
+}
+
+func TestMempoolPastItsCapKeepsTheHighestFees(t *testing.T) {
+	size := len(syntheticTx(1, 500).Serialize())
+	newTestPool(t, 5*size+size/2) // Room for five
+
+	var ids []string // Fees 10 to 50
+	for i := 1; i <= 5; i++ {
+		tx := syntheticTx(i, 500)
+		id := hex.EncodeToString(tx.ID)
+		if evicted, err := memoryPool.Add(id, tx, 10*i, 0); err != nil || len(evicted) != 0 {
+			t.Fatalf("Add(fee %d) = %v, %v; want it to fit", 10*i, evicted, err)
+		}
+		ids = append(ids, id)
+	}
+
+	// Cheaper than everything pooled: refused, and nothing is evicted for it
+	cheap := syntheticTx(6, 500)
+	if _, err := memoryPool.Add(hex.EncodeToString(cheap.ID), cheap, 5, 0); !errors.Is(err, ErrMempoolFull) {
+		t.Errorf("Add(fee 5) = %v, want ErrMempoolFull", err)
+	}
+	if n := memoryPool.Len(); n != 5 {
+		t.Errorf("pool holds %d transactions after a refusal, want 5", n)
+	}
+
+	// Each better-paying arrival pushes out the cheapest
+	for i, fee := range []int{60, 70} {
+		tx := syntheticTx(7+i, 500)
+		id := hex.EncodeToString(tx.ID)
+		evicted, err := memoryPool.Add(id, tx, fee, 0)
+		if err != nil || len(evicted) != 1 || evicted[0] != ids[i] {
+			t.Fatalf("Add(fee %d) = %v, %v; want fee %d evicted", fee, evicted, err, 10*(i+1))
+		}
+		ids = append(ids, id)
+	}
+
+	for i, id := range ids {
+		if _, pooled := memoryPool.Get(id); pooled != (i >= 2) {
+			t.Errorf("transaction paying %d: pooled %v", 10*(i+1), pooled)
+		}
+	}
+	if got := memoryPool.Size(); got > 5*size+size/2 {
+		t.Errorf("pool holds %d bytes, above its cap", got)
+	}
+}
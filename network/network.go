@@ -2,18 +2,27 @@ package network
 
 import (
 	"bytes"
+	"context"
 	"encoding/gob"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
+	"math/rand"
 	"net"
+	"net/http"
 	"os"
-	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/golang-blockchain/blockchain"
+	"github.com/golang-blockchain/logger"
+	"github.com/golang-blockchain/metrics"
 	"github.com/vrecan/death/v3"
 )
 
@@ -41,8 +50,9 @@ import (
 	   - Receives "addr" messages with other node addresses
 
 	3. BlockChain Sync:
-	   - If behind: sends "getblocks" to request block hashes
-	   - Receives "inv" with block hashes
+	   - If behind: sends "getblocks" with a locator of its recent block hashes
+	   - Receives "inv" with the block hashes after the last one both sides share
+	     (at most maxBlocksPerInv; once downloaded it asks for the next batch)
 	   - Requests missing blocks with "getdata"
 	   - Receives blocks with "block" messages
 	   - Adds blocks to a local chain
@@ -51,8 +61,9 @@ import (
 	   - User creates transaction
 	   - Node sends "tx" message to peers
 	   - Peers validate and add to the memory pool
+	   - Each peer relays new transactions to a few random peers ("inv" gossip)
 	   - Miner nodes bundle transactions into blocks
-	   - Miner broadcasts a new block with "inv"
+	   - Miner announces a new block with "inv", which is gossiped the same way
 
 	5. Mining:
 	   - Miner collects transactions from the memory pool
@@ -80,19 +91,87 @@ NOTE: SVP - Simplified Payment Verification
 // Network protocol constants define how nodes communicate
 const (
 	protocol      = "tcp" // Transport protocol (TCP for reliability)
-	version       = 1     // Network protocol version (for backward compatibility)
+	version       = 2     // Network protocol version (2 adds length-prefixed framing, see framing.go)
 	commandLength = 12    // Fixed the length for command names in messages
+
+	txAckTimeout = 10 * time.Second // How long SendTxWithAck waits for the peer's verdict
+
+	maxBlocksPerInv = 500 // Most block hashes sent in answer to one "getblocks"
 )
 
+// handlerShutdownTimeout is the longest a shutdown waits for connection handlers and background loops
+var handlerShutdownTimeout = 10 * time.Second
+
 // Global network state variables
+// KnownNodes and blocksInTransit are shared by every connection goroutine:
+// use the helpers below (guarded by nodesMu / transitMu) instead of touching them directly
+var (
+	nodeAddress         string                       // This node's address (e.g., "localhost:3000")
+	mineAddress         string                       // Miner's reward address (if this node mines)
+	mineTag             string                       // Miner tag put in the coinbase of blocks we mine
+	KnownNodes          = []string{"localhost:3000"} // Bootstrap node list - starts with the central seed node
+	blocksInTransit     = [][]byte{}                 // Blocks we're currently downloading
+	moreBlocksAvailable bool                         // The peer we sync from has blocks beyond blocksInTransit
+	memoryPool          = NewMempool(MaxMempoolSize) // Unconfirmed transactions waiting for mining
+
+	nodesMu   sync.RWMutex // Guards KnownNodes
+	transitMu sync.Mutex   // Guards blocksInTransit and moreBlocksAvailable
+
+	feeEstimator = blockchain.NewFeeEstimator(blockchain.FeeHistoryWindow) // Learns confirmation times from fee rates
+)
+
+// ErrBroadcastFailed is returned when no peer accepted a broadcast transaction
+var ErrBroadcastFailed = errors.New("no peer accepted the transaction")
+
+// ErrTxRejected is returned when a peer verified a transaction and refused it
+var ErrTxRejected = errors.New("transaction rejected")
+
+// ErrShutdownTimeout is returned when the node's workers were still running at shutdown
+var ErrShutdownTimeout = errors.New("node did not stop in time; the memory pool was not saved")
+
+// In-flight mining state
+// Each MineTx attempt registers its cancel function here so HandleBlock can abandon it
+// when a competing block arrives first; several handlers may be mining at once
+var (
+	miningMu          sync.Mutex                         // Guards miningAttempts and nextMiningAttempt
+	miningAttempts    = make(map[int]context.CancelFunc) // Attempt number -> cancels that attempt
+	nextMiningAttempt int                                // Number of the next attempt to start
+)
+
+// Memory pool memory guard
+// When the pooled transactions grow past the watermark, the cheapest ones are evicted
+// before the pool runs the process out of memory. Only the pool's own bytes count:
+// the rest of the heap is not the pool's to shed, and the transactions just accepted
+// must not be dropped for it
+const defaultMempoolMemoryLimit = 256 // MiB, overridden by the MEMPOOL_MEMORY_LIMIT_MB env. var.
+
+// Memory pool size limit
+// Unlike the watermark above, this caps the pool itself: a flood of transactions can
+// fill it, but past the limit each newcomer must outbid the cheapest pooled ones
+const defaultMaxMempoolSize = 64 // MiB, overridden by the MEMPOOL_MAX_SIZE_MB env. var.
+
+// MaxMempoolSize is the most serialized transaction bytes the memory pool holds
+var MaxMempoolSize = loadPositiveEnv("MEMPOOL_MAX_SIZE_MB", defaultMaxMempoolSize) << 20
+
 var (
-	nodeAddress     string                                    // This node's address (e.g., "localhost:3000")
-	mineAddress     string                                    // Miner's reward address (if this node mines)
-	KnownNodes      = []string{"localhost:3000"}              // Bootstrap node list - starts with the central seed node
-	blocksInTransit = [][]byte{}                              // Blocks we're currently downloading
-	memoryPool      = make(map[string]blockchain.Transaction) // Unconfirmed transactions waiting for mining
+	mempoolMemoryLimit = loadMempoolMemoryLimit() // Memory pool watermark in bytes
 )
 
+// Propagation fan-out
+// New transactions and blocks are relayed to a random subset of peers instead of all of them;
+// every node that learns something new relays it again, so gossip still covers the network
+const defaultFanout = 4 // Peers per relay, overridden by the PROPAGATION_FANOUT env. var.
+
+var fanout = loadFanout() // Number of peers each relay is sent to
+
+// Connection timeouts
+// Every connection, dialed or accepted, gets a deadline, so a peer that stops reading or
+// writing can't hold a goroutine forever. A peer we can't deliver to in time is treated
+// like one we can't reach and leaves the known nodes list.
+const defaultConnectionTimeout = 30 // Seconds, overridden by the CONNECTION_TIMEOUT_SECONDS env. var.
+
+var connectionTimeout = time.Duration(loadPositiveEnv("CONNECTION_TIMEOUT_SECONDS", defaultConnectionTimeout)) * time.Second
+
 // ============================================================================
 // NETWORK MESSAGE STRUCTURES (P2P Protocol Messages)
 // ============================================================================
@@ -110,7 +189,8 @@ type Block struct {
 
 // GetBlocks message requests block hashes from a peer (inventory discovery)
 type GetBlocks struct {
-	AddrFrom string // Requestor's address
+	AddrFrom string   // Requestor's address
+	Locator  [][]byte // Requestor's block locator, tip first (see BlockChain.BlockLocator)
 }
 
 // GetData message requests specific data (block or transaction) from a peer
@@ -133,6 +213,24 @@ type Tx struct {
 	Transaction []byte // Serialized transaction data
 }
 
+// TxAck answers a "tx" message on the same connection
+// It tells the sender whether the transaction passed verification and entered the memory pool
+type TxAck struct {
+	TxID     []byte // ID of the acknowledged transaction
+	Accepted bool   // True if the transaction was added to the memory pool
+	Reason   string // Why the transaction was rejected (empty when accepted)
+}
+
+// Ping message checks that a peer is still alive; it answers on the same connection with Pong
+type Ping struct {
+	AddrFrom string // Sender's address
+}
+
+// Pong message answers a Ping
+type Pong struct {
+	AddrFrom string // Responder's address
+}
+
 // Version message exchanges version information when nodes connect (handshake)
 type Version struct {
 	Version    int    // Protocol version for compatibility checking
@@ -182,16 +280,16 @@ func ExtractCmd(request []byte) []byte {
 
 // RequestBlocks asks all known nodes for their block inventories
 // Used during initial sync to discover missing blocks
-func RequestBlocks() {
-	for _, node := range KnownNodes {
-		SendGetBlocks(node)
+func RequestBlocks(chain *blockchain.BlockChain) {
+	for _, node := range knownNodes() {
+		SendGetBlocks(node, chain)
 	}
 }
 
 // SendAddr broadcasts our known node list to a peer
 // Helps with peer discovery and network connectivity
 func SendAddr(address string) {
-	nodes := Addr{KnownNodes}
+	nodes := Addr{knownNodes()}
 	nodes.AddrList = append(nodes.AddrList, nodeAddress) // Include ourselves
 	payload := GobEncode(nodes)
 	request := append(CmdToBytes("addr"), payload...)
@@ -210,34 +308,59 @@ func SendBlock(addr string, b *blockchain.Block) {
 
 // SendData is the low-level function that transmits data over TCP
 // Handles connection errors and updates a known nodes list
-func SendData(addr string, data []byte) {
-	conn, err := net.Dial(protocol, addr)
+// Returns an error if the peer couldn't be reached or the data couldn't be written
+func SendData(addr string, data []byte) error {
+	conn, err := dialNode(addr)
+	if err != nil {
+		return err
+	}
 
+	defer conn.Close()
+	if peerSupportsFraming(addr) {
+		data = frameMessage(data)
+	}
+	_, err = io.Copy(conn, bytes.NewReader(data))
+	if isTimeout(err) {
+		logger.Warn("Node stopped reading", "node", addr, "timeout", connectionTimeout)
+		removeKnownNode(addr)
+	}
 	if err != nil {
-		fmt.Printf("%s is not available\n", addr)
+		return fmt.Errorf("sending to %s: %w", addr, err)
+	}
+	return nil
+}
 
-		// Remove dead node from known nodes
-		var updatedNodes []string
-		for _, node := range KnownNodes {
-			if node != addr {
-				updatedNodes = append(updatedNodes, node)
-			}
+// dialNode opens a connection to a peer, with connectionTimeout to finish talking to it
+// A peer that can't be reached is removed from the known nodes list
+func dialNode(addr string) (net.Conn, error) {
+	conn, err := net.DialTimeout(protocol, addr, connectionTimeout)
+	if err == nil {
+		err = conn.SetDeadline(time.Now().Add(connectionTimeout))
+		if err != nil {
+			conn.Close()
 		}
-		KnownNodes = updatedNodes
-		return
 	}
 
-	defer conn.Close()
-	_, err = io.Copy(conn, bytes.NewReader(data))
 	if err != nil {
-		log.Panic(err)
+		logger.Warn("Node is not available", "node", addr, "err", err)
+
+		// Remove dead node from known nodes
+		removeKnownNode(addr)
+		return nil, fmt.Errorf("%s is not available: %w", addr, err)
 	}
+	return conn, nil
+}
+
+// isTimeout reports whether err is a connection deadline being exceeded
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
 
 // SendGetBlocks requests block hashes from a node
-// First step in blockchain synchronization
-func SendGetBlocks(address string) {
-	payload := GobEncode(GetBlocks{AddrFrom: nodeAddress})
+// First step in blockchain synchronization; the locator lets the node skip the blocks we have
+func SendGetBlocks(address string, chain *blockchain.BlockChain) {
+	payload := GobEncode(GetBlocks{AddrFrom: nodeAddress, Locator: chain.BlockLocator()})
 	request := append(CmdToBytes("getblocks"), payload...)
 
 	SendData(address, request)
@@ -262,12 +385,116 @@ func SendInv(address, kind string, items [][]byte) {
 }
 
 // SendTx broadcasts a transaction to the network
-func SendTx(address string, tx *blockchain.Transaction) {
+// It doesn't wait for the peer's verdict; use SendTxWithAck for that
+func SendTx(address string, tx *blockchain.Transaction) error {
 	data := Tx{AddrFrom: nodeAddress, Transaction: tx.Serialize()}
 	payload := GobEncode(data)
 	request := append(CmdToBytes("tx"), payload...)
 
-	SendData(address, request)
+	return SendData(address, request)
+}
+
+// SendTxWithAck sends a transaction and waits for the peer to verify it
+// Returns nil only if the peer added it to its memory pool,
+// or an error wrapping ErrTxRejected with the peer's reason
+func SendTxWithAck(address string, tx *blockchain.Transaction) error {
+	data := Tx{AddrFrom: nodeAddress, Transaction: tx.Serialize()}
+	payload := GobEncode(data)
+	request := append(CmdToBytes("tx"), payload...)
+
+	// Step 1 & 2: Send the request and wait for the "txack" reply on the same connection
+	response, err := sendAndAwaitReply(address, request, "txack", txAckTimeout)
+	if err != nil {
+		return fmt.Errorf("no acknowledgement: %w", err)
+	}
+
+	var ack TxAck
+	dec := gob.NewDecoder(bytes.NewReader(response[commandLength:]))
+	if err := dec.Decode(&ack); err != nil {
+		return fmt.Errorf("bad acknowledgement from %s: %w", address, err)
+	}
+
+	// Step 3: Report the peer's verdict
+	if !ack.Accepted {
+		return fmt.Errorf("%w by %s: %s", ErrTxRejected, address, ack.Reason)
+	}
+	return nil
+}
+
+// sendAndAwaitReply sends a request and waits up to timeout for a reply of the given command
+// on the same connection; the whole reply message (command included) is returned
+func sendAndAwaitReply(address string, request []byte, replyCmd string, timeout time.Duration) ([]byte, error) {
+	if peerSupportsFraming(address) {
+		request = frameMessage(request)
+	}
+
+	conn, err := dialNode(address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return exchange(conn, address, request, replyCmd, timeout)
+}
+
+// exchange is sendAndAwaitReply on a connection the caller has opened (and closes)
+// The request must already be framed if the peer expects it
+func exchange(conn net.Conn, address string, request []byte, replyCmd string, timeout time.Duration) ([]byte, error) {
+	// Send the request and close our side, a version 1 peer reads until EOF
+	if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(conn, bytes.NewReader(request)); err != nil {
+		return nil, fmt.Errorf("sending to %s: %w", address, err)
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		if err := tcpConn.CloseWrite(); err != nil {
+			return nil, fmt.Errorf("sending to %s: %w", address, err)
+		}
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	response, _, err := readMessage(conn)
+	if err != nil {
+		return nil, fmt.Errorf("reading reply from %s: %w", address, err)
+	}
+	if len(response) < commandLength || BytesToCmd(response[:commandLength]) != replyCmd {
+		return nil, fmt.Errorf("%s did not reply with %q", address, replyCmd)
+	}
+	return response, nil
+}
+
+// BroadcastTx hands a transaction to the network
+// Known nodes are tried in order until one of them takes it, so a single
+// unreachable bootstrap node doesn't lose the transaction
+// Returns ErrTxRejected if a node refused it as invalid,
+// or ErrBroadcastFailed if no node could be reached
+func BroadcastTx(tx *blockchain.Transaction) error {
+	var errs []error
+
+	// Work on a copy: SendData drops unreachable nodes from KnownNodes as we go
+	for _, node := range knownNodes() {
+		if node == nodeAddress {
+			continue // Don't send to ourselves
+		}
+
+		err := SendTxWithAck(node, tx)
+		if errors.Is(err, ErrTxRejected) {
+			return err // Invalid everywhere, no point asking other nodes
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue // Try the next node
+		}
+		return nil // Accepted
+	}
+
+	if len(errs) == 0 {
+		return fmt.Errorf("%w: no known nodes", ErrBroadcastFailed)
+	}
+	return fmt.Errorf("%w: %w", ErrBroadcastFailed, errors.Join(errs...))
 }
 
 // SendVersion exchanges version information during handshake
@@ -285,81 +512,100 @@ func SendVersion(address string, chain *blockchain.BlockChain) {
 // ============================================================================
 
 // HandleAddr processes incoming address lists from peers
-func HandleAddr(request []byte) {
-	var buff bytes.Buffer
+func HandleAddr(request []byte, chain *blockchain.BlockChain) {
 	var payload Addr
-
-	buff.Write(request[commandLength:])
-	dec := gob.NewDecoder(&buff)
-	err := dec.Decode(&payload)
-	if err != nil {
-		log.Panic(err)
+	if err := decodePayload(request, &payload); err != nil {
+		logger.Error("Dropped malformed message", "command", "addr", "err", err)
+		return
 	}
 
 	// Add new nodes to our known nodes list
-	KnownNodes = append(KnownNodes, payload.AddrList...)
-	fmt.Printf("There are %d known nodes\n", len(KnownNodes))
-	RequestBlocks() // Request blocks from new nodes
+	addKnownNodes(payload.AddrList...)
+	logger.Debug("Received addresses", "count", len(payload.AddrList), "knownNodes", len(knownNodes()))
+	RequestBlocks(chain) // Request blocks from new nodes
 }
 
 // HandleBlock processes incoming blocks and adds them to our blockchain
 func HandleBlock(request []byte, chain *blockchain.BlockChain) {
-	var buff bytes.Buffer
 	var payload Block
+	if err := decodePayload(request, &payload); err != nil {
+		logger.Error("Dropped malformed message", "command", "block", "err", err)
+		return
+	}
 
-	buff.Write(request[commandLength:])
-	dec := gob.NewDecoder(&buff)
-	err := dec.Decode(&payload)
+	block, err := blockchain.DeserializeE(payload.Block)
 	if err != nil {
-		log.Panic(err)
+		logger.Error("Dropped malformed block", "from", payload.AddrFrom, "err", err)
+		return
+	}
+	logger.Debug("Received block", "hash", hex.EncodeToString(block.Hash), "height", block.Height, "from", payload.AddrFrom)
+
+	// A block higher than our tip makes whatever we are mining stale
+	extendsChain := block.Height > chain.GetBestHeight()
+	isNew := !chain.HasBlock(block.Hash)
+
+	err = chain.AddBlock(block)
+	switch {
+	case errors.Is(err, blockchain.ErrOrphanBlock):
+		// Parents usually follow (e.g. a relayed tip arriving mid-sync); it is added once they arrive
+		logger.Info("Queued orphan block until its parent arrives", "hash", hex.EncodeToString(block.Hash))
+		isNew = false
+	case err != nil:
+		// A peer serving forged blocks can't be trusted for the rest of the download
+		logger.Warn("Rejected block", "hash", hex.EncodeToString(block.Hash), "from", payload.AddrFrom, "err", err)
+		setBlocksInTransit(nil)
+		return
+	default:
+		logger.Info("Added block to the chain", "hash", hex.EncodeToString(block.Hash), "height", block.Height)
 	}
 
-	blockData := payload.Block
-	block := blockchain.Deserialize(blockData)
+	if isNew && extendsChain {
+		StopMining()
+	}
+
+	// Relay a freshly announced tip (not blocks we are downloading during a sync)
+	if isNew && extendsChain && blocksInTransitCount() == 0 {
+		for _, node := range gossipPeers(payload.AddrFrom) {
+			SendInv(node, "block", [][]byte{block.Hash})
+		}
+	}
 
-	fmt.Println("Received a new block!")
-	chain.AddBlock(block)
-	fmt.Printf("Added block %x to the chain\n", block.Hash)
+	// Transactions confirmed by this block no longer belong in the memory pool
+	confirmMempoolTxs(chain, block)
 
 	// If we have more blocks to download, request the next one
-	if len(blocksInTransit) > 0 {
-		blockHash := blocksInTransit[0]
+	// (AddBlock already keeps the UTXO set up to date, block by block)
+	// Once a truncated inventory is downloaded, ask the same peer for the next batch
+	if blockHash, ok := nextBlockInTransit(); ok {
 		SendGetData(payload.AddrFrom, "block", blockHash)
-		blocksInTransit = blocksInTransit[1:]
-	} else {
-		// All blocks downloaded, reindex UTXO set
-		UTXOSet := blockchain.UTXOSet{Blockchain: chain}
-		UTXOSet.Reindex()
+	} else if takeMoreBlocksAvailable() {
+		SendGetBlocks(payload.AddrFrom, chain)
 	}
 }
 
 // HandleGetBlocks processes block hash requests and sends inventory
 func HandleGetBlocks(request []byte, chain *blockchain.BlockChain) {
-	var buff bytes.Buffer
 	var payload GetBlocks
-
-	buff.Write(request[commandLength:])
-	dec := gob.NewDecoder(&buff)
-	err := dec.Decode(&payload)
-	if err != nil {
-		log.Panic(err)
+	if err := decodePayload(request, &payload); err != nil {
+		logger.Error("Dropped malformed message", "command", "getblocks", "err", err)
+		return
 	}
 
-	// Send inventory of all our block hashes
-	blocks := chain.GetBlockHashes()
+	// Send the hashes after the last block we share with the requester, oldest first
+	// (a version 1 peer sends no locator and gets our chain from genesis)
+	blocks := chain.HashesAfterLocator(payload.Locator, maxBlocksPerInv)
+	if len(blocks) == 0 {
+		return // The requester is already up to date with us
+	}
 	SendInv(payload.AddrFrom, "block", blocks)
 }
 
 // HandleGetData processes requests for specific data (blocks or transactions)
 func HandleGetData(request []byte, chain *blockchain.BlockChain) {
-	var buff bytes.Buffer
 	var payload GetData
-
-	buff.Write(request[commandLength:])
-	dec := gob.NewDecoder(&buff)
-	err := dec.Decode(&payload)
-	if err != nil {
-		log.Panic(err)
+	if err := decodePayload(request, &payload); err != nil {
+		logger.Error("Dropped malformed message", "command", "getdata", "err", err)
+		return
 	}
 
 	// Send the requested block
@@ -368,170 +614,566 @@ func HandleGetData(request []byte, chain *blockchain.BlockChain) {
 		if err != nil {
 			return // Block not found
 		}
+		if block.IsPruned() {
+			return // Peers can't verify a block without its transactions
+		}
 		SendBlock(payload.AddrFrom, &block)
 	}
 
 	// Send requested transaction from memory pool
 	if payload.Type == "tx" {
 		txID := hex.EncodeToString(payload.ID)
-		tx := memoryPool[txID]
-		SendTx(payload.AddrFrom, &tx)
+		if tx, ok := memoryPool.Get(txID); ok {
+			SendTx(payload.AddrFrom, &tx)
+		}
 	}
 }
 
 // HandleTx processes incoming transactions and adds them to the memory pool
-func HandleTx(request []byte, chain *blockchain.BlockChain) {
-	var buff bytes.Buffer
+// The verdict is written to reply as a "txack" message before relaying or mining,
+// so the sender doesn't wait on a block being mined
+func HandleTx(request []byte, chain *blockchain.BlockChain, reply io.Writer) {
 	var payload Tx
+	if err := decodePayload(request, &payload); err != nil {
+		logger.Error("Dropped malformed message", "command", "tx", "err", err)
+		return
+	}
 
-	buff.Write(request[commandLength:])
-	dec := gob.NewDecoder(&buff)
-	err := dec.Decode(&payload)
+	tx, err := blockchain.DeserializeTransactionE(payload.Transaction)
 	if err != nil {
-		log.Panic(err)
+		logger.Error("Dropped malformed transaction", "from", payload.AddrFrom, "err", err)
+		return
 	}
+	metrics.IncTransactionsReceived()
 
-	txData := payload.Transaction
-	tx := blockchain.DeserializeTransaction(txData)
+	isNew, reason := admitTx(chain, &tx)
+	if reason != "" {
+		sendTxAck(reply, TxAck{TxID: tx.ID, Reason: reason})
+		return
+	}
+	sendTxAck(reply, TxAck{TxID: tx.ID, Accepted: true})
 
-	// Add to the memory pool (unconfirmed transactions)
-	memoryPool[hex.EncodeToString(tx.ID)] = tx
-	fmt.Printf("%s, %d", nodeAddress, len(memoryPool))
+	if isNew {
+		relayTx(chain, &tx, payload.AddrFrom)
+	}
+}
 
-	// If we're the central node, broadcast to all other nodes
-	if nodeAddress == KnownNodes[0] {
-		for _, node := range KnownNodes {
-			if node != nodeAddress && node != payload.AddrFrom {
-				SendInv(node, "tx", [][]byte{tx.ID})
-			}
+// admitTx verifies a transaction and adds it to the memory pool
+// Returns why it was rejected, or "" once it is in the pool; isNew is false when the
+// pool already held it
+func admitTx(chain *blockchain.BlockChain, tx *blockchain.Transaction) (isNew bool, reason string) {
+	// The pool, the UTXO set and the index all key on the ID, so it must be the transaction's own
+	if err := tx.CheckID(); err != nil {
+		return false, err.Error()
+	}
+
+	// A resubmitted transaction is already verified and relayed: nothing left to do
+	txID := hex.EncodeToString(tx.ID)
+	if _, ok := memoryPool.Get(txID); ok {
+		logger.Debug("Transaction is already in the memory pool", "txid", txID)
+		return false, ""
+	}
+
+	// Only verified transactions enter the pool
+	if tx.IsCoinbase() {
+		return false, "coinbase transactions are only valid inside blocks"
+	}
+	if _, err := chain.FindTransaction(tx.ID); err == nil {
+		return false, "already confirmed on chain"
+	}
+	if err := tx.CheckInputs(); err != nil {
+		return false, err.Error()
+	}
+	if !chain.VerifyTransaction(tx) {
+		return false, "verification failed: unknown inputs or bad signature"
+	}
+	// A valid signature over an output the chain already spent is still a double spend
+	if err := (blockchain.UTXOSet{Blockchain: chain}).CheckUnspent(tx); err != nil {
+		return false, err.Error()
+	}
+	fee, err := chain.Fee(tx)
+	if err != nil || fee < 0 {
+		return false, "outputs spend more than the inputs provide"
+	}
+	if !blockchain.FitsInBlock([]*blockchain.Transaction{tx}) {
+		return false, fmt.Sprintf("too large to fit in a block of %d bytes", blockchain.MaxBlockSize)
+	}
+
+	// A transaction spending outputs a pooled one already spends must outbid it (replace-by-fee)
+	conflicts := memoryPool.Conflicts(tx)
+	if reason := checkReplacement(chain, fee, conflicts); reason != "" {
+		return false, reason
+	}
+
+	// Add to the memory pool (unconfirmed transactions), on disk too so a restart keeps it
+	// Only relay transactions we haven't seen yet, otherwise gossip would loop forever
+	evicted, err := memoryPool.Replace(txID, *tx, fee, chain.GetBestHeight(), conflicts)
+	if errors.Is(err, ErrAlreadyPooled) {
+		return false, "" // Another connection pooled it first
+	} else if err != nil {
+		return false, err.Error()
+	}
+	for id, old := range conflicts {
+		if err := chain.DeleteMempoolTx(old.ID); err != nil {
+			logger.Error("Could not remove transaction from disk", "txid", id, "err", err)
 		}
-	} else {
-		// If we're a mining node and have enough transactions, mine a block
-		if len(memoryPool) >= 2 && len(mineAddress) > 0 {
-			MineTx(chain)
+		logger.Info("Replaced transaction by fee", "replaced", id, "by", txID)
+	}
+	evictMempoolTxs(chain, evicted, txID)
+	if err := chain.SaveMempoolTx(tx); err != nil {
+		logger.Error("Could not persist transaction", "txid", txID, "err", err)
+	}
+	logger.Info("Added transaction to the memory pool", "txid", txID, "poolSize", memoryPool.Len())
+
+	// Shed the cheapest transactions if the pool has grown past the memory watermark
+	GuardMempoolMemory(chain)
+	return true, ""
+}
+
+// checkReplacement decides whether a transaction paying fee may evict the pooled
+// transactions it conflicts with: it must pay strictly more than all of them together
+// Returns why the replacement is refused, or "" when it may go ahead
+func checkReplacement(chain *blockchain.BlockChain, fee int, conflicts map[string]blockchain.Transaction) string {
+	if len(conflicts) == 0 {
+		return ""
+	}
+
+	replacedFees := 0
+	for _, old := range conflicts {
+		oldFee, err := chain.Fee(&old)
+		if err != nil {
+			continue // Its inputs are gone; it can't be mined anyway
 		}
+		replacedFees += oldFee
+	}
+	if fee <= replacedFees {
+		return fmt.Sprintf("double spends a pooled transaction: replacement fee %d must exceed %d", fee, replacedFees)
+	}
+	return ""
+}
+
+// relayTx passes a newly pooled transaction on to a few random peers (except the one
+// it came from, if any) and mines a block when this node mines and the pool is full enough
+func relayTx(chain *blockchain.BlockChain, tx *blockchain.Transaction, addrFrom string) {
+	for _, node := range gossipPeers(addrFrom) {
+		SendInv(node, "tx", [][]byte{tx.ID})
+	}
+
+	// If we're a mining node and have enough transactions, mine a block
+	if memoryPool.Len() >= 2 && len(mineAddress) > 0 {
+		MineTx(chain)
+	}
+}
+
+// sendTxAck answers a "tx" message on the connection it arrived on
+// Senders using plain SendTx have already hung up, so write errors are ignored
+func sendTxAck(reply io.Writer, ack TxAck) {
+	if !ack.Accepted {
+		logger.Warn("Rejected transaction", "txid", hex.EncodeToString(ack.TxID), "reason", ack.Reason)
 	}
+	response := append(CmdToBytes("txack"), GobEncode(ack)...)
+	_, _ = reply.Write(response)
 }
 
 // MineTx mines a new block with transactions from the memory pool
 func MineTx(chain *blockchain.BlockChain) {
 	var txs []*blockchain.Transaction
 
+	// The coinbase (mining reward) counts towards the block size like any transaction
+	// It is rebuilt below to claim the fees; the larger value takes a few bytes at most,
+	// well within the slack FitsInBlock leaves
+	height := chain.GetBestHeight() + 1
+	txs = append(txs, blockchain.CoinbaseTx(mineAddress, mineTag, height, 0))
+
+	// Best-paying transactions first, so those left out by the size limit pay the least
+	var pool []blockchain.Transaction
+	for _, tx := range memoryPool.Snapshot() {
+		pool = append(pool, tx)
+	}
+	sort.Slice(pool, func(i, j int) bool {
+		return feeRate(chain, &pool[i]) > feeRate(chain, &pool[j])
+	})
+
 	// Collect valid transactions from the memory pool
-	for id := range memoryPool {
-		fmt.Printf("Tx: %s\n", memoryPool[id].ID)
-		tx := memoryPool[id]
-		if chain.VerifyTransaction(&tx) {
-			txs = append(txs, &tx)
+	// The first transaction spending an output wins; later ones conflicting with it are left out
+	spent := make(map[string]bool)
+	utxoSet := blockchain.UTXOSet{Blockchain: chain}
+	for i := range pool {
+		tx := pool[i]
+		logger.Debug("Considering transaction for the block", "txid", hex.EncodeToString(tx.ID))
+		if !chain.VerifyTransaction(&tx) {
+			continue
+		}
+		if err := utxoSet.CheckUnspent(&tx); err != nil {
+			logger.Info("Skipping transaction", "txid", hex.EncodeToString(tx.ID), "err", err)
+			continue
+		}
+		if conflictsWith(&tx, spent) {
+			logger.Info("Skipping transaction: it spends an output already spent in this block", "txid", hex.EncodeToString(tx.ID))
+			continue
+		}
+		if !blockchain.FitsInBlock(append(txs, &tx)) {
+			logger.Debug("Leaving transaction for a later block: the block is full", "txid", hex.EncodeToString(tx.ID))
+			continue
 		}
+		for _, in := range tx.Inputs {
+			spent[in.Outpoint()] = true
+		}
+		txs = append(txs, &tx)
 	}
 
-	if len(txs) == 0 {
-		fmt.Println("All Transactions are invalid")
+	if len(txs) == 1 {
+		logger.Warn("All transactions are invalid")
 		return
 	}
 
-	// Add coinbase transaction (mining reward)
-	cbTx := blockchain.CoinbaseTx(mineAddress, "")
-	txs = append(txs, cbTx)
-
-	// Mine the new block
-	newBlock := chain.MineBlock(txs)
+	// Claim the fees of the transactions picked (see VerifyCoinbase)
+	fees, err := chain.Fees(txs[1:])
+	if err != nil {
+		logger.Error("Could not total the block's fees", "err", err)
+		return
+	}
+	txs[0] = blockchain.CoinbaseTx(mineAddress, mineTag, height, fees)
+
+	// Mine the new block, abandoning it if a competing block arrives meanwhile
+	ctx, cancel := context.WithCancel(context.Background())
+	done := startMining(cancel)
+	newBlock, err := chain.MineBlockWithContext(ctx, txs)
+	done()
+	if errors.Is(err, blockchain.ErrMiningCancelled) {
+		// Nothing was stored; the transactions stay in the pool for the next attempt
+		logger.Info("Mining cancelled: a competing block was received")
+		return
+	}
+	if errors.Is(err, blockchain.ErrStaleTip) {
+		// A block arrived just as the proof of work was found; the transactions stay in the pool
+		logger.Info("Mined block discarded: the chain tip moved", "err", err)
+		return
+	}
+	blockchain.Handle(err)
 
-	// Update UTXO set
+	// Spend the block's inputs and add its outputs; a full reindex would also fail on a pruned chain
 	UTXOSet := blockchain.UTXOSet{Blockchain: chain}
-	UTXOSet.Reindex()
+	if err := UTXOSet.Update(newBlock); err != nil {
+		logger.Error("Could not update the UTXO set with the mined block, run reindexutxo", "hash", hex.EncodeToString(newBlock.Hash), "err", err)
+	}
 
-	fmt.Println("New Block mined")
+	logger.Info("New block mined", "hash", hex.EncodeToString(newBlock.Hash), "height", newBlock.Height)
 
 	// Remove mined transactions from the memory pool
-	for _, tx := range txs {
-		txID := hex.EncodeToString(tx.ID)
-		delete(memoryPool, txID)
-	}
+	confirmMempoolTxs(chain, newBlock)
 
-	// Broadcast new block to network
-	for _, node := range KnownNodes {
-		if node != nodeAddress {
-			SendInv(node, "block", [][]byte{newBlock.Hash})
-		}
+	// Announce the new block to a few random peers; gossip carries it the rest of the way
+	for _, node := range gossipPeers() {
+		SendInv(node, "block", [][]byte{newBlock.Hash})
 	}
 
 	// If more transactions remain, continue mining
-	if len(memoryPool) > 0 {
+	if memoryPool.Len() > 0 {
 		MineTx(chain)
 	}
 }
 
+// conflictsWith reports whether tx spends any output in spent (or the same output twice)
+func conflictsWith(tx *blockchain.Transaction, spent map[string]bool) bool {
+	seen := make(map[string]bool)
+	for _, in := range tx.Inputs {
+		outpoint := in.Outpoint()
+		if spent[outpoint] || seen[outpoint] {
+			return true
+		}
+		seen[outpoint] = true
+	}
+	return false
+}
+
+// startMining registers cancel as an in-flight mining attempt, so StopMining can cancel it
+// The function returned ends the attempt: it unregisters and cancels this attempt only
+func startMining(cancel context.CancelFunc) func() {
+	miningMu.Lock()
+	defer miningMu.Unlock()
+
+	attempt := nextMiningAttempt
+	nextMiningAttempt++
+	miningAttempts[attempt] = cancel
+
+	return func() {
+		miningMu.Lock()
+		delete(miningAttempts, attempt)
+		miningMu.Unlock()
+		cancel()
+	}
+}
+
+// StopMining cancels every in-flight mining attempt
+func StopMining() {
+	miningMu.Lock()
+	defer miningMu.Unlock()
+
+	for attempt, cancel := range miningAttempts {
+		cancel()
+		delete(miningAttempts, attempt)
+	}
+}
+
 // HandleVersion processes version messages during node handshake
 func HandleVersion(request []byte, chain *blockchain.BlockChain) {
-	var buff bytes.Buffer
 	var payload Version
-
-	buff.Write(request[commandLength:])
-	dec := gob.NewDecoder(&buff)
-	err := dec.Decode(&payload)
-	if err != nil {
-		log.Panic(err)
+	if err := decodePayload(request, &payload); err != nil {
+		logger.Error("Dropped malformed message", "command", "version", "err", err)
+		return
 	}
 
+	// From now on talk to this peer in the newest protocol it understands
+	setPeerVersion(payload.AddrFrom, payload.Version)
+
 	bestHeight := chain.GetBestHeight()
 	otherHeight := payload.BestHeight
 
 	// Determine who has a longer chain and sync accordingly
 	if bestHeight < otherHeight {
-		SendGetBlocks(payload.AddrFrom) // Request blocks if another node is ahead
+		SendGetBlocks(payload.AddrFrom, chain) // Request blocks if another node is ahead
 	} else if bestHeight > otherHeight {
 		SendVersion(payload.AddrFrom, chain) // Send our version if we're ahead
 	}
 
 	// Add a new node to known nodes if not already known
-	if !NodeIsKnown(payload.AddrFrom) {
-		KnownNodes = append(KnownNodes, payload.AddrFrom)
-	}
+	addKnownNodes(payload.AddrFrom)
 }
 
 // HandleInv processes inventory messages (advertisements of available data)
 func HandleInv(request []byte, chain *blockchain.BlockChain) {
-	var buff bytes.Buffer
 	var payload Inv
+	if err := decodePayload(request, &payload); err != nil {
+		logger.Error("Dropped malformed message", "command", "inv", "err", err)
+		return
+	}
+
+	logger.Debug("Received inventory", "type", payload.Type, "count", len(payload.Items), "from", payload.AddrFrom)
 
-	buff.Write(request[commandLength:])
-	dec := gob.NewDecoder(&buff)
-	err := dec.Decode(&payload)
+	// An empty inventory has nothing to request (and no Items[0] to read)
+	if len(payload.Items) == 0 {
+		logger.Warn("Ignoring empty inventory", "type", payload.Type, "from", payload.AddrFrom)
+		return
+	}
+
+	switch payload.Type {
+	case "block":
+		// Skip blocks we already have, so relayed announcements stop once everyone has them
+		var missing [][]byte
+		for _, hash := range payload.Items {
+			if !chain.HasBlock(hash) {
+				missing = append(missing, hash)
+			}
+		}
+		if len(missing) == 0 {
+			return
+		}
+		// Request first block in inventory and queue the rest
+		// A full inventory means the peer has more after it (see HandleGetBlocks)
+		blockHash := missing[0]
+		setBlocksInTransit(missing[1:])
+		setMoreBlocksAvailable(len(payload.Items) >= maxBlocksPerInv)
+		SendGetData(payload.AddrFrom, "block", blockHash)
+
+	case "tx":
+		// Request every transaction we don't have yet
+		for _, txID := range payload.Items {
+			if _, ok := memoryPool.Get(hex.EncodeToString(txID)); !ok {
+				SendGetData(payload.AddrFrom, "tx", txID)
+			}
+		}
+
+	default:
+		logger.Warn("Ignoring inventory of unknown type", "type", payload.Type, "from", payload.AddrFrom)
+	}
+}
+
+// ============================================================================
+// MEMORY POOL GUARD & FEE ESTIMATION
+// ============================================================================
+
+// feeRate returns the fee a transaction pays per serialized byte
+// Transactions whose inputs can't be resolved are treated as paying nothing
+func feeRate(chain *blockchain.BlockChain, tx *blockchain.Transaction) float64 {
+	fee, err := chain.Fee(tx)
 	if err != nil {
-		log.Panic(err)
+		fee = 0
+	}
+	return float64(fee) / float64(len(tx.Serialize()))
+}
+
+// confirmMempoolTxs removes the transactions confirmed by block from the memory pool
+// and records how many blocks each one waited, so the fee estimator learns from it
+func confirmMempoolTxs(chain *blockchain.BlockChain, block *blockchain.Block) {
+	for _, tx := range block.Transactions {
+		txID := hex.EncodeToString(tx.ID)
+		entryHeight, ok := memoryPool.EntryHeight(txID)
+		if !ok {
+			continue // Not one of ours (e.g. the coinbase)
+		}
+
+		feeEstimator.RecordConfirmation(feeRate(chain, tx), block.Height-entryHeight)
+		removeMempoolTx(chain, txID)
 	}
+}
 
-	fmt.Printf("Received inventory with %d %s\n", len(payload.Items), payload.Type)
+// removeMempoolTx drops a transaction from the memory pool and its on-disk copy
+func removeMempoolTx(chain *blockchain.BlockChain, txID string) {
+	tx, ok := memoryPool.Delete(txID)
+	if !ok {
+		return
+	}
 
-	// Process block inventory
-	if payload.Type == "block" {
-		blocksInTransit = payload.Items
+	if err := chain.DeleteMempoolTx(tx.ID); err != nil {
+		logger.Error("Could not remove transaction from disk", "txid", txID, "err", err)
+	}
+}
 
-		// Request first block in inventory
-		blockHash := payload.Items[0]
-		SendGetData(payload.AddrFrom, "block", blockHash)
+// evictMempoolTxs drops from disk the transactions the memory pool evicted to make room for txID
+func evictMempoolTxs(chain *blockchain.BlockChain, evicted []string, txID string) {
+	dropMempoolTxs(chain, evicted)
+	if len(evicted) > 0 {
+		logger.Info("Memory pool full: evicted the cheapest transactions", "evicted", len(evicted), "for", txID)
+	}
+}
 
-		// Remove the requested block from a transit list
-		newInTransit := [][]byte{}
-		for _, b := range blocksInTransit {
-			if bytes.Compare(b, blockHash) != 0 {
-				newInTransit = append(newInTransit, b)
+// dropMempoolTxs removes from disk the transactions already evicted from the memory pool
+func dropMempoolTxs(chain *blockchain.BlockChain, evicted []string) {
+	for _, id := range evicted {
+		raw, err := hex.DecodeString(id)
+		if err == nil {
+			err = chain.DeleteMempoolTx(raw)
+		}
+		if err != nil {
+			logger.Error("Could not remove transaction from disk", "txid", id, "err", err)
+		}
+	}
+}
+
+// loadMempool refills the memory pool with the transactions persisted before a restart
+// Transactions confirmed (or invalidated) while the node was down are dropped
+func loadMempool(chain *blockchain.BlockChain) {
+	txs, err := chain.LoadMempool()
+	if err != nil {
+		logger.Error("Could not load the memory pool", "err", err)
+		return
+	}
+
+	height := chain.GetBestHeight()
+	for _, tx := range txs {
+		_, err := chain.FindTransaction(tx.ID)
+		confirmed := err == nil
+		if confirmed || !chain.VerifyTransaction(&tx) {
+			if err := chain.DeleteMempoolTx(tx.ID); err != nil {
+				logger.Error("Could not remove transaction from disk", "txid", hex.EncodeToString(tx.ID), "err", err)
 			}
+			continue
 		}
-		blocksInTransit = newInTransit
+
+		txID := hex.EncodeToString(tx.ID)
+		fee, err := chain.Fee(&tx)
+		if err != nil {
+			fee = 0
+		}
+		evicted, err := memoryPool.Add(txID, tx, fee, height)
+		if errors.Is(err, ErrMempoolFull) {
+			// Smaller limit than before the restart: this one lost its place for good
+			if err := chain.DeleteMempoolTx(tx.ID); err != nil {
+				logger.Error("Could not remove transaction from disk", "txid", txID, "err", err)
+			}
+			continue
+		}
+		evictMempoolTxs(chain, evicted, txID)
 	}
+	logger.Info("Loaded the memory pool", "transactions", memoryPool.Len())
+}
 
-	// Process transaction inventory
-	if payload.Type == "tx" {
-		txID := payload.Items[0]
+// EstimateSmartFee returns the fee rate that historically got transactions
+// confirmed within targetBlocks blocks on this node
+func EstimateSmartFee(targetBlocks int) (float64, error) {
+	return feeEstimator.EstimateSmartFee(targetBlocks)
+}
+
+// loadMempoolMemoryLimit reads the memory watermark from MEMPOOL_MEMORY_LIMIT_MB
+// Falls back to the default when the variable is unset or not a number
+func loadMempoolMemoryLimit() uint64 {
+	limit := uint64(defaultMempoolMemoryLimit)
+
+	if value := os.Getenv("MEMPOOL_MEMORY_LIMIT_MB"); value != "" {
+		mb, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			logger.Warn("Invalid MEMPOOL_MEMORY_LIMIT_MB, using the default", "value", value, "defaultMiB", defaultMempoolMemoryLimit)
+		} else {
+			limit = mb
+		}
+	}
+	return limit << 20 // MiB -> bytes
+}
+
+// GuardMempoolMemory evicts the lowest fee-rate transactions from the memory pool
+// while the pooled transactions take more bytes than the configured watermark
+func GuardMempoolMemory(chain *blockchain.BlockChain) {
+	size := memoryPool.Size()
+	if uint64(size) <= mempoolMemoryLimit {
+		return // Under the watermark, nothing to do
+	}
+
+	evicted := memoryPool.Shrink(int(mempoolMemoryLimit))
+	dropMempoolTxs(chain, evicted)
+
+	logger.Warn("Memory pool above the watermark: shed the cheapest transactions",
+		"limitMiB", mempoolMemoryLimit>>20, "transactions", len(evicted), "bytes", size-memoryPool.Size())
+}
+
+// ============================================================================
+// GOSSIP PROPAGATION
+// ============================================================================
+
+// loadFanout reads the relay fan-out from PROPAGATION_FANOUT
+// Falls back to the default when the variable is unset or not a positive number
+func loadFanout() int {
+	n := defaultFanout
+
+	if value := os.Getenv("PROPAGATION_FANOUT"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			logger.Warn("Invalid PROPAGATION_FANOUT, using the default", "value", value, "default", defaultFanout)
+		} else {
+			n = parsed
+		}
+	}
+	return n
+}
+
+// gossipPeers picks up to fanout random known nodes to relay to
+// Ourselves and the given addresses (e.g. the peer we heard it from) are never picked
+func gossipPeers(exclude ...string) []string {
+	return pickPeers(knownNodes(), fanout, append(exclude, nodeAddress)...)
+}
+
+// pickPeers returns up to n distinct random nodes, skipping the excluded addresses
+func pickPeers(nodes []string, n int, exclude ...string) []string {
+	skip := make(map[string]bool)
+	for _, addr := range exclude {
+		skip[addr] = true
+	}
 
-		// Request transaction if we don't have it
-		if memoryPool[hex.EncodeToString(txID)].ID == nil {
-			SendGetData(payload.AddrFrom, "tx", txID)
+	var candidates []string
+	for _, node := range nodes {
+		if !skip[node] {
+			candidates = append(candidates, node)
+			skip[node] = true // KnownNodes may hold duplicates
 		}
 	}
+
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates
 }
 
 // ============================================================================
@@ -539,22 +1181,52 @@ func HandleInv(request []byte, chain *blockchain.BlockChain) {
 // ============================================================================
 
 // HandleConnection processes incoming network connections
+// A handler that panics (e.g. on data a peer crafted to trip a blockchain.Handle call)
+// only loses its own connection: the panic is logged and the node keeps serving
 func HandleConnection(conn net.Conn, chain *blockchain.BlockChain) {
-	req, err := ioutil.ReadAll(conn)
 	defer conn.Close()
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Dropped connection after a handler panic", "from", conn.RemoteAddr().String(), "panic", r)
+			logger.Debug("Handler panic stack", "stack", string(debug.Stack()))
+		}
+	}()
 
+	// Read a single bounded message; oversized, truncated or too slow ones are dropped
+	if err := conn.SetDeadline(time.Now().Add(connectionTimeout)); err != nil {
+		logger.Warn("Dropped connection", "from", conn.RemoteAddr().String(), "err", err)
+		return
+	}
+	req, framed, err := readMessage(conn)
 	if err != nil {
-		log.Panic(err)
+		logger.Warn("Dropped message", "from", conn.RemoteAddr().String(), "err", err)
+		return
+	}
+	if len(req) < commandLength {
+		logger.Warn("Dropped message: too short", "from", conn.RemoteAddr().String())
+		return
+	}
+
+	// The reply gets a full window of its own, however long the request took to arrive
+	if err := conn.SetDeadline(time.Now().Add(connectionTimeout)); err != nil {
+		logger.Warn("Dropped connection", "from", conn.RemoteAddr().String(), "err", err)
+		return
+	}
+
+	// Replies on this connection use the same form as the request
+	var reply io.Writer = conn
+	if framed {
+		reply = frameWriter{conn}
 	}
 
 	// Extract and process command
 	command := BytesToCmd(req[:commandLength])
-	fmt.Printf("Received %s command\n", command)
+	logger.Debug("Received command", "command", command, "from", conn.RemoteAddr().String())
 
 	// Route to the appropriate handler based on command
 	switch command {
 	case "addr":
-		HandleAddr(req)
+		HandleAddr(req, chain)
 	case "block":
 		HandleBlock(req, chain)
 	case "inv":
@@ -564,14 +1236,27 @@ func HandleConnection(conn net.Conn, chain *blockchain.BlockChain) {
 	case "getdata":
 		HandleGetData(req, chain)
 	case "tx":
-		HandleTx(req, chain)
+		HandleTx(req, chain, reply)
 	case "version":
 		HandleVersion(req, chain)
+	case "ping":
+		HandlePing(req, reply)
+	case "getmempool":
+		HandleGetMempool(req, chain, reply)
+	case "getheaders":
+		HandleGetHeaders(req, chain, reply)
+	case "getproof":
+		HandleGetProof(req, chain, reply)
 	default:
-		fmt.Println("Unknown command")
+		logger.Warn("Unknown command", "command", command)
 	}
 }
 
+// decodePayload gob-decodes the body of a message (everything after the command) into payload
+func decodePayload(request []byte, payload interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(request[commandLength:])).Decode(payload)
+}
+
 // GobEncode serializes data structures for network transmission
 func GobEncode(data interface{}) []byte {
 	var buff bytes.Buffer
@@ -586,6 +1271,9 @@ func GobEncode(data interface{}) []byte {
 
 // NodeIsKnown checks if a node address is already in our known nodes list
 func NodeIsKnown(addr string) bool {
+	nodesMu.RLock()
+	defer nodesMu.RUnlock()
+
 	for _, node := range KnownNodes {
 		if node == addr {
 			return true
@@ -594,17 +1282,167 @@ func NodeIsKnown(addr string) bool {
 	return false
 }
 
-// CloseDB gracefully shuts down the database on process termination
-func CloseDB(chain *blockchain.BlockChain) {
+// knownNodes returns a copy of the known nodes list that is safe to range over
+func knownNodes() []string {
+	nodesMu.RLock()
+	defer nodesMu.RUnlock()
+
+	return append([]string{}, KnownNodes...)
+}
+
+// addKnownNodes appends the addresses we don't know yet to the known nodes list
+// The list is saved to disk when it changes
+func addKnownNodes(addrs ...string) {
+	if addNodes(addrs...) {
+		savePeers()
+	}
+}
+
+// addNodes appends the unknown addresses and reports whether the list changed
+func addNodes(addrs ...string) bool {
+	nodesMu.Lock()
+	defer nodesMu.Unlock()
+
+	changed := false
+	for _, addr := range addrs {
+		known := false
+		for _, node := range KnownNodes {
+			if node == addr {
+				known = true
+				break
+			}
+		}
+		if !known {
+			KnownNodes = append(KnownNodes, addr)
+			changed = true
+		}
+	}
+	metrics.SetKnownPeers(len(KnownNodes))
+	return changed
+}
+
+// removeKnownNode drops an address from the known nodes list, on disk too
+func removeKnownNode(addr string) {
+	nodesMu.Lock()
+	var updatedNodes []string
+	for _, node := range KnownNodes {
+		if node != addr {
+			updatedNodes = append(updatedNodes, node)
+		}
+	}
+	changed := len(updatedNodes) != len(KnownNodes)
+	KnownNodes = updatedNodes
+	metrics.SetKnownPeers(len(KnownNodes))
+	nodesMu.Unlock()
+
+	if changed {
+		savePeers()
+	}
+}
+
+// setBlocksInTransit replaces the list of blocks still to be downloaded
+func setBlocksInTransit(hashes [][]byte) {
+	transitMu.Lock()
+	defer transitMu.Unlock()
+
+	blocksInTransit = append([][]byte{}, hashes...)
+}
+
+// nextBlockInTransit pops the next block to download, if any
+func nextBlockInTransit() ([]byte, bool) {
+	transitMu.Lock()
+	defer transitMu.Unlock()
+
+	if len(blocksInTransit) == 0 {
+		return nil, false
+	}
+	hash := blocksInTransit[0]
+	blocksInTransit = blocksInTransit[1:]
+	return hash, true
+}
+
+// setMoreBlocksAvailable records whether the peer has blocks beyond the current download
+func setMoreBlocksAvailable(more bool) {
+	transitMu.Lock()
+	defer transitMu.Unlock()
+
+	moreBlocksAvailable = more
+}
+
+// takeMoreBlocksAvailable reports (and clears) whether another batch should be requested
+func takeMoreBlocksAvailable() bool {
+	transitMu.Lock()
+	defer transitMu.Unlock()
+
+	more := moreBlocksAvailable
+	moreBlocksAvailable = false
+	return more
+}
+
+// blocksInTransitCount returns how many blocks are still to be downloaded
+func blocksInTransitCount() int {
+	transitMu.Lock()
+	defer transitMu.Unlock()
+
+	return len(blocksInTransit)
+}
+
+// waitForShutdown blocks until the process is told to terminate, then calls stop,
+// which ends runServer
+func waitForShutdown(stop context.CancelFunc) {
 	d := death.NewDeath(syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 
 	d.WaitForDeathWithFunc(func() {
-		defer os.Exit(1)
-		defer runtime.Goexit()
-		chain.Database.Close()
+		logger.Info("Shutting down")
+		stop()
 	})
 }
 
+// shutdownNode finishes what the node was doing once it stopped accepting connections:
+// it abandons mining, lets in-flight API requests, connection handlers and background
+// loops finish, and persists the memory pool
+// Returns false if workers were still running after handlerShutdownTimeout; they may
+// still be using the database, so the memory pool is not saved and the caller must
+// leave the database open (the process is about to exit anyway)
+func shutdownNode(chain *blockchain.BlockChain, httpServer *http.Server, workers *sync.WaitGroup) bool {
+	// A handler stuck in proof of work would otherwise hold up the shutdown
+	StopMining()
+
+	if httpServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		if err := httpServer.Shutdown(ctx); err != nil {
+			logger.Error("HTTP API shutdown failed", "err", err)
+		}
+		cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(handlerShutdownTimeout):
+		logger.Warn("Workers still running, leaving the database as it is", "waited", handlerShutdownTimeout)
+		return false
+	}
+
+	saveMempool(chain)
+	logger.Info("Node stopped")
+	return true
+}
+
+// saveMempool writes every pooled transaction to disk, so the next start picks them up
+// admitTx already persists each transaction; this catches any write that failed then
+func saveMempool(chain *blockchain.BlockChain) {
+	for id, tx := range memoryPool.Snapshot() {
+		if err := chain.SaveMempoolTx(&tx); err != nil {
+			logger.Error("Could not persist transaction", "txid", id, "err", err)
+		}
+	}
+}
+
 // ============================================================================
 // MAIN NETWORK SERVER ENTRY POINT
 // ============================================================================
@@ -612,36 +1450,118 @@ func CloseDB(chain *blockchain.BlockChain) {
 // StartServer initializes and runs the P2P network node
 // nodeID: Port number for this node (e.g., "3000", "3001")
 // minerAddress: If not empty, this node will mine blocks to this address
-func StartServer(nodeID, minerAddress string) {
-	// Set the node address and mining address
+// minerTag: Tag put in the coinbase of every block this node mines (see ValidateMinerTag)
+// httpPort: If not empty, the JSON API (see http_api.go) is served on this port too
+// Runs until SIGINT or SIGTERM, then shuts down cleanly and returns nil
+func StartServer(nodeID, minerAddress, minerTag, httpPort string) error {
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	// Set up a graceful shutdown: a signal cancels ctx, ending runServer
+	go waitForShutdown(stop)
+
+	return runServer(ctx, nodeID, minerAddress, minerTag, httpPort)
+}
+
+// runServer runs the node like StartServer until ctx is cancelled
+// Returns ErrShutdownTimeout, with the database left open, if the node's workers did not
+// stop in time (see shutdownNode)
+func runServer(ctx context.Context, nodeID, minerAddress, minerTag, httpPort string) error {
+	if err := blockchain.ValidateMinerTag(minerTag); err != nil {
+		return err
+	}
+
+	// Set the node address, mining address and miner tag
 	nodeAddress = fmt.Sprintf("localhost:%s", nodeID)
 	mineAddress = minerAddress
+	mineTag = minerTag
 
 	// Start listening for incoming connections
 	ln, err := net.Listen(protocol, nodeAddress)
 	if err != nil {
-		log.Panic(err)
+		return fmt.Errorf("listening on %s: %w", nodeAddress, err)
 	}
 	defer ln.Close()
 
 	// Load or create a blockchain for this node
 	chain := blockchain.ContinueBlockChain(nodeID)
-	defer chain.Database.Close()
 
-	// Set up a graceful shutdown
-	go CloseDB(chain)
+	// Gauges read on every /metrics scrape
+	metrics.SetChainHeight(chain.GetBestHeight)
+	metrics.SetMempoolSize(memoryPool.Len)
+	metrics.SetKnownPeers(len(knownNodes()))
+
+	// Serve the HTTP API next to the P2P listener
+	var httpServer *http.Server
+	if httpPort != "" {
+		httpServer = NewHTTPServer(httpPort, chain)
+		go func() {
+			logger.Info("HTTP API listening", "addr", httpServer.Addr)
+			if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("HTTP API stopped", "err", err)
+			}
+		}()
+	}
 
-	// If this is the bootstrap node, broadcast our version
-	if nodeAddress != KnownNodes[0] {
-		SendVersion(KnownNodes[0], chain)
+	// Closing the listener ends the accept loop below
+	go func() {
+		<-ctx.Done()
+		if err := ln.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			logger.Error("Could not close the listener", "err", err)
+		}
+	}()
+
+	// Pick up the peers and unconfirmed transactions we had before the last shutdown
+	loadPeers(nodeID)
+	loadMempool(chain)
+
+	// Announce ourselves to every node we know (the bootstrap node, plus any saved peers)
+	for _, node := range knownNodes() {
+		if node != nodeAddress {
+			SendVersion(node, chain)
+		}
 	}
 
-	// Main server loop - accept and handle connections
+	// Everything that may touch the database, so the shutdown can wait for it
+	var workers sync.WaitGroup
+
+	// Keep checking that our peers are still alive
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		PingPeers(ctx, pingInterval)
+	}()
+
+	// Drop pooled transactions that are too old or can no longer be mined
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		SweepMempool(ctx, chain, mempoolSweepInterval)
+	}()
+
+	// Main server loop - accept and handle connections until the listener is closed
 	for {
 		conn, err := ln.Accept()
-		if err != nil {
-			log.Panic(err)
+		if errors.Is(err, net.ErrClosed) {
+			break
+		} else if err != nil {
+			logger.Error("Could not accept connection", "err", err)
+			continue
+		}
+		// Refuse peers over their rate limit, and everyone once all slots are taken
+		if !admitConnection(conn) {
+			continue
 		}
-		go HandleConnection(conn, chain) // Handle in goroutine for concurrency
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			defer releaseConnection()
+			HandleConnection(conn, chain) // Handle in goroutine for concurrency
+		}()
+	}
+
+	if !shutdownNode(chain, httpServer, &workers) {
+		return ErrShutdownTimeout
 	}
+	return chain.Database.Close()
 }
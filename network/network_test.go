@@ -0,0 +1,446 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-blockchain/blockchain"
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 12:40
+ */
+
+func TestAdmitTxRefusesASpentOutput(t *testing.T) {
+	sender, alice, bob, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	// Both spend the sender's only output; the one to Alice gets mined
+	toAlice := newTestTransfer(t, chain, sender, alice, 40, 1)
+	toBob := newTestTransfer(t, chain, sender, bob, 40, 1)
+	mineTestBlock(t, chain, miner, toAlice)
+
+	// Its signature is still valid, but the output it spends is gone
+	if !chain.VerifyTransaction(toBob) {
+		t.Fatal("the losing transaction should still verify on its own")
+	}
+	if isNew, reason := admitTx(chain, toBob); isNew || !strings.Contains(reason, blockchain.ErrOutputSpent.Error()) {
+		t.Errorf("admitTx = %v, %q; want the spent output refused", isNew, reason)
+	}
+	if _, pooled := memoryPool.Get(hex.EncodeToString(toBob.ID)); pooled {
+		t.Error("a transaction spending a spent output was pooled")
+	}
+
+	// A fresh transaction from the change is fine
+	fromChange := newTestTransfer(t, chain, sender, bob, 20, 1)
+	if isNew, reason := admitTx(chain, fromChange); !isNew || reason != "" {
+		t.Errorf("admitTx = %v, %q; want it pooled", isNew, reason)
+	}
+}
+
+func TestReplaceByFee(t *testing.T) {
+	sender, alice, bob := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+
+	// All of these spend the sender's only output
+	original := newTestTransfer(t, chain, sender, alice, 40, 2)
+	if isNew, reason := admitTx(chain, original); !isNew || reason != "" {
+		t.Fatalf("admitTx(original) = %v, %q; want it pooled", isNew, reason)
+	}
+	originalID := hex.EncodeToString(original.ID)
+
+	// Paying the same or less does not replace it
+	for _, fee := range []int{1, 2} {
+		lower := newTestTransfer(t, chain, sender, bob, 40, fee)
+		if isNew, reason := admitTx(chain, lower); isNew || !strings.Contains(reason, "replacement fee") {
+			t.Errorf("fee %d: admitTx = %v, %q; want a refused replacement", fee, isNew, reason)
+		}
+		if _, pooled := memoryPool.Get(hex.EncodeToString(lower.ID)); pooled {
+			t.Errorf("fee %d: the lower-fee replacement was pooled", fee)
+		}
+	}
+	if _, pooled := memoryPool.Get(originalID); !pooled {
+		t.Fatal("a refused replacement evicted the original")
+	}
+
+	// Paying more does, in memory and on disk
+	higher := newTestTransfer(t, chain, sender, bob, 40, 3)
+	if isNew, reason := admitTx(chain, higher); !isNew || reason != "" {
+		t.Fatalf("admitTx(higher fee) = %v, %q; want it pooled", isNew, reason)
+	}
+	if _, pooled := memoryPool.Get(originalID); pooled {
+		t.Error("the replaced transaction is still pooled")
+	}
+	if saved, err := chain.IsMempoolTx(original.ID); err != nil || saved {
+		t.Errorf("replaced transaction on disk = %v, %v; want it removed", saved, err)
+	}
+	if saved, err := chain.IsMempoolTx(higher.ID); err != nil || !saved {
+		t.Errorf("replacement on disk = %v, %v; want it saved", saved, err)
+	}
+}
+
+func TestMineTxSplitsAFullPoolAcrossBlocks(t *testing.T) {
+	miner, recipient := wallet.MakeWallet(), wallet.MakeWallet()
+	senders := make(map[*wallet.Wallet]int)
+	for i := 0; i < 30; i++ {
+		senders[wallet.MakeWallet()] = 100
+	}
+	chain := newTestChain(t, senders)
+
+	// A mining node with no peers to announce to, and blocks of the smallest allowed size
+	nodes, address, maxSize := KnownNodes, mineAddress, blockchain.MaxBlockSize
+	KnownNodes, mineAddress, blockchain.MaxBlockSize = nil, string(miner.Address()), 4<<10
+	t.Cleanup(func() { KnownNodes, mineAddress, blockchain.MaxBlockSize = nodes, address, maxSize })
+
+	var txs []*blockchain.Transaction
+	for sender := range senders {
+		tx := newTestTransfer(t, chain, sender, recipient, 10, 1)
+		if isNew, reason := admitTx(chain, tx); !isNew || reason != "" {
+			t.Fatalf("admitTx = %v, %q; want it pooled", isNew, reason)
+		}
+		txs = append(txs, tx)
+	}
+	if blockchain.FitsInBlock(txs) {
+		t.Fatal("the pool fits in one block; the test needs more or larger transactions")
+	}
+
+	MineTx(chain) // Keeps mining until the pool is empty
+
+	if n := memoryPool.Len(); n != 0 {
+		t.Errorf("%d transactions left in the pool", n)
+	}
+	if height := chain.GetBestHeight(); height < 2 {
+		t.Errorf("everything went into %d block(s), want a split", height)
+	}
+	for height := 1; height <= chain.GetBestHeight(); height++ {
+		block, err := chain.GetBlockByHeight(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size := len(block.Serialize()); size > blockchain.MaxBlockSize {
+			t.Errorf("block %d is %d bytes, limit %d", height, size, blockchain.MaxBlockSize)
+		}
+	}
+	for _, tx := range txs {
+		if _, err := chain.FindTransaction(tx.ID); err != nil {
+			t.Errorf("transaction %x was not mined: %v", tx.ID, err)
+		}
+	}
+}
+
+func TestMineTxOnAPrunedChainUpdatesTheUTXOSet(t *testing.T) {
+	sender, recipient, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+	for chain.GetBestHeight() < blockchain.MinPruneKeep+2 {
+		mineTestBlock(t, chain, miner)
+	}
+	if err := chain.Prune(blockchain.MinPruneKeep); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+
+	nodes, address := KnownNodes, mineAddress
+	KnownNodes, mineAddress = nil, string(miner.Address())
+	t.Cleanup(func() { KnownNodes, mineAddress = nodes, address })
+
+	tx := newTestTransfer(t, chain, sender, recipient, 30, 1)
+	if isNew, reason := admitTx(chain, tx); !isNew || reason != "" {
+		t.Fatalf("admitTx = %v, %q; want it pooled", isNew, reason)
+	}
+	MineTx(chain)
+
+	utxoSet := blockchain.UTXOSet{Blockchain: chain}
+	if err := utxoSet.CheckUnspent(tx); err == nil {
+		t.Error("the mined transaction's inputs are still in the UTXO set")
+	}
+	for w, want := range map[*wallet.Wallet]int{sender: 69, recipient: 30} {
+		if got, err := utxoSet.GetBalance(string(w.Address())); err != nil || got != want {
+			t.Errorf("balance %d, %v; want %d", got, err, want)
+		}
+	}
+}
+
+// handleTestRequest hands request to HandleConnection as a framed message and returns everything
+// the node wrote back before closing the connection
+func handleTestRequest(t *testing.T, chain *blockchain.BlockChain, request []byte) []byte {
+	t.Helper()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	if err := client.SetDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	go HandleConnection(server, chain)
+
+	_, _ = client.Write(frameMessage(request)) // The node may hang up before reading it all
+	reply, err := io.ReadAll(client)
+	if err != nil {
+		t.Fatalf("reading the reply: %v", err)
+	}
+	return reply
+}
+
+func TestGarbageMessagesDoNotStopTheNode(t *testing.T) {
+	chain := newTestChain(t, map[*wallet.Wallet]int{wallet.MakeWallet(): 100})
+
+	rng := rand.New(rand.NewSource(1))
+	noise := make([]byte, 256)
+	rng.Read(noise)
+
+	// Every command with a body that isn't gob, then bytes that aren't even a command
+	commands := []string{"addr", "block", "inv", "getblocks", "getdata", "tx", "version", "ping", "getmempool", "getheaders", "getproof"}
+	for _, command := range commands {
+		handleTestRequest(t, chain, append(CmdToBytes(command), noise...))
+	}
+	handleTestRequest(t, chain, noise)
+	handleTestRequest(t, chain, noise[:3])
+
+	// Still serving: a ping gets its pong
+	reply := handleTestRequest(t, chain, append(CmdToBytes("ping"), GobEncode(Ping{AddrFrom: "localhost:1"})...))
+	if len(reply) < messageHeaderLength+commandLength {
+		t.Fatalf("ping got a %d byte reply", len(reply))
+	}
+	if command := BytesToCmd(reply[messageHeaderLength : messageHeaderLength+commandLength]); command != "pong" {
+		t.Errorf("ping got %q, want pong", command)
+	}
+}
+
+func TestStopMiningCancelsEveryAttempt(t *testing.T) {
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+	doneFirst := startMining(cancelFirst)
+	doneSecond := startMining(cancelSecond)
+	defer doneFirst()
+
+	// The second attempt finishing leaves the first one running
+	doneSecond()
+	if second.Err() == nil {
+		t.Error("the finished attempt was not cancelled")
+	}
+	if first.Err() != nil {
+		t.Fatal("another attempt finishing cancelled the first one")
+	}
+
+	// A competing block still reaches the first attempt
+	StopMining()
+	if first.Err() == nil {
+		t.Error("StopMining did not cancel the attempt still in flight")
+	}
+}
+
+func TestSendTxWithAckReportsTheVerdict(t *testing.T) {
+	sender, recipient := wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+	nodes := KnownNodes
+	KnownNodes = nil // Nothing to relay to
+	t.Cleanup(func() { KnownNodes = nodes })
+	addr := serveTestChain(t, chain)
+
+	// Paying the recipient more than was signed for: a fresh ID, but a signature that no longer matches
+	forged := newTestTransfer(t, chain, sender, recipient, 10, 1)
+	forged.Outputs[0].Value = 90
+	forged.SetID()
+	err := SendTxWithAck(addr, forged)
+	if !errors.Is(err, ErrTxRejected) || !strings.Contains(err.Error(), "verification failed") {
+		t.Errorf("SendTxWithAck(forged) = %v, want ErrTxRejected for a bad signature", err)
+	}
+	if _, pooled := memoryPool.Get(hex.EncodeToString(forged.ID)); pooled {
+		t.Error("a rejected transaction was pooled")
+	}
+
+	valid := newTestTransfer(t, chain, sender, recipient, 10, 1)
+	if err := SendTxWithAck(addr, valid); err != nil {
+		t.Fatalf("SendTxWithAck(valid) = %v, want it accepted", err)
+	}
+	if _, pooled := memoryPool.Get(hex.EncodeToString(valid.ID)); !pooled {
+		t.Error("an accepted transaction is not in the memory pool")
+	}
+}
+
+func TestGossipWithASmallFanoutReachesEveryNode(t *testing.T) {
+	const nodes = 40
+	mesh := make([]string, nodes) // Every node knows every other one
+	for i := range mesh {
+		mesh[i] = fmt.Sprintf("node%d:3000", i)
+	}
+
+	known, address, width := KnownNodes, nodeAddress, fanout
+	KnownNodes, fanout = mesh, 16
+	t.Cleanup(func() { KnownNodes, nodeAddress, fanout = known, address, width })
+
+	// Each node relays only the first time it hears of something, to the peers gossipPeers
+	// picks as that node; hop by hop until nobody has anything new to relay
+	reached := map[string]bool{mesh[0]: true}
+	type relay struct{ from, to string }
+	wave := []relay{{to: mesh[0]}}
+	messages, hops := 0, 0
+	for ; len(wave) > 0; hops++ {
+		var next []relay
+		for _, r := range wave {
+			nodeAddress = r.to
+			for _, peer := range gossipPeers(r.from) {
+				messages++
+				if peer == r.to || peer == r.from {
+					t.Fatalf("%s relayed to %s, coming from %s", r.to, peer, r.from)
+				}
+				if !reached[peer] {
+					reached[peer] = true
+					next = append(next, relay{from: r.to, to: peer})
+				}
+			}
+		}
+		wave = next
+	}
+
+	if len(reached) != nodes {
+		t.Errorf("gossip reached %d of %d nodes", len(reached), nodes)
+	}
+	if flood := nodes * (nodes - 1); messages >= flood {
+		t.Errorf("gossip sent %d messages, flooding takes %d", messages, flood)
+	}
+	if hops > 5 {
+		t.Errorf("gossip took %d hops to settle", hops)
+	}
+}
+
+// recordingPeer listens like a peer and passes on every message it is sent
+func recordingPeer(t *testing.T) (string, <-chan []byte) {
+	t.Helper()
+
+	ln, err := net.Listen(protocol, "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan []byte, 16)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			if req, _, err := readMessage(conn); err == nil {
+				received <- req
+			}
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestHandleInvIgnoresEmptyAndUnknownInventories(t *testing.T) {
+	chain := newTestChain(t, map[*wallet.Wallet]int{wallet.MakeWallet(): 100})
+
+	// The announcing peer records the requests it gets back
+	peer, received := recordingPeer(t)
+
+	inv := func(kind string, items ...[]byte) []byte {
+		return append(CmdToBytes("inv"), GobEncode(Inv{AddrFrom: peer, Type: kind, Items: items})...)
+	}
+	unknown := []byte("a block we don't have")
+	for _, request := range [][]byte{inv("block"), inv("tx"), inv("compact", unknown)} {
+		HandleInv(request, chain) // Must neither panic nor ask for anything
+	}
+	if count := blocksInTransitCount(); count != 0 {
+		t.Errorf("%d blocks in transit after ignored inventories", count)
+	}
+
+	// So the first request the peer sees answers a real inventory
+	HandleInv(inv("block", unknown), chain)
+	select {
+	case req := <-received:
+		var getData GetData
+		if err := decodePayload(req, &getData); err != nil || BytesToCmd(req[:commandLength]) != "getdata" || !bytes.Equal(getData.ID, unknown) {
+			t.Errorf("peer got %q (%+v, %v), want getdata for the announced block", BytesToCmd(req[:commandLength]), getData, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no getdata for the announced block")
+	}
+}
+
+func TestHandleTxPoolsAndRelaysOnlyNewValidTransactions(t *testing.T) {
+	sender, recipient, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+	peer, received := recordingPeer(t)
+	nodes := KnownNodes
+	KnownNodes = []string{peer}
+	t.Cleanup(func() { KnownNodes = nodes })
+
+	// submit hands tx to the node as a peer would, and returns its acknowledgement
+	submit := func(tx *blockchain.Transaction) TxAck {
+		t.Helper()
+		request := append(CmdToBytes("tx"), GobEncode(Tx{AddrFrom: "localhost:1", Transaction: tx.Serialize()})...)
+		reply, _, err := readMessage(bytes.NewReader(handleTestRequest(t, chain, request)))
+		var ack TxAck
+		if err == nil {
+			err = decodePayload(reply, &ack)
+		}
+		if err != nil {
+			t.Fatalf("no acknowledgement: %v", err)
+		}
+		return ack
+	}
+	// relayed reports whether the node announced the transaction to its peer
+	relayed := func(tx *blockchain.Transaction) bool {
+		t.Helper()
+		select {
+		case req := <-received:
+			var inv Inv
+			if err := decodePayload(req, &inv); err != nil || len(inv.Items) != 1 || !bytes.Equal(inv.Items[0], tx.ID) {
+				t.Fatalf("peer got %q (%+v, %v), want the transaction's inventory", BytesToCmd(req[:commandLength]), inv, err)
+			}
+			return true
+		case <-time.After(200 * time.Millisecond):
+			return false
+		}
+	}
+
+	// A valid signature copied onto a different payment
+	forged := newTestTransfer(t, chain, sender, recipient, 10, 1)
+	forged.Outputs[0].Value = 90
+	forged.SetID()
+	if ack := submit(forged); ack.Accepted || !strings.Contains(ack.Reason, "bad signature") {
+		t.Errorf("forged transaction got %+v, want it rejected for its signature", ack)
+	}
+	if _, pooled := memoryPool.Get(hex.EncodeToString(forged.ID)); pooled || relayed(forged) {
+		t.Errorf("forged transaction pooled (%v) or relayed", pooled)
+	}
+
+	// Submitted twice: accepted both times, relayed once
+	valid := newTestTransfer(t, chain, sender, recipient, 10, 1)
+	for i := 1; i <= 2; i++ {
+		if ack := submit(valid); !ack.Accepted || !bytes.Equal(ack.TxID, valid.ID) {
+			t.Fatalf("submission %d got %+v, want it accepted", i, ack)
+		}
+	}
+	if !relayed(valid) {
+		t.Error("new valid transaction was not relayed")
+	}
+	if relayed(valid) {
+		t.Error("resubmitted transaction was relayed again")
+	}
+	if memoryPool.Len() != 1 {
+		t.Errorf("memory pool holds %d transactions, want 1", memoryPool.Len())
+	}
+
+	// Once mined it is refused outright
+	memoryPool = NewMempool(0)
+	mineTestBlock(t, chain, miner, valid)
+	if ack := submit(valid); ack.Accepted || !strings.Contains(ack.Reason, "already confirmed") {
+		t.Errorf("confirmed transaction got %+v, want it rejected", ack)
+	}
+}
@@ -0,0 +1,93 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/golang-blockchain/logger"
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 21/12/2025
+ * Time: 09:50
+ */
+
+// PERSISTENT PEER LIST
+// KnownNodes is written to {DATA_DIR}/peers_{nodeID}.json whenever it changes and read back
+// by StartServer, so a restarted node rejoins the network it already knew instead of
+// starting over from the bootstrap node alone.
+
+const (
+	peersFile      = "peers_%s.json" // Relative to wallet.DataDir
+	maxStoredPeers = 1000            // Most peers kept on disk; the oldest entries are dropped first
+)
+
+var (
+	peersPath   string     // File the peer list is saved to, empty until StartServer sets it
+	peersFileMu sync.Mutex // Serializes writes to peersPath
+)
+
+// loadPeers adds the peers saved by a previous run to KnownNodes
+// A missing file simply means there is nothing to restore
+func loadPeers(nodeID string) {
+	peersPath = wallet.DataPath(fmt.Sprintf(peersFile, nodeID))
+
+	content, err := os.ReadFile(peersPath)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		logger.Error("Could not read peers", "file", peersPath, "err", err)
+		return
+	}
+
+	var peers []string
+	if err := json.Unmarshal(content, &peers); err != nil {
+		logger.Warn("Ignoring corrupt peers file", "file", peersPath, "err", err)
+		return
+	}
+
+	addKnownNodes(peers...)
+	logger.Info("Loaded known nodes", "count", len(knownNodes()), "file", peersPath)
+}
+
+// savePeers writes the current KnownNodes to disk, de-duplicated and capped at maxStoredPeers
+func savePeers() {
+	if peersPath == "" {
+		return // Not running a server (e.g. a CLI send), nothing to persist
+	}
+
+	var peers []string
+	seen := make(map[string]bool)
+	for _, node := range knownNodes() {
+		if node != nodeAddress && !seen[node] {
+			seen[node] = true
+			peers = append(peers, node)
+		}
+	}
+	if len(peers) > maxStoredPeers {
+		peers = peers[len(peers)-maxStoredPeers:]
+	}
+
+	content, err := json.MarshalIndent(peers, "", "  ")
+	if err != nil {
+		logger.Error("Could not encode peers", "err", err)
+		return
+	}
+
+	peersFileMu.Lock()
+	defer peersFileMu.Unlock()
+	if err := wallet.EnsureDataDir(); err != nil {
+		logger.Error("Could not save peers", "err", err)
+		return
+	}
+	if err := os.WriteFile(peersPath, content, 0644); err != nil {
+		logger.Error("Could not save peers", "file", peersPath, "err", err)
+	}
+}
@@ -0,0 +1,92 @@
+package network
+
+import (
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-blockchain/logger"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 21/12/2025
+ * Time: 14:15
+ */
+
+// PEER LIVENESS
+// Every pingInterval the node sends each known peer a "ping" and waits pingTimeout
+// for the "pong". Peers that don't answer are dropped from KnownNodes (and the saved
+// peer list), instead of lingering until some other message happens to fail.
+
+const (
+	defaultPingInterval = 60 // Seconds, overridden by the PING_INTERVAL_SECONDS env. var.
+	pingTimeout         = 5 * time.Second
+)
+
+var pingInterval = loadPingInterval() // Time between two rounds of pings
+
+// loadPingInterval reads the ping interval from PING_INTERVAL_SECONDS
+// Falls back to the default when the variable is unset or not a positive number
+func loadPingInterval() time.Duration {
+	seconds := defaultPingInterval
+
+	if value := os.Getenv("PING_INTERVAL_SECONDS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			logger.Warn("Invalid PING_INTERVAL_SECONDS, using the default", "value", value, "default", defaultPingInterval)
+		} else {
+			seconds = parsed
+		}
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// PingPeers pings every known node each interval until ctx is cancelled
+func PingPeers(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, node := range knownNodes() {
+				if node == nodeAddress {
+					continue
+				}
+				if err := SendPing(node); err != nil {
+					logger.Warn("Dropping unresponsive node", "node", node, "err", err)
+					removeKnownNode(node)
+				}
+			}
+		}
+	}
+}
+
+// SendPing pings a node and waits up to pingTimeout for its pong
+func SendPing(address string) error {
+	request := append(CmdToBytes("ping"), GobEncode(Ping{AddrFrom: nodeAddress})...)
+
+	_, err := sendAndAwaitReply(address, request, "pong", pingTimeout)
+	return err
+}
+
+// HandlePing answers a ping with a pong on the same connection
+func HandlePing(request []byte, reply io.Writer) {
+	var payload Ping
+	if err := decodePayload(request, &payload); err != nil {
+		logger.Error("Dropped malformed message", "command", "ping", "err", err)
+		return
+	}
+
+	response := append(CmdToBytes("pong"), GobEncode(Pong{AddrFrom: nodeAddress})...)
+	if _, err := reply.Write(response); err != nil {
+		logger.Error("Could not answer ping", "from", payload.AddrFrom, "err", err)
+	}
+}
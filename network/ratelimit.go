@@ -0,0 +1,148 @@
+package network
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-blockchain/logger"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 09:20
+ */
+
+// CONNECTION LIMITS
+// Two guards sit in front of HandleConnection in the accept loop:
+//  1. A token bucket per remote IP: each connection takes a token, and tokens refill at
+//     PEER_CONNECTIONS_PER_SECOND up to PEER_CONNECTION_BURST. A flooding peer runs dry.
+//  2. A cap of MAX_CONNECTIONS connections handled at once, so no mix of peers can
+//     exhaust file descriptors or goroutines.
+//
+// Connections over either limit are closed straight away, without reading them.
+// Local test networks run every node on one IP, so the defaults are generous.
+const (
+	defaultMaxConnections      = 256
+	defaultPeerConnectionsRate = 50  // Tokens added per second to each IP's bucket
+	defaultPeerConnectionBurst = 200 // Size of each IP's bucket
+
+	maxTrackedPeers = 10000           // Buckets kept before idle ones are swept
+	peerBucketIdle  = 5 * time.Minute // A bucket unused this long is full again and can go
+)
+
+var (
+	connectionSlots = make(chan struct{}, loadPositiveEnv("MAX_CONNECTIONS", defaultMaxConnections))
+	peerLimiter     = newRateLimiter(
+		float64(loadPositiveEnv("PEER_CONNECTIONS_PER_SECOND", defaultPeerConnectionsRate)),
+		float64(loadPositiveEnv("PEER_CONNECTION_BURST", defaultPeerConnectionBurst)),
+	)
+)
+
+// loadPositiveEnv reads a positive integer from the named env. var.
+// Falls back to def when the variable is unset or not a positive number
+func loadPositiveEnv(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 1 {
+		logger.Warn("Invalid "+name+", using the default", "value", value, "default", def)
+		return def
+	}
+	return parsed
+}
+
+// tokenBucket is the connection allowance of one remote IP
+type tokenBucket struct {
+	tokens float64
+	last   time.Time // When tokens was last brought up to date
+}
+
+// rateLimiter hands out connection tokens per remote IP
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // Tokens per second
+	burst   float64 // Bucket capacity
+	buckets map[string]*tokenBucket
+}
+
+// newRateLimiter creates a limiter refilling rate tokens per second up to burst
+func newRateLimiter(rate, burst float64) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: burst, buckets: make(map[string]*tokenBucket)}
+}
+
+// Allow takes a token from ip's bucket and reports whether one was available
+func (l *rateLimiter) Allow(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	bucket, ok := l.buckets[ip]
+	if !ok {
+		if len(l.buckets) >= maxTrackedPeers {
+			l.sweep(now)
+		}
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[ip] = bucket
+	}
+
+	// Refill for the time since the last connection, up to the bucket size
+	bucket.tokens += now.Sub(bucket.last).Seconds() * l.rate
+	if bucket.tokens > l.burst {
+		bucket.tokens = l.burst
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// sweep forgets buckets that have been idle long enough to be full again
+// The caller holds l.mu
+func (l *rateLimiter) sweep(now time.Time) {
+	for ip, bucket := range l.buckets {
+		if now.Sub(bucket.last) > peerBucketIdle {
+			delete(l.buckets, ip)
+		}
+	}
+}
+
+// admitConnection applies both limits to a freshly accepted connection
+// It returns false (having closed conn) when the connection is refused; otherwise the
+// caller must call releaseConnection once the connection has been handled
+func admitConnection(conn net.Conn) bool {
+	ip := conn.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+
+	if !peerLimiter.Allow(ip) {
+		logger.Warn("Refused connection: peer rate limit exceeded", "from", ip)
+		conn.Close()
+		return false
+	}
+
+	select {
+	case connectionSlots <- struct{}{}:
+		return true
+	default:
+		logger.Warn("Refused connection: too many connections", "from", ip, "max", cap(connectionSlots))
+		conn.Close()
+		return false
+	}
+}
+
+// releaseConnection frees the slot taken by admitConnection
+func releaseConnection() {
+	<-connectionSlots
+}
@@ -0,0 +1,93 @@
+package network
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 13:30
+ */
+
+// peerConn is one end of a pipe that claims to come from ip
+type peerConn struct {
+	net.Conn
+	ip string
+}
+
+func (c peerConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.ParseIP(c.ip), Port: 40000}
+}
+
+// dialFrom returns the node's end of a new connection from ip, and the peer's end
+func dialFrom(t *testing.T, ip string) (net.Conn, net.Conn) {
+	t.Helper()
+
+	peer, node := net.Pipe()
+	t.Cleanup(func() { peer.Close(); node.Close() })
+	return peerConn{Conn: node, ip: ip}, peer
+}
+
+// withLimits swaps in fresh connection limits for the test
+func withLimits(t *testing.T, maxConnections int, rate, burst float64) {
+	slots, limiter := connectionSlots, peerLimiter
+	connectionSlots, peerLimiter = make(chan struct{}, maxConnections), newRateLimiter(rate, burst)
+	t.Cleanup(func() { connectionSlots, peerLimiter = slots, limiter })
+}
+
+// assertRefused checks that the node hung up on the peer
+func assertRefused(t *testing.T, peer net.Conn) {
+	t.Helper()
+
+	if _, err := peer.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("refused connection read = %v, want io.EOF", err)
+	}
+}
+
+func TestPeerBurstPastItsBucketIsRefused(t *testing.T) {
+	withLimits(t, 100, 0.001, 3) // Next to no refill while the test runs
+
+	for i := 0; i < 3; i++ {
+		conn, _ := dialFrom(t, "10.0.0.1")
+		if !admitConnection(conn) {
+			t.Fatalf("connection %d within the burst was refused", i+1)
+		}
+	}
+	conn, peer := dialFrom(t, "10.0.0.1")
+	if admitConnection(conn) {
+		t.Fatal("connection past the burst was admitted")
+	}
+	assertRefused(t, peer)
+
+	// Other peers have buckets of their own
+	if conn, _ := dialFrom(t, "10.0.0.2"); !admitConnection(conn) {
+		t.Error("another peer was refused for the first peer's burst")
+	}
+}
+
+func TestConnectionsPastTheCapAreRefused(t *testing.T) {
+	withLimits(t, 2, 100, 100)
+
+	for i, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		if conn, _ := dialFrom(t, ip); !admitConnection(conn) {
+			t.Fatalf("connection %d under the cap was refused", i+1)
+		}
+	}
+	conn, peer := dialFrom(t, "10.0.0.3")
+	if admitConnection(conn) {
+		t.Fatal("connection past the cap was admitted")
+	}
+	assertRefused(t, peer)
+
+	// A finished connection frees its slot
+	releaseConnection()
+	if conn, _ := dialFrom(t, "10.0.0.3"); !admitConnection(conn) {
+		t.Error("connection refused after a slot was released")
+	}
+}
@@ -0,0 +1,120 @@
+package network
+
+import (
+	"context"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-blockchain/blockchain"
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 10:50
+ */
+
+// newTestNode creates a chain for a node listening on a free local port, in a temporary
+// data directory, and returns the node ID
+func newTestNode(t *testing.T) string {
+	t.Helper()
+
+	dataDir := wallet.DataDir
+	wallet.DataDir = t.TempDir()
+	t.Cleanup(func() { wallet.DataDir = dataDir })
+
+	ln, err := net.Listen(protocol, "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeID := strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+	ln.Close()
+
+	owner := wallet.MakeWallet()
+	chain, err := blockchain.InitBlockChainWithGenesis(map[string]int{string(owner.Address()): 100}, blockchain.MinDifficulty, nodeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.Database.Close()
+	return nodeID
+}
+
+func TestStartAndStopServer(t *testing.T) {
+	nodeID := newTestNode(t)
+	pool := memoryPool
+	memoryPool = NewMempool(0)
+	t.Cleanup(func() { memoryPool = pool })
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	stopped := make(chan error, 1)
+	go func() { stopped <- runServer(ctx, nodeID, "", "", "") }()
+
+	// The node is up once it accepts connections
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, err := net.Dial(protocol, "localhost:"+nodeID)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("node never accepted a connection: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A transaction pooled only in memory must be on disk once the node stopped
+	tx := syntheticTx(0, 100)
+	if _, err := memoryPool.Add(hex.EncodeToString(tx.ID), tx, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	stop()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatalf("runServer = %v, want nil", err)
+		}
+	case <-time.After(handlerShutdownTimeout + 5*time.Second):
+		t.Fatal("node did not stop")
+	}
+
+	// The database was closed, so it can be opened again
+	chain := blockchain.ContinueBlockChain(nodeID)
+	defer chain.Database.Close()
+	if saved, err := chain.IsMempoolTx(tx.ID); err != nil || !saved {
+		t.Errorf("pooled transaction saved = %v, %v; want it saved at shutdown", saved, err)
+	}
+}
+
+func TestShutdownTimeoutSavesNothing(t *testing.T) {
+	chain := newTestPool(t, 0)
+	tx := syntheticTx(0, 100)
+	if _, err := memoryPool.Add(hex.EncodeToString(tx.ID), tx, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := handlerShutdownTimeout
+	handlerShutdownTimeout = 10 * time.Millisecond
+	t.Cleanup(func() { handlerShutdownTimeout = timeout })
+
+	// A worker that never finishes, e.g. a handler blocked on the database
+	var workers sync.WaitGroup
+	workers.Add(1)
+	defer workers.Done()
+
+	if shutdownNode(chain, nil, &workers) {
+		t.Fatal("shutdownNode reported a clean stop with a worker still running")
+	}
+	if saved, err := chain.IsMempoolTx(tx.ID); err != nil || saved {
+		t.Errorf("pooled transaction saved = %v, %v; want nothing written", saved, err)
+	}
+}
@@ -0,0 +1,111 @@
+package network
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/golang-blockchain/blockchain"
+	"github.com/golang-blockchain/logger"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 16:10
+ */
+
+// TRANSACTION PROOFS
+// A light client that received a payment sends a "getproof" message with the transaction
+// ID; the node answers on the same connection with a "txproof" message holding the header
+// of the confirming block, the transaction and its Merkle proof. The client checks the
+// header against the ones it synced (see headers.go) and calls blockchain.VerifySPV.
+
+// GetProof asks a node to prove that a transaction is in its active chain
+type GetProof struct {
+	AddrFrom string
+	TxID     []byte
+}
+
+// TxProof answers a GetProof; Error is set instead of the other fields when there is no proof
+type TxProof struct {
+	AddrFrom    string
+	Header      []byte // Serialized blockchain.BlockHeader of the confirming block
+	Transaction []byte // Serialized transaction
+	Proof       []blockchain.MerkleProofStep
+	Error       string
+}
+
+// RequestTxProof fetches the proof that transaction txID is confirmed from the node at address
+// The transaction is checked to match txID, but verifying the proof is up to the caller
+func RequestTxProof(address string, txID []byte) (blockchain.BlockHeader, *blockchain.Transaction, []blockchain.MerkleProofStep, error) {
+	var header blockchain.BlockHeader
+
+	conn, err := net.DialTimeout(protocol, address, headersQueryTimeout)
+	if err != nil {
+		return header, nil, nil, fmt.Errorf("%w on %s: %v", ErrNodeNotRunning, address, err)
+	}
+	defer conn.Close()
+
+	request := append(CmdToBytes("getproof"), GobEncode(GetProof{AddrFrom: nodeAddress, TxID: txID})...)
+	response, err := exchange(conn, address, request, "txproof", headersQueryTimeout)
+	if err != nil {
+		return header, nil, nil, err
+	}
+
+	var payload TxProof
+	if err := gob.NewDecoder(bytes.NewReader(response[commandLength:])).Decode(&payload); err != nil {
+		return header, nil, nil, fmt.Errorf("bad proof reply from %s: %w", address, err)
+	}
+	if payload.Error != "" {
+		return header, nil, nil, errors.New(payload.Error)
+	}
+
+	header, err = blockchain.DeserializeHeader(payload.Header)
+	if err != nil {
+		return header, nil, nil, fmt.Errorf("bad header from %s: %w", address, err)
+	}
+	tx, err := blockchain.DeserializeTransactionE(payload.Transaction)
+	if err != nil {
+		return header, nil, nil, fmt.Errorf("bad transaction from %s: %w", address, err)
+	}
+	if !bytes.Equal(tx.ID, txID) {
+		return header, nil, nil, fmt.Errorf("%s sent transaction %x, not %x", address, tx.ID, txID)
+	}
+	return header, &tx, payload.Proof, nil
+}
+
+// HandleGetProof answers a getproof message with the Merkle proof of a confirmed transaction
+func HandleGetProof(request []byte, chain *blockchain.BlockChain, reply io.Writer) {
+	var payload GetProof
+	if err := decodePayload(request, &payload); err != nil {
+		logger.Error("Dropped malformed message", "command", "getproof", "err", err)
+		return
+	}
+
+	result := TxProof{AddrFrom: nodeAddress}
+	tx, block, err := chain.FindTransactionBlock(payload.TxID)
+	if err == nil {
+		var proof []blockchain.MerkleProofStep
+		if proof, err = block.MerkleProof(tx.ID); err == nil {
+			result.Header = block.Header(chain.Difficulty).Serialize()
+			result.Transaction = tx.Serialize()
+			result.Proof = proof
+		}
+	}
+	if err != nil {
+		logger.Debug("No proof for transaction", "txid", hex.EncodeToString(payload.TxID), "err", err)
+		result.Error = err.Error()
+	}
+
+	response := append(CmdToBytes("txproof"), GobEncode(result)...)
+	if _, err := reply.Write(response); err != nil {
+		logger.Error("Could not send a transaction proof", "from", payload.AddrFrom, "err", err)
+	}
+}
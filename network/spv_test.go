@@ -0,0 +1,104 @@
+package network
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/golang-blockchain/blockchain"
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 13:50
+ */
+
+// serveTestChain answers connections to a free local port with chain until the test ends
+// and returns the address; the test ends only once every handler has returned
+func serveTestChain(t *testing.T, chain *blockchain.BlockChain) string {
+	t.Helper()
+
+	ln, err := net.Listen(protocol, "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The test's cleanups must not swap the node's globals under a handler still running
+	var handlers sync.WaitGroup
+	accepting := make(chan struct{})
+	t.Cleanup(func() {
+		ln.Close()
+		<-accepting
+		handlers.Wait()
+	})
+
+	go func() {
+		defer close(accepting)
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			handlers.Add(1)
+			go func() {
+				defer handlers.Done()
+				HandleConnection(conn, chain)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestLightClientVerifiesAPayment(t *testing.T) {
+	sender, recipient, miner := wallet.MakeWallet(), wallet.MakeWallet(), wallet.MakeWallet()
+	chain := newTestChain(t, map[*wallet.Wallet]int{sender: 100})
+	chain.Consensus = nil // The light client checks real proof of work
+
+	// The full node mines the payment and a block on top
+	payment := newTestTransfer(t, chain, sender, recipient, 30, 1)
+	mineTestBlock(t, chain, miner, payment)
+	mineTestBlock(t, chain, miner)
+	address := serveTestChain(t, chain)
+
+	// The light client syncs and checks the headers...
+	headers, err := RequestHeaders(address, nil)
+	if err != nil {
+		t.Fatalf("RequestHeaders: %v", err)
+	}
+	if len(headers) != chain.GetBestHeight()+1 {
+		t.Fatalf("got %d headers, want %d", len(headers), chain.GetBestHeight()+1)
+	}
+	if err := blockchain.VerifyHeaders(headers, chain.Difficulty); err != nil {
+		t.Fatalf("VerifyHeaders: %v", err)
+	}
+
+	// ...then proves the payment against the header it already trusts
+	header, tx, proof, err := RequestTxProof(address, payment.ID)
+	if err != nil {
+		t.Fatalf("RequestTxProof: %v", err)
+	}
+	if header.Height != 1 || !bytes.Equal(header.Hash, headers[1].Hash) {
+		t.Fatalf("proof is against block %x at height %d, want the synced block %x at height 1", header.Hash, header.Height, headers[1].Hash)
+	}
+	if !blockchain.VerifySPV(headers[1], tx.MerkleHash(header.Version), proof) {
+		t.Error("VerifySPV rejected the mined payment")
+	}
+
+	// The proof is for that transaction only
+	other := blockchain.CoinbaseTx(string(miner.Address()), "", 1, 0)
+	if blockchain.VerifySPV(headers[1], other.MerkleHash(header.Version), proof) {
+		t.Error("VerifySPV accepted the proof for another transaction")
+	}
+
+	// An unconfirmed transaction has no proof
+	unconfirmed := newTestTransfer(t, chain, recipient, sender, 5, 0)
+	if _, _, _, err := RequestTxProof(address, unconfirmed.ID); err == nil || errors.Is(err, ErrNodeNotRunning) {
+		t.Errorf("RequestTxProof of an unconfirmed transaction = %v, want the node's refusal", err)
+	}
+}
@@ -0,0 +1,83 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/golang-blockchain/blockchain"
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 12:30
+ */
+
+// TEST CHAINS
+// Like the blockchain package's own: a chain in a temporary data directory, sealed by
+// NoOpConsensus, whose coinbases mature at once. The node gets an empty memory pool.
+
+// newTestChain creates a chain whose genesis pays each wallet its allocation
+// The UTXO set is indexed, and the database closed and the memory pool restored when the test ends
+func newTestChain(t *testing.T, allocations map[*wallet.Wallet]int) *blockchain.BlockChain {
+	t.Helper()
+
+	dataDir, maturity := wallet.DataDir, blockchain.CoinbaseMaturity
+	wallet.DataDir, blockchain.CoinbaseMaturity = t.TempDir(), 0
+	t.Cleanup(func() { wallet.DataDir, blockchain.CoinbaseMaturity = dataDir, maturity })
+
+	pool := memoryPool
+	memoryPool = NewMempool(0)
+	t.Cleanup(func() { memoryPool = pool })
+
+	addresses := make(map[string]int)
+	for w, amount := range allocations {
+		addresses[string(w.Address())] = amount
+	}
+	chain, err := blockchain.InitBlockChainWithGenesis(addresses, blockchain.MinDifficulty, "test")
+	if err != nil {
+		t.Fatalf("create chain: %v", err)
+	}
+	t.Cleanup(func() { chain.Database.Close() })
+
+	chain.Consensus = blockchain.NoOpConsensus{Difficulty: blockchain.MinDifficulty}
+	if err := (blockchain.UTXOSet{Blockchain: chain}).Reindex(); err != nil {
+		t.Fatalf("index the UTXO set: %v", err)
+	}
+	return chain
+}
+
+// mineTestBlock mines txs into a block paying miner the reward and fees, and updates the UTXO set
+func mineTestBlock(t *testing.T, chain *blockchain.BlockChain, miner *wallet.Wallet, txs ...*blockchain.Transaction) *blockchain.Block {
+	t.Helper()
+
+	fees, err := chain.Fees(txs)
+	if err != nil {
+		t.Fatalf("fees: %v", err)
+	}
+	height := chain.GetBestHeight() + 1
+	coinbase := blockchain.CoinbaseTx(string(miner.Address()), "", height, fees)
+	block, err := chain.MineBlockWithContext(t.Context(), append([]*blockchain.Transaction{coinbase}, txs...))
+	if err != nil {
+		t.Fatalf("mine block %d: %v", height, err)
+	}
+	utxoSet := blockchain.UTXOSet{Blockchain: chain}
+	if err := utxoSet.Update(block); err != nil {
+		t.Fatalf("update the UTXO set with block %d: %v", height, err)
+	}
+	return block
+}
+
+// newTestTransfer builds a signed transfer of amount from sender to recipient on chain
+func newTestTransfer(t *testing.T, chain *blockchain.BlockChain, sender, recipient *wallet.Wallet, amount, fee int) *blockchain.Transaction {
+	t.Helper()
+
+	utxoSet := blockchain.UTXOSet{Blockchain: chain}
+	tx, err := blockchain.NewTransaction(sender, string(recipient.Address()), amount, fee, &utxoSet)
+	if err != nil {
+		t.Fatalf("build transaction: %v", err)
+	}
+	return tx
+}
@@ -0,0 +1,87 @@
+package network
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang-blockchain/wallet"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 14:30
+ */
+
+// withConnectionTimeout shortens connectionTimeout for the test
+func withConnectionTimeout(t *testing.T, timeout time.Duration) {
+	old := connectionTimeout
+	connectionTimeout = timeout
+	t.Cleanup(func() { connectionTimeout = old })
+}
+
+func TestSendDataGivesUpOnAPeerThatNeverReads(t *testing.T) {
+	withConnectionTimeout(t, 200*time.Millisecond)
+
+	// A peer that accepts connections, then leaves them unread
+	ln, err := net.Listen(protocol, "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	addr := ln.Addr().String()
+
+	nodes, path := KnownNodes, peersPath
+	KnownNodes, peersPath = []string{addr}, "" // Nothing written to disk
+	t.Cleanup(func() { KnownNodes, peersPath = nodes, path })
+
+	// Far more than the socket buffers hold, so the write has to wait for the peer
+	started := time.Now()
+	err = SendData(addr, make([]byte, 64<<20))
+	if err == nil || !isTimeout(err) {
+		t.Fatalf("SendData = %v, want a timeout", err)
+	}
+	if waited := time.Since(started); waited > 5*time.Second {
+		t.Errorf("SendData took %s to give up, timeout is %s", waited, connectionTimeout)
+	}
+	for _, node := range knownNodes() {
+		if node == addr {
+			t.Error("the peer that never reads is still a known node")
+		}
+	}
+}
+
+func TestHandleConnectionGivesUpOnAPeerThatNeverReads(t *testing.T) {
+	withConnectionTimeout(t, 200*time.Millisecond)
+	chain := newTestChain(t, map[*wallet.Wallet]int{wallet.MakeWallet(): 100})
+
+	// The peer asks for a pong, then neither reads it nor hangs up
+	client, server := net.Pipe()
+	defer client.Close()
+	done := make(chan struct{})
+	go func() {
+		HandleConnection(server, chain)
+		close(done)
+	}()
+	if _, err := client.Write(frameMessage(append(CmdToBytes("ping"), GobEncode(Ping{AddrFrom: "localhost:1"})...))); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the handler is still waiting on a peer that never reads")
+	}
+}
@@ -0,0 +1,47 @@
+package wallet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 24/12/2025
+ * Time: 10:15
+ */
+
+// DATA DIRECTORY
+// Everything a node persists (block database, wallet file, peer list) lives under one
+// directory, taken from the DATA_DIR env. var. (default ./tmp). Lives in this package
+// because it is the one both blockchain and network already import.
+const defaultDataDir = "./tmp"
+
+// DataDir is the directory holding this node's databases and files
+var DataDir = loadDataDir()
+
+// loadDataDir reads the data directory from the DATA_DIR env. var.
+// Falls back to defaultDataDir when the variable is unset or blank
+func loadDataDir() string {
+	dir := os.Getenv("DATA_DIR")
+	if dir == "" {
+		return defaultDataDir
+	}
+	return filepath.Clean(dir)
+}
+
+// DataPath returns the path of a file or directory named name inside DataDir
+func DataPath(name string) string {
+	return filepath.Join(DataDir, name)
+}
+
+// EnsureDataDir creates DataDir (and any parents) so files can be written into it
+func EnsureDataDir() error {
+	if err := os.MkdirAll(DataDir, 0700); err != nil {
+		return fmt.Errorf("create data directory %s: %w", DataDir, err)
+	}
+	return nil
+}
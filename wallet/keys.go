@@ -0,0 +1,78 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 23/12/2025
+ * Time: 10:05
+ */
+
+// PRIVATE KEY EXPORT
+// Keys are exported WIF-style, with the same Base58Check layout as addresses:
+// [version 0x80 (1)] + [private scalar D, 32 bytes big-endian] + [0x01 if compressed] + [checksum (4)]
+// The checksum catches typos when the key is copied by hand; the compression flag
+// makes the import derive the same address as the exporting wallet.
+const (
+	privateKeyVersion    = byte(0x80)
+	privateKeyCompressed = byte(0x01)
+)
+
+// ErrInvalidPrivateKey is returned when an exported key is malformed or its checksum doesn't match
+var ErrInvalidPrivateKey = errors.New("invalid private key")
+
+// ExportKey returns the wallet's private key in WIF-like Base58Check form
+func (w *Wallet) ExportKey() string {
+	d := make([]byte, privateKeyLength)
+	w.PrivateKey.D.FillBytes(d)
+
+	payload := append([]byte{privateKeyVersion}, d...)
+	if w.Version == walletVersionCompressed {
+		payload = append(payload, privateKeyCompressed)
+	}
+	return string(Base58Encode(append(payload, Checksum(payload)...)))
+}
+
+// ImportKey rebuilds a wallet from a key produced by ExportKey
+func ImportKey(key string) (*Wallet, error) {
+	decoded, err := Base58DecodeSafe([]byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+	}
+	if len(decoded) != 1+privateKeyLength+checksumLength && len(decoded) != 1+privateKeyLength+1+checksumLength {
+		return nil, fmt.Errorf("%w: wrong length", ErrInvalidPrivateKey)
+	}
+
+	payload := decoded[:len(decoded)-checksumLength]
+	if !bytes.Equal(decoded[len(payload):], Checksum(payload)) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidPrivateKey)
+	}
+	if payload[0] != privateKeyVersion {
+		return nil, fmt.Errorf("%w: unknown version byte 0x%02x", ErrInvalidPrivateKey, payload[0])
+	}
+
+	walletVersion := walletVersionUncompressed
+	if len(payload) == 1+privateKeyLength+1 {
+		if payload[len(payload)-1] != privateKeyCompressed {
+			return nil, fmt.Errorf("%w: unknown key flag 0x%02x", ErrInvalidPrivateKey, payload[len(payload)-1])
+		}
+		walletVersion = walletVersionCompressed
+	}
+
+	// The scalar must lie in [1, N-1] to be a P-256 private key
+	dBytes := payload[1 : 1+privateKeyLength]
+	d := new(big.Int).SetBytes(dBytes)
+	if d.Sign() == 0 || d.Cmp(elliptic.P256().Params().N) >= 0 {
+		return nil, fmt.Errorf("%w: out of range for P-256", ErrInvalidPrivateKey)
+	}
+
+	return walletFromD(dBytes, walletVersion), nil
+}
@@ -0,0 +1,96 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 26/12/2025
+ * Time: 13:00
+ */
+
+// ADDRESS LABELS
+// Friendly names for addresses, e.g. "alice", kept per node in {DATA_DIR}/labels_{nodeID}.json
+// next to the wallet file. A label may name any valid address, not only our own wallets.
+// Commands taking an address accept a label instead; a string that isn't a label is
+// used as an address unchanged (see Resolve). Labels hold no secrets, so the file is
+// plain JSON even when the wallet file is encrypted.
+const labelsFile = "labels_%s.json" // Relative to DataDir
+
+// ErrInvalidLabel is returned for a label that is empty, contains spaces or looks like an address
+var ErrInvalidLabel = errors.New("invalid label")
+
+// Labels maps each label to the Base58 address it names
+type Labels map[string]string
+
+// LoadLabels reads the labels of a node; a missing file gives an empty set
+func LoadLabels(nodeID string) (Labels, error) {
+	labels := make(Labels)
+	filePath := DataPath(fmt.Sprintf(labelsFile, nodeID))
+
+	content, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return labels, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, &labels); err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return labels, nil
+}
+
+// Save writes the labels of a node to disk
+func (l Labels) Save(nodeID string) error {
+	content, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := EnsureDataDir(); err != nil {
+		return err
+	}
+	return os.WriteFile(DataPath(fmt.Sprintf(labelsFile, nodeID)), content, 0600)
+}
+
+// Set names address with label, replacing whatever address the label named before
+// A label can't be a valid address itself, or Resolve couldn't tell the two apart
+func (l Labels) Set(label, address string) error {
+	if label == "" || strings.ContainsAny(label, " \t\n") || ValidateAddress(label) {
+		return fmt.Errorf("%w: %q", ErrInvalidLabel, label)
+	}
+	if !ValidateAddress(address) {
+		return fmt.Errorf("invalid address %s", address)
+	}
+	l[label] = address
+	return nil
+}
+
+// Resolve returns the address named by label, or the string itself if it isn't a label
+func (l Labels) Resolve(labelOrAddress string) string {
+	if address, ok := l[labelOrAddress]; ok {
+		return address
+	}
+	return labelOrAddress
+}
+
+// LabelsOf returns every label naming address, sorted
+func (l Labels) LabelsOf(address string) []string {
+	var names []string
+	for label, labeled := range l {
+		if labeled == address {
+			names = append(names, label)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
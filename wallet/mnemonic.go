@@ -0,0 +1,69 @@
+package wallet
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 22/12/2025
+ * Time: 17:10
+ */
+
+// MNEMONIC BACKUP
+// A wallet is fully determined by its P-256 private scalar D, so the phrase simply
+// encodes D: D is written as a 32-byte big-endian number (left-padded with zeros),
+// and those 256 bits are the BIP39 entropy. BIP39 adds an 8-bit SHA256 checksum and
+// splits the 264 bits into 24 words of 11 bits each from the English wordlist.
+//
+// This is NOT a BIP32/BIP44 seed: there is no passphrase or key derivation, the words
+// are the key itself. A 12-word phrase carries only 128 bits, too few for a P-256
+// key, so phrases are always 24 words.
+//
+// The phrase doesn't record the wallet format, so it always restores a compressed-key
+// wallet; uncompressed (version 1) wallets are backed up with exportkey instead.
+
+const privateKeyLength = 32 // Size of a P-256 private scalar in bytes
+
+// ErrInvalidMnemonic is returned when a phrase isn't a valid 24-word wallet backup
+var ErrInvalidMnemonic = errors.New("invalid mnemonic phrase")
+
+// Mnemonic returns the 24-word phrase that restores this wallet with WalletFromMnemonic
+func (w *Wallet) Mnemonic() (string, error) {
+	if w.PrivateKey.D == nil {
+		return "", errors.New("wallet has no private key")
+	}
+	if w.Version != walletVersionCompressed {
+		return "", errors.New("uncompressed wallets can't be restored from a mnemonic, use exportkey")
+	}
+
+	entropy := make([]byte, privateKeyLength)
+	w.PrivateKey.D.FillBytes(entropy) // Panics only if D is wider than 32 bytes, impossible on P-256
+	return bip39.NewMnemonic(entropy)
+}
+
+// WalletFromMnemonic rebuilds the wallet (and so the same address) from its 24-word phrase
+func WalletFromMnemonic(phrase string) (*Wallet, error) {
+	entropy, err := bip39.EntropyFromMnemonic(phrase)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMnemonic, err)
+	}
+	if len(entropy) != privateKeyLength {
+		return nil, fmt.Errorf("%w: want 24 words, got a %d-bit phrase", ErrInvalidMnemonic, len(entropy)*8)
+	}
+
+	// The scalar must lie in [1, N-1] to be a P-256 private key
+	d := new(big.Int).SetBytes(entropy)
+	if d.Sign() == 0 || d.Cmp(elliptic.P256().Params().N) >= 0 {
+		return nil, fmt.Errorf("%w: not a valid P-256 private key", ErrInvalidMnemonic)
+	}
+
+	return walletFromD(entropy, walletVersionCompressed), nil
+}
@@ -0,0 +1,88 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 11:30
+ */
+
+func TestMnemonicRoundTrip(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		w := MakeWallet()
+		phrase, err := w.Mnemonic()
+		if err != nil {
+			t.Fatalf("Mnemonic: %v", err)
+		}
+		if words := len(strings.Fields(phrase)); words != 24 {
+			t.Fatalf("phrase has %d words, want 24", words)
+		}
+
+		restored, err := WalletFromMnemonic(phrase)
+		if err != nil {
+			t.Fatalf("WalletFromMnemonic: %v", err)
+		}
+		if restored.PrivateKey.D.Cmp(w.PrivateKey.D) != 0 || !bytes.Equal(restored.PublicKey, w.PublicKey) {
+			t.Fatal("restored wallet holds a different key")
+		}
+		if !bytes.Equal(restored.Address(), w.Address()) {
+			t.Fatalf("restored address %s, want %s", restored.Address(), w.Address())
+		}
+	}
+}
+
+func TestWalletFromMnemonicRejectsBadPhrases(t *testing.T) {
+	entropy := make([]byte, privateKeyLength)
+	for i := range entropy {
+		entropy[i] = byte(i + 1)
+	}
+	phrase, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := strings.Fields(phrase)
+	if _, err := WalletFromMnemonic(phrase); err != nil {
+		t.Fatalf("WalletFromMnemonic: %v", err)
+	}
+
+	short, err := bip39.NewMnemonic(make([]byte, 16)) // 12 words carry only 128 bits
+	if err != nil {
+		t.Fatal(err)
+	}
+	zero, err := bip39.NewMnemonic(make([]byte, privateKeyLength)) // D = 0 is no key
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapped := append([]string{}, words...) // Same words, so only the checksum catches it
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+
+	for name, bad := range map[string]string{
+		"empty":     "",
+		"12 words":  short,
+		"23 words":  strings.Join(words[:23], " "),
+		"swapped":   strings.Join(swapped, " "),
+		"zero key":  zero,
+		"not words": "correct horse battery staple",
+	} {
+		if _, err := WalletFromMnemonic(bad); !errors.Is(err, ErrInvalidMnemonic) {
+			t.Errorf("%s: WalletFromMnemonic = %v, want ErrInvalidMnemonic", name, err)
+		}
+	}
+}
+
+func TestUncompressedWalletHasNoMnemonic(t *testing.T) {
+	w := walletFromD(MakeWallet().PrivateKey.D.Bytes(), walletVersionUncompressed)
+	if _, err := w.Mnemonic(); err == nil {
+		t.Error("an uncompressed wallet produced a mnemonic it can't be restored from")
+	}
+}
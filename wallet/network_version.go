@@ -0,0 +1,44 @@
+package wallet
+
+import (
+	"os"
+
+	"github.com/golang-blockchain/logger"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 23/12/2025
+ * Time: 17:25
+ */
+
+// NETWORK VERSION
+// Every address starts with a version byte naming its network, so coins can't be sent
+// to a testnet address from mainnet (or the other way round) by mistake. The node picks
+// its network from the NETWORK env. var. ("mainnet" or "testnet", default mainnet);
+// addresses of any other network fail ValidateAddress.
+const (
+	MainnetVersion = byte(0x00) // Addresses start with 1
+	TestnetVersion = byte(0x6f) // Addresses start with m or n
+
+	defaultNetwork = "mainnet"
+)
+
+// NetworkVersion is the address version byte of the network this node runs on
+var NetworkVersion = loadNetworkVersion()
+
+// loadNetworkVersion reads the network from the NETWORK env. var.
+// Falls back to mainnet when the variable is unset or names an unknown network
+func loadNetworkVersion() byte {
+	switch network := os.Getenv("NETWORK"); network {
+	case "", "mainnet":
+		return MainnetVersion
+	case "testnet":
+		return TestnetVersion
+	default:
+		logger.Warn("Invalid NETWORK, using the default", "value", network, "default", defaultNetwork)
+		return MainnetVersion
+	}
+}
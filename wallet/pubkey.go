@@ -0,0 +1,130 @@
+package wallet
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 23/12/2025
+ * Time: 14:50
+ */
+
+// COMPRESSED PUBLIC KEYS
+// A point on the curve is fully determined by X and the parity of Y, so the public key
+// can be stored in 33 bytes instead of 64:
+//
+//	[0x02 if Y is even, 0x03 if Y is odd] + [X, 32 bytes big-endian]
+//
+// Wallets of format version 2 use this form (and so derive different addresses);
+// version 1 wallets keep the original uncompressed X || Y form, 64 bytes. Version 1
+// wallets were written with X.Bytes() || Y.Bytes(), which drops leading zero bytes, so
+// about 1 in 128 of them has a shorter key; it is kept as it was, because the wallet's
+// address (and its funds) hash it. ParsePubKey accepts all of these and nothing else.
+const (
+	compressedPubKeyLength   = 33
+	uncompressedPubKeyLength = 64
+	pubKeyEvenPrefix         = byte(0x02)
+	pubKeyOddPrefix          = byte(0x03)
+)
+
+// ErrInvalidPublicKey is returned when public key bytes don't describe a point on P-256
+var ErrInvalidPublicKey = errors.New("invalid public key")
+
+// CompressPubKey encodes a P-256 point in the 33-byte compressed form
+func CompressPubKey(x, y *big.Int) []byte {
+	compressed := make([]byte, compressedPubKeyLength)
+	compressed[0] = pubKeyEvenPrefix
+	if y.Bit(0) == 1 {
+		compressed[0] = pubKeyOddPrefix
+	}
+	x.FillBytes(compressed[1:])
+	return compressed
+}
+
+// UncompressedPubKey encodes a P-256 point as X || Y, each coordinate left-padded to 32 bytes
+// big.Int.Bytes drops leading zero bytes, so about 1 key in 128 would otherwise come out
+// shorter than 64 bytes
+func UncompressedPubKey(x, y *big.Int) []byte {
+	pubKey := make([]byte, uncompressedPubKeyLength)
+	x.FillBytes(pubKey[:uncompressedPubKeyLength/2])
+	y.FillBytes(pubKey[uncompressedPubKeyLength/2:])
+	return pubKey
+}
+
+// legacyPubKey encodes a P-256 point the way version 1 wallets always have: X.Bytes() || Y.Bytes()
+func legacyPubKey(x, y *big.Int) []byte {
+	return append(x.Bytes(), y.Bytes()...)
+}
+
+// curveY2 returns X³ - 3X + B (mod P), the square of Y for the point with this X
+func curveY2(x *big.Int) *big.Int {
+	params := elliptic.P256().Params()
+	x3 := new(big.Int).Exp(x, big.NewInt(3), params.P)
+	threeX := new(big.Int).Mul(x, big.NewInt(3))
+	ySquared := new(big.Int).Sub(x3, threeX)
+	ySquared.Add(ySquared, params.B)
+	return ySquared.Mod(ySquared, params.P)
+}
+
+// isOnCurve reports whether (x, y) is a point of P-256
+func isOnCurve(x, y *big.Int) bool {
+	p := elliptic.P256().Params().P
+	if x.Cmp(p) >= 0 || y.Cmp(p) >= 0 {
+		return false
+	}
+	return new(big.Int).Exp(y, big.NewInt(2), p).Cmp(curveY2(x)) == 0
+}
+
+// DecompressPubKey recovers the point from a 33-byte compressed public key
+// Y is the square root of X³ - 3X + B (mod P) with the parity given by the prefix
+func DecompressPubKey(pubKey []byte) (*big.Int, *big.Int, error) {
+	if len(pubKey) != compressedPubKeyLength || (pubKey[0] != pubKeyEvenPrefix && pubKey[0] != pubKeyOddPrefix) {
+		return nil, nil, ErrInvalidPublicKey
+	}
+
+	params := elliptic.P256().Params()
+	x := new(big.Int).SetBytes(pubKey[1:])
+	if x.Cmp(params.P) >= 0 {
+		return nil, nil, ErrInvalidPublicKey
+	}
+
+	// y² = x³ - 3x + b
+	y := new(big.Int).ModSqrt(curveY2(x), params.P)
+	if y == nil {
+		return nil, nil, ErrInvalidPublicKey // X is not on the curve
+	}
+	if y.Bit(0) != uint(pubKey[0]&1) {
+		y.Sub(params.P, y)
+	}
+	return x, y, nil
+}
+
+// ParsePubKey returns the point behind public key bytes in either form:
+// 33-byte compressed, or the uncompressed X || Y concatenation, 64 bytes or, for a
+// version 1 key with a coordinate starting with a zero byte, fewer
+func ParsePubKey(pubKey []byte) (*big.Int, *big.Int, error) {
+	switch size := len(pubKey); {
+	case size == compressedPubKeyLength:
+		return DecompressPubKey(pubKey)
+	case size == uncompressedPubKeyLength:
+		// Uncompressed: first half is X, second half is Y
+		x := new(big.Int).SetBytes(pubKey[:uncompressedPubKeyLength/2])
+		y := new(big.Int).SetBytes(pubKey[uncompressedPubKeyLength/2:])
+		return x, y, nil
+	case size > compressedPubKeyLength && size < uncompressedPubKeyLength:
+		// A shortened X || Y: the split is where both halves give a point on the curve
+		for xSize := size - uncompressedPubKeyLength/2; xSize <= uncompressedPubKeyLength/2; xSize++ {
+			x := new(big.Int).SetBytes(pubKey[:xSize])
+			y := new(big.Int).SetBytes(pubKey[xSize:])
+			if isOnCurve(x, y) {
+				return x, y, nil
+			}
+		}
+	}
+	return nil, nil, ErrInvalidPublicKey
+}
@@ -0,0 +1,145 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/gob"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 10:00
+ */
+
+// shortYKey generates keys until one has a Y coordinate starting with a zero byte
+func shortYKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	bound := new(big.Int).Lsh(big.NewInt(1), 248)
+	for i := 0; i < 1<<16; i++ {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key.Y.Cmp(bound) < 0 && key.X.Cmp(bound) >= 0 {
+			return key
+		}
+	}
+	t.Fatal("no key with a short Y")
+	return nil
+}
+
+func TestShortYVersion1WalletKeepsItsKey(t *testing.T) {
+	key := shortYKey(t)
+	legacy := append(key.X.Bytes(), key.Y.Bytes()...)
+	if len(legacy) != uncompressedPubKeyLength-1 {
+		t.Fatalf("legacy key is %d bytes, want %d", len(legacy), uncompressedPubKeyLength-1)
+	}
+
+	// A version 1 wallet saved before padding must reload with the same key and address
+	var saved bytes.Buffer
+	if err := gob.NewEncoder(&saved).Encode(&Wallet{PrivateKey: *key, PublicKey: legacy, Version: walletVersionUncompressed}); err != nil {
+		t.Fatal(err)
+	}
+	var loaded Wallet
+	if err := gob.NewDecoder(&saved).Decode(&loaded); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(loaded.PublicKey, legacy) {
+		t.Errorf("reloaded key %x, want %x", loaded.PublicKey, legacy)
+	}
+
+	// And the short key must still parse to the right point, so its funds can be spent
+	x, y, err := ParsePubKey(legacy)
+	if err != nil {
+		t.Fatalf("ParsePubKey: %v", err)
+	}
+	if x.Cmp(key.X) != 0 || y.Cmp(key.Y) != 0 {
+		t.Errorf("parsed (%x, %x), want (%x, %x)", x, y, key.X, key.Y)
+	}
+}
+
+func TestNewKeysArePadded(t *testing.T) {
+	key := shortYKey(t)
+	padded := UncompressedPubKey(key.X, key.Y)
+	if len(padded) != uncompressedPubKeyLength {
+		t.Fatalf("padded key is %d bytes, want %d", len(padded), uncompressedPubKeyLength)
+	}
+	x, y, err := ParsePubKey(padded)
+	if err != nil || x.Cmp(key.X) != 0 || y.Cmp(key.Y) != 0 {
+		t.Errorf("ParsePubKey(padded) = %x, %x, %v", x, y, err)
+	}
+}
+
+func TestParsePubKeyRejectsPointsOffTheCurve(t *testing.T) {
+	key := shortYKey(t)
+	legacy := append(key.X.Bytes(), key.Y.Bytes()...)
+	legacy[len(legacy)-1] ^= 1
+
+	if _, _, err := ParsePubKey(legacy); !errors.Is(err, ErrInvalidPublicKey) {
+		t.Errorf("ParsePubKey(off curve) = %v, want ErrInvalidPublicKey", err)
+	}
+	if _, _, err := ParsePubKey(make([]byte, 20)); !errors.Is(err, ErrInvalidPublicKey) {
+		t.Errorf("ParsePubKey(20 bytes) = %v, want ErrInvalidPublicKey", err)
+	}
+}
+
+func TestCompressDecompressRoundTrip(t *testing.T) {
+	parities := map[uint]bool{}
+	for i := 0; i < 64; i++ {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compressed := CompressPubKey(key.X, key.Y)
+		if len(compressed) != compressedPubKeyLength {
+			t.Fatalf("compressed key is %d bytes, want %d", len(compressed), compressedPubKeyLength)
+		}
+		x, y, err := DecompressPubKey(compressed)
+		if err != nil || x.Cmp(key.X) != 0 || y.Cmp(key.Y) != 0 {
+			t.Fatalf("DecompressPubKey = %x, %x, %v; want %x, %x", x, y, err, key.X, key.Y)
+		}
+		parities[key.Y.Bit(0)] = true
+	}
+	if len(parities) != 2 {
+		t.Error("only one parity of Y was exercised")
+	}
+}
+
+func TestDecompressPubKeyRejectsMalformed(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed := CompressPubKey(key.X, key.Y)
+
+	badPrefix := append([]byte{}, compressed...)
+	badPrefix[0] = 0x04
+	tooBig := append([]byte{pubKeyEvenPrefix}, bytes.Repeat([]byte{0xff}, 32)...) // X >= P
+	offCurve := append([]byte{}, compressed...)
+	for x := new(big.Int).SetBytes(offCurve[1:]); ; x.Add(x, big.NewInt(1)) {
+		if new(big.Int).ModSqrt(curveY2(x), elliptic.P256().Params().P) == nil {
+			x.FillBytes(offCurve[1:])
+			break
+		}
+	}
+
+	for name, pubKey := range map[string][]byte{
+		"short":     compressed[:32],
+		"prefix":    badPrefix,
+		"X >= P":    tooBig,
+		"off curve": offCurve,
+	} {
+		if _, _, err := DecompressPubKey(pubKey); !errors.Is(err, ErrInvalidPublicKey) {
+			t.Errorf("%s: DecompressPubKey = %v, want ErrInvalidPublicKey", name, err)
+		}
+	}
+}
@@ -9,6 +9,7 @@ package wallet
  */
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/mr-tron/base58"
@@ -30,6 +31,7 @@ func Base58Encode(input []byte) []byte {
 
 // Base58Decode converts a Base58-encoded string back to original binary data
 // This is the inverse operation of Base58Encode
+// It panics on malformed input; use Base58DecodeSafe for data that comes from users or peers
 func Base58Decode(input []byte) []byte {
 	// input is []byte containing Base58 characters
 	// Convert to string for the decode function
@@ -46,6 +48,15 @@ func Base58Decode(input []byte) []byte {
 	return decode
 }
 
+// Base58DecodeSafe is Base58Decode returning the decode error instead of panicking
+func Base58DecodeSafe(input []byte) ([]byte, error) {
+	decode, err := base58.Decode(string(input))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Base58 input: %w", err)
+	}
+	return decode, nil
+}
+
 // Base58 Character Set: WHY THESE CHARACTERS ARE ELIMINATED
 // ----------------------------------------------------------
 // Base58 removes 6 confusing characters from Base64:
@@ -0,0 +1,42 @@
+package wallet
+
+import (
+	"bytes"
+	"testing"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 12:00
+ */
+
+// notBase58 holds inputs with characters outside the Base58 alphabet
+var notBase58 = []string{"0", "O", "I", "l", "1A1zP1eP5QGefi2DMPTfTL5SLmv7Divf+a", "addr ess", "ünïcode"}
+
+func TestBase58RoundTrip(t *testing.T) {
+	for _, data := range [][]byte{{0}, {0, 0, 1}, {0xff}, []byte("hello world")} {
+		decoded, err := Base58DecodeSafe(Base58Encode(data))
+		if err != nil || !bytes.Equal(decoded, data) {
+			t.Errorf("round trip of %x = %x, %v", data, decoded, err)
+		}
+	}
+}
+
+func TestBase58DecodeSafeRejectsNonBase58(t *testing.T) {
+	for _, input := range notBase58 {
+		if _, err := Base58DecodeSafe([]byte(input)); err == nil {
+			t.Errorf("Base58DecodeSafe(%q) succeeded", input)
+		}
+	}
+}
+
+func TestValidateAddressRejectsNonBase58(t *testing.T) {
+	for _, input := range append(notBase58, "") {
+		if ValidateAddress(input) { // Must return, not panic
+			t.Errorf("ValidateAddress(%q) = true", input)
+		}
+	}
+}
@@ -23,9 +23,9 @@ import (
 
 // Wallet system constants
 const (
-	checksumLength = 4          // Length of checksum in bytes (used for error detection)
-	version        = byte(0x00) // Network version byte (0x00 for Bitcoin mainnet)
-	// The version byte is a critical identifier that tells the network which blockchain an address belongs to.
+	checksumLength = 4 // Length of checksum in bytes (used for error detection)
+	// The network version byte (NetworkVersion, see network_version.go) is a critical identifier
+	// that tells the network which blockchain an address belongs to.
 	// It's like an area code for cryptocurrencies.
 
 	/*  MAINNET STANDS FOR MAIN NETWORK
@@ -45,8 +45,16 @@ const (
 type Wallet struct {
 	PrivateKey ecdsa.PrivateKey // Private key for signing transactions (KEEP SECRET!)
 	PublicKey  []byte           // Public key for verification (can be shared)
+	Version    int              // Wallet format: walletVersionUncompressed or walletVersionCompressed
 }
 
+// Wallet format versions
+// The format decides how PublicKey is encoded, and with it the wallet's address
+const (
+	walletVersionUncompressed = 1 // PublicKey is X || Y (wallets created before compressed keys)
+	walletVersionCompressed   = 2 // PublicKey is the 33-byte compressed form (see pubkey.go)
+)
+
 // Address generates a human-readable blockchain address from the wallet's public key
 // This follows Bitcoin's address generation standard:
 // PublicKey → SHA256 → RIPEMD160 → Add version → Add checksum → Base58Encode
@@ -55,7 +63,7 @@ func (w Wallet) Address() []byte {
 	pubHash := PublicKeyHash(w.PublicKey)
 
 	// Step 2: Add version byte to identify network (0x00 = mainnet, 0x6f = testnet)
-	versionedHash := append([]byte{version}, pubHash...)
+	versionedHash := append([]byte{NetworkVersion}, pubHash...)
 
 	// Step 3: Calculate checksum for error detection (first 4 bytes of SHA256(SHA256(data)))
 	checksum := Checksum(versionedHash)
@@ -79,10 +87,14 @@ func (w Wallet) Address() []byte {
 // 1. The address can be Base58 decoded
 // 2. The structure is correct (version + pubkey hash + checksum)
 // 3. The checksum matches the calculated checksum
+// 4. The version byte matches this node's network (a testnet address is invalid on mainnet)
 func ValidateAddress(address string) bool {
 	// Step 1: Decode the Base58 address back to binary
 	// This gives us: [version(1)] + [pubKeyHash(20)] + [checksum(4)] = 25 bytes
-	pubKeyHash := Base58Decode([]byte(address))
+	pubKeyHash, err := Base58DecodeSafe([]byte(address))
+	if err != nil {
+		return false // Not Base58 at all
+	}
 
 	// Validate length: Should be exactly 25 bytes for Bitcoin-style addresses
 	// 1 byte version + 20 bytes hash + 4 bytes checksum = 25 bytes
@@ -102,7 +114,12 @@ func ValidateAddress(address string) bool {
 
 	// Step 4: Compare checksums
 	// If they match, the address is valid (no typos)
-	return bytes.Equal(actualChecksum, targetChecksum)
+	if !bytes.Equal(actualChecksum, targetChecksum) {
+		return false
+	}
+
+	// Step 5: A well-formed address of another network is still not ours
+	return addressVersion == NetworkVersion
 }
 
 // NewKeyPair generates a new ECDSA key pair for cryptocurrency transactions
@@ -118,18 +135,19 @@ func NewKeyPair() (ecdsa.PrivateKey, []byte) {
 		log.Panic(err)
 	}
 
-	// Public key is concatenation of X and Y coordinates (uncompressed format)
-	// In compressed format, you'd use X coordinate and parity bit
-	publicKey := append(private.PublicKey.X.Bytes(), private.PublicKey.Y.Bytes()...)
+	// Public key is concatenation of X and Y coordinates (uncompressed format), 32 bytes each
+	// MakeWallet re-encodes it with CompressPubKey (X coordinate and parity bit)
+	publicKey := UncompressedPubKey(private.PublicKey.X, private.PublicKey.Y)
 
 	return *private, publicKey
 }
 
 // MakeWallet creates a new wallet with a fresh key pair
-// This is the wallet constructor function
+// This is the wallet constructor function; new wallets use compressed public keys
 func MakeWallet() *Wallet {
-	privateKey, publicKey := NewKeyPair()
-	wallet := Wallet{privateKey, publicKey}
+	privateKey, _ := NewKeyPair()
+	publicKey := CompressPubKey(privateKey.PublicKey.X, privateKey.PublicKey.Y)
+	wallet := Wallet{privateKey, publicKey, walletVersionCompressed}
 	return &wallet
 }
 
@@ -167,14 +185,16 @@ func Checksum(payload []byte) []byte {
 }
 
 // GobEncode implements gob.GobEncoder.
-// We serialize only the private scalar D. Curve is fixed to P256, so we can
-// reconstruct the full key from D when decoding.
+// We serialize only the private scalar D and the wallet format version. Curve is
+// fixed to P256, so we can reconstruct the full key from D when decoding.
 func (w *Wallet) GobEncode() ([]byte, error) {
 	// Just store D (private scalar) as bytes.
 	data := struct {
-		D []byte
+		D       []byte
+		Version int
 	}{
-		D: w.PrivateKey.D.Bytes(),
+		D:       w.PrivateKey.D.Bytes(),
+		Version: w.Version,
 	}
 
 	var buf bytes.Buffer
@@ -189,7 +209,8 @@ func (w *Wallet) GobEncode() ([]byte, error) {
 // It restores the wallet by recreating the key on the P256 curve.
 func (w *Wallet) GobDecode(b []byte) error {
 	var data struct {
-		D []byte
+		D       []byte
+		Version int
 	}
 
 	dec := gob.NewDecoder(bytes.NewReader(b))
@@ -197,12 +218,24 @@ func (w *Wallet) GobDecode(b []byte) error {
 		return err
 	}
 
+	// Wallets saved before the version existed are uncompressed
+	if data.Version == 0 {
+		data.Version = walletVersionUncompressed
+	}
+	*w = *walletFromD(data.D, data.Version)
+
+	return nil
+}
+
+// walletFromD rebuilds a wallet from its private scalar D (big-endian bytes)
+// The public key is recomputed on the P256 curve as G * D and encoded for the format version
+func walletFromD(dBytes []byte, version int) *Wallet {
 	curve := elliptic.P256()
 
-	d := new(big.Int).SetBytes(data.D)
+	d := new(big.Int).SetBytes(dBytes)
 
 	// Recompute public key (G * D)
-	x, y := curve.ScalarBaseMult(data.D)
+	x, y := curve.ScalarBaseMult(dBytes)
 
 	priv := ecdsa.PrivateKey{
 		PublicKey: ecdsa.PublicKey{
@@ -213,8 +246,10 @@ func (w *Wallet) GobDecode(b []byte) error {
 		D: d,
 	}
 
-	w.PrivateKey = priv
-	w.PublicKey = append(x.Bytes(), y.Bytes()...)
-
-	return nil
+	// Version 1 keys stay unpadded, as they were created: the address hashes these bytes
+	publicKey := legacyPubKey(x, y)
+	if version == walletVersionCompressed {
+		publicKey = CompressPubKey(x, y)
+	}
+	return &Wallet{PrivateKey: priv, PublicKey: publicKey, Version: version}
 }
@@ -0,0 +1,64 @@
+package wallet
+
+import "testing"
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 11:40
+ */
+
+// onNetwork switches the node to the network with the given address version until the test ends
+func onNetwork(t *testing.T, version byte) {
+	t.Helper()
+
+	previous := NetworkVersion
+	NetworkVersion = version
+	t.Cleanup(func() { NetworkVersion = previous })
+}
+
+func TestTestnetAddress(t *testing.T) {
+	w := MakeWallet()
+
+	onNetwork(t, TestnetVersion)
+	testnet := string(w.Address())
+	if testnet[0] != 'm' && testnet[0] != 'n' {
+		t.Errorf("testnet address %s starts with %c, want m or n", testnet, testnet[0])
+	}
+	if !ValidateAddress(testnet) {
+		t.Errorf("testnet address %s is invalid on testnet", testnet)
+	}
+
+	onNetwork(t, MainnetVersion)
+	mainnet := string(w.Address())
+	if mainnet[0] != '1' {
+		t.Errorf("mainnet address %s starts with %c, want 1", mainnet, mainnet[0])
+	}
+	if ValidateAddress(testnet) {
+		t.Errorf("testnet address %s is valid on mainnet", testnet)
+	}
+	if !ValidateAddress(mainnet) {
+		t.Errorf("mainnet address %s is invalid on mainnet", mainnet)
+	}
+
+	onNetwork(t, TestnetVersion)
+	if ValidateAddress(mainnet) {
+		t.Errorf("mainnet address %s is valid on testnet", mainnet)
+	}
+}
+
+func TestLoadNetworkVersion(t *testing.T) {
+	for network, want := range map[string]byte{
+		"":        MainnetVersion,
+		"mainnet": MainnetVersion,
+		"testnet": TestnetVersion,
+		"regtest": MainnetVersion, // Unknown networks fall back to mainnet
+	} {
+		t.Setenv("NETWORK", network)
+		if got := loadNetworkVersion(); got != want {
+			t.Errorf("NETWORK=%q: version %#x, want %#x", network, got, want)
+		}
+	}
+}
@@ -3,10 +3,12 @@ package wallet
 import (
 	"bytes"
 	"encoding/gob"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"time"
 )
 
 /**
@@ -17,9 +19,16 @@ import (
  * Time: 12:53
  */
 
-// walletFile defines the persistent storage location for wallet data
+// walletFile defines the persistent storage location for wallet data, relative to DataDir
 // This file stores all wallets in serialized format for persistence across restarts
-const walletFile = "./tmp/wallets_%s.data"
+const walletFile = "wallets_%s.data"
+
+// ErrCorruptWalletFile is returned when the wallet file exists but can't be decoded
+// The file may still hold recoverable keys, so it must never be silently overwritten
+var ErrCorruptWalletFile = errors.New("wallet file is corrupt")
+
+// ErrWalletNotFound is returned when the collection holds no wallet for an address
+var ErrWalletNotFound = errors.New("wallet not found")
 
 // Wallets is a collection of cryptocurrency wallets
 // It manages multiple wallet instances, each with its own key pair and address
@@ -38,6 +47,7 @@ func CreateWallets(nodeID string) (*Wallets, error) {
 
 	// Attempt to load existing wallets from a file
 	// If a file doesn't exist, returns an empty wallet collection
+	// A corrupt file is reported as ErrCorruptWalletFile
 	err := wallets.LoadFile(nodeID)
 	return &wallets, err
 }
@@ -53,15 +63,21 @@ func (ws *Wallets) AddWallet() string {
 	address := fmt.Sprintf("%s", wallet.Address()) // Convert []byte to string
 
 	// Store the wallet in the map using address as a key
+	// The caller persists the collection with SaveFile(nodeID)
 	ws.Wallets[address] = wallet
 
-	// Persist to disk to prevent data loss
-	ws.SaveFile(address)
-
 	// Return the new address so the caller knows what was created
 	return address
 }
 
+// ImportWallet adds an existing wallet (e.g. from ImportKey) to the collection
+// Returns its address; importing a wallet that is already present changes nothing
+func (ws *Wallets) ImportWallet(w *Wallet) string {
+	address := string(w.Address())
+	ws.Wallets[address] = w
+	return address
+}
+
 // GetAllAddresses returns a list of all wallet addresses in the collection
 // Useful for displaying available wallets or iterating through them
 func (ws *Wallets) GetAllAddresses() []string {
@@ -76,19 +92,25 @@ func (ws *Wallets) GetAllAddresses() []string {
 }
 
 // GetWallet retrieves a specific wallet by its address
-// Returns nil if the address doesn't exist in the collection
-func (ws *Wallets) GetWallet(address string) Wallet {
-	return *ws.Wallets[address] // Map lookup - O(1) complexity
+// Returns ErrWalletNotFound if the address doesn't exist in the collection
+func (ws *Wallets) GetWallet(address string) (Wallet, error) {
+	w, ok := ws.Wallets[address] // Map lookup - O(1) complexity
+	if !ok {
+		return Wallet{}, fmt.Errorf("%w: no wallet for address %s", ErrWalletNotFound, address)
+	}
+	return *w, nil
 }
 
 // LoadFile reads wallet data from disk and deserializes it
 // This restores the wallet state from a previous session
+// Encrypted files are opened with WALLET_PASSPHRASE (see encryption.go);
+// a wrong or missing passphrase returns ErrWrongPassphrase / ErrPassphraseRequired
 func (ws *Wallets) LoadFile(nodeID string) error {
-	filePath := fmt.Sprintf(walletFile, nodeID)
+	filePath := DataPath(fmt.Sprintf(walletFile, nodeID))
 	// Check if a wallet file exists
-	// If not, return an error (a file will be created on the first SaveFile)
+	// If not, there is nothing to load (a file will be created on the first SaveFile)
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return err // File doesn't exist yet (first run)
+		return nil // File doesn't exist yet (first run)
 	}
 
 	var wallets Wallets
@@ -99,13 +121,20 @@ func (ws *Wallets) LoadFile(nodeID string) error {
 		return err // Can't read file (permissions, corrupted, etc.)
 	}
 
+	if isEncrypted(fileContent) {
+		fileContent, err = decryptWallets(fileContent, walletPassphrase())
+		if err != nil {
+			return fmt.Errorf("%s: %w", filePath, err)
+		}
+	}
+
 	// Create decoder to deserialize the binary data
 	decoder := gob.NewDecoder(bytes.NewReader(fileContent))
 
 	// Decode the binary data back into Wallets struct
 	err = decoder.Decode(&wallets)
 	if err != nil {
-		return err // Corrupted or incompatible data
+		return fmt.Errorf("%w: %s: %v", ErrCorruptWalletFile, filePath, err) // Corrupted or incompatible data
 	}
 
 	// Copy the loaded wallets into the current instance
@@ -114,11 +143,24 @@ func (ws *Wallets) LoadFile(nodeID string) error {
 	return nil
 }
 
+// BackupFile moves the node's wallet file aside to a timestamped backup and returns its path
+// Used before starting over after a corrupt file, so the old keys are kept for recovery
+func BackupFile(nodeID string) (string, error) {
+	filePath := DataPath(fmt.Sprintf(walletFile, nodeID))
+	backupPath := fmt.Sprintf("%s.corrupt-%d", filePath, time.Now().Unix())
+
+	if err := os.Rename(filePath, backupPath); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
+
 // SaveFile serializes all wallets to disk for persistence
 // This should be called whenever wallets are modified
+// With WALLET_PASSPHRASE set the file is encrypted (see encryption.go)
 func (ws *Wallets) SaveFile(nodeID string) {
 	var content bytes.Buffer // Buffer to hold serialized data
-	filePath := fmt.Sprintf(walletFile, nodeID)
+	filePath := DataPath(fmt.Sprintf(walletFile, nodeID))
 
 	// Create an encoder to serialize to binary format
 	encoder := gob.NewEncoder(&content)
@@ -129,9 +171,21 @@ func (ws *Wallets) SaveFile(nodeID string) {
 		log.Panic(err) // Should never happen unless data is corrupted
 	}
 
-	// Write serialized data to a file with user read/write permissions
-	// 0644 = an owner can read/write, others can read
-	err = ioutil.WriteFile(filePath, content.Bytes(), 0644)
+	data := content.Bytes()
+	if passphrase := walletPassphrase(); passphrase != "" {
+		data, err = encryptWallets(data, passphrase)
+		if err != nil {
+			log.Panic(err)
+		}
+	}
+
+	if err := EnsureDataDir(); err != nil {
+		log.Panic(err)
+	}
+
+	// Write serialized data to a file with owner-only read/write permissions
+	// 0600 = only the owner can read/write; the file holds private keys
+	err = ioutil.WriteFile(filePath, data, 0600)
 	if err != nil {
 		log.Panic(err) // Disk full, permissions, etc.
 	}
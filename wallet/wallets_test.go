@@ -0,0 +1,69 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/**
+ * Created by GoLand.
+ * Project: golang-blockchain
+ * User: PETER DANIEL KILIMBA
+ * Date: 27/12/2025
+ * Time: 15:50
+ */
+
+func TestGetWalletForAMissingAddress(t *testing.T) {
+	wallets := Wallets{Wallets: make(map[string]*Wallet)}
+	address := wallets.AddWallet()
+
+	w, err := wallets.GetWallet(address)
+	if err != nil || !bytes.Equal(w.Address(), []byte(address)) {
+		t.Fatalf("GetWallet(%s) = %s, %v; want its wallet", address, w.Address(), err)
+	}
+
+	missing := string(MakeWallet().Address())
+	_, err = wallets.GetWallet(missing)
+	if !errors.Is(err, ErrWalletNotFound) {
+		t.Fatalf("GetWallet of a missing address = %v, want ErrWalletNotFound", err)
+	}
+	if !strings.Contains(err.Error(), missing) {
+		t.Errorf("error %q does not name the address", err)
+	}
+}
+
+func TestWalletFileLivesInDataDir(t *testing.T) {
+	t.Setenv("DATA_DIR", "")
+	if dir := loadDataDir(); dir != defaultDataDir {
+		t.Errorf("data directory without DATA_DIR = %s, want %s", dir, defaultDataDir)
+	}
+
+	root := t.TempDir()
+	t.Setenv("DATA_DIR", root+"/node//")
+	t.Setenv("WALLET_PASSPHRASE", "")
+	dataDir := DataDir
+	DataDir = loadDataDir()
+	t.Cleanup(func() { DataDir = dataDir })
+	if want := filepath.Join(root, "node"); DataDir != want {
+		t.Fatalf("data directory from DATA_DIR = %s, want %s", DataDir, want)
+	}
+
+	// Saving creates the directory; loading reads the same file back
+	wallets := Wallets{Wallets: make(map[string]*Wallet)}
+	address := wallets.AddWallet()
+	wallets.SaveFile("3000")
+	if _, err := os.Stat(filepath.Join(root, "node", "wallets_3000.data")); err != nil {
+		t.Fatalf("wallet file not in the data directory: %v", err)
+	}
+	loaded, err := CreateWallets("3000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loaded.GetWallet(address); err != nil {
+		t.Errorf("wallet saved in the data directory does not load back: %v", err)
+	}
+}